@@ -21,6 +21,16 @@ type AddFileParams struct {
 	Mode    revision.FileMode
 	// Scan the file based on a small subset of available information which is assumed to be unchanging
 	AddLazy bool
+	// Don't record the modification time and POSIX mode of each file
+	NoMetadata bool
+	// If not empty, split every file with this chunker
+	Chunker string
+	// The number of files to cache at once. If 0, the number of CPUs is used
+	Jobs int
+	// Add files even if they match a pattern in .fawsignore
+	NoIgnore bool
+	// Read every file again, even if it appears unchanged since it was last added
+	Rehash bool
 	// Display all files that are cached
 	Verbose bool
 }
@@ -60,6 +70,21 @@ func AddFile(params *AddFileParams) {
 	if params.AddLazy {
 		o = append(o, repo.WithLazy(true))
 	}
+	if params.NoMetadata {
+		o = append(o, repo.WithMetadata(false))
+	}
+	if params.Chunker != "" {
+		o = append(o, repo.WithChunker(params.Chunker))
+	}
+	if params.NoIgnore {
+		o = append(o, repo.WithIgnore(false))
+	}
+	if params.Rehash {
+		o = append(o, repo.WithRehash(true))
+	}
+	if params.Jobs > 0 {
+		o = append(o, repo.WithWorkers(params.Jobs))
+	}
 
 	// Users can specify already-existing objects to add to the index
 	if params.SourceIsRef {
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"net/url"
+	"path/filepath"
+
+	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/identity"
+	"github.com/faws-vcs/faws/faws/repo/cas"
+)
+
+// BundleCreateParams are the input parameters to the command "faws bundle create", [BundleCreate]
+type BundleCreateParams struct {
+	// The repository being bundled
+	Directory string
+	// The directory to write the bundle into
+	Output string
+	// The tags to bundle. If empty, every tag is bundled
+	Tags []string
+	// Which identity to use to sign the bundle manifest
+	Sign string
+
+	// Sets the maximum file size of an archive (objects.XXXXXX)
+	MaxArchiveSize int64
+	// If greater than zero, similar parts are stored as deltas, in chains no deeper than this
+	DeltaChainDepth int
+}
+
+// BundleCreate is the implementation of the command "faws bundle create"
+func BundleCreate(params *BundleCreateParams) {
+	app.Open()
+	defer func() {
+		app.Close()
+	}()
+
+	if err := cas.CheckDeltaChainDepth(params.DeltaChainDepth); err != nil {
+		app.Fatal(err)
+	}
+
+	if err := Open(params.Directory); err != nil {
+		app.Fatal(err)
+	}
+
+	var (
+		signing_identity     identity.Pair
+		publisher_attributes identity.Attributes
+	)
+
+	ring := app.Configuration.Ring()
+
+	var err error
+	if params.Sign == "" {
+		err = ring.GetPrimaryPair(&signing_identity, &publisher_attributes)
+		if err != nil {
+			app.Warning("You don't seem to have a signing identity yet")
+			app.Quote("faws id create")
+			app.Info("to create one")
+		}
+	} else {
+		err = ring.GetNametagPair(params.Sign, &signing_identity, &publisher_attributes)
+	}
+	if err != nil {
+		Close()
+		app.Fatal(err)
+	}
+
+	var options []cas.PackWriterOption
+	if params.DeltaChainDepth > 0 {
+		options = append(options, cas.WithDeltas(params.DeltaChainDepth))
+	}
+
+	if err = Repo.Bundle(params.Output, &signing_identity, &publisher_attributes, params.MaxArchiveSize, params.Tags, options...); err != nil {
+		Close()
+		app.Fatal(err)
+	}
+
+	Close()
+
+	output, err := filepath.Abs(params.Output)
+	if err != nil {
+		app.Fatal(err)
+	}
+	// the URI names the publisher, so that clones refuse a bundle signed by anyone else
+	var bundle_uri url.URL
+	bundle_uri.Scheme = "bundle"
+	bundle_uri.Path = filepath.ToSlash(output)
+	bundle_uri.RawQuery = "publisher=" + signing_identity.ID().String()
+	app.Info("The bundle was written. It can be cloned with:")
+	app.Quote("faws clone '" + bundle_uri.String() + "'")
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"io"
 	"os"
 
 	"github.com/faws-vcs/faws/faws/app"
@@ -32,10 +33,13 @@ func CatFile(params *CatFileParams) {
 	if err != nil {
 		app.Fatal(err)
 	}
-	prefix, object, err := Repo.LoadObject(hash)
+	// objects are streamed, so that large parts are never held in memory
+	prefix, object_content, _, err := Repo.OpenObject(hash)
 	if err != nil {
 		app.Fatal(err)
 	}
+	defer object_content.Close()
+
 	if params.PrettyPrint {
 		switch prefix {
 		case cas.Tree:
@@ -47,12 +51,22 @@ func CatFile(params *CatFileParams) {
 		case cas.Commit:
 			display_commit(hash)
 		case cas.File:
+			object, err := io.ReadAll(object_content)
+			if err != nil {
+				app.Fatal(err)
+			}
 			var file_part cas.ContentID
 			for len(object) > 0 {
 				copy(file_part[:], object[:cas.ContentIDSize])
 				app.Info(file_part)
 				object = object[cas.ContentIDSize:]
 			}
+		case cas.Link:
+			target, err := Repo.Link(hash)
+			if err != nil {
+				app.Fatal(err)
+			}
+			app.Info(target)
 		case cas.Part:
 			app.Info("run without -p, --pretty-print to output raw data")
 		default:
@@ -63,20 +77,17 @@ func CatFile(params *CatFileParams) {
 
 	switch prefix {
 	case cas.File:
-		var file_part cas.ContentID
-		for len(object) > 0 {
-			copy(file_part[:], object[:cas.ContentIDSize])
-			object = object[cas.ContentIDSize:]
-			_, part_object, err := Repo.LoadObject(file_part)
-			if err != nil {
-				app.Fatal(err)
-			}
-			if _, err = os.Stdout.Write(part_object); err != nil {
-				app.Fatal(err)
-			}
+		file, err := Repo.OpenFile(hash)
+		if err != nil {
+			app.Fatal(err)
+		}
+		_, err = io.Copy(os.Stdout, file)
+		file.Close()
+		if err != nil {
+			app.Fatal(err)
 		}
 	default:
-		if _, err = os.Stdout.Write(object); err != nil {
+		if _, err = io.Copy(os.Stdout, object_content); err != nil {
 			app.Fatal(err)
 		}
 	}
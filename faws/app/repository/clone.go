@@ -4,8 +4,10 @@ import (
 	"os"
 
 	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/app/identities"
 	"github.com/faws-vcs/faws/faws/fs"
 	"github.com/faws-vcs/faws/faws/repo"
+	"github.com/faws-vcs/faws/faws/repo/remote"
 )
 
 // CloneParams are the input parameters to the command "faws clone", [Clone]
@@ -14,6 +16,8 @@ type CloneParams struct {
 	Directory  string
 	Remote     string
 	Force      bool
+	// Object directories of other local repositories to borrow objects from, instead of downloading them
+	Alternates []string
 }
 
 // Clone is the implementation of the command "faws clone"
@@ -45,7 +49,11 @@ func Clone(params *CloneParams) {
 	}
 
 	if !repo.Exists(params.Directory) {
-		if err := repo.Initialize(params.Directory, params.Remote, false, params.Force); err != nil {
+		credentials, err := origin_credentials(params.Remote)
+		if err != nil {
+			app.Fatal(err)
+		}
+		if err := repo.Initialize(params.Directory, params.Remote, false, params.Force, repo.WithOriginOptions(remote.WithCredentials(credentials), remote.WithTrust(identities.NewRingTrust(app.Configuration.Ring()).Check))); err != nil {
 			app.Fatal(err)
 		}
 	}
@@ -54,6 +62,12 @@ func Clone(params *CloneParams) {
 		app.Fatal(err)
 	}
 
+	for _, alternate := range params.Alternates {
+		if err := Repo.AddAlternate(alternate); err != nil {
+			app.Fatal(err)
+		}
+	}
+
 	if err := Repo.Clone(); err != nil {
 		app.Fatal(err)
 	}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/repo"
+	"github.com/faws-vcs/faws/faws/repo/cas"
+)
+
+// DiffParams are the input parameters to the command "faws diff", [Diff]
+type DiffParams struct {
+	Directory string
+	// The old commit or tree
+	Ref1 string
+	// The new commit or tree
+	Ref2 string
+	// If true, display only the status letter and path of each difference
+	NameStatus bool
+}
+
+func display_difference(tw *tabwriter.Writer, difference *repo.Difference) {
+	switch {
+	case difference.Status&repo.DiffAdded != 0:
+		fmt.Fprintf(tw, "added\t%s\t%s\n", difference.Path, difference.Prefix2)
+	case difference.Status&repo.DiffRemoved != 0:
+		fmt.Fprintf(tw, "removed\t%s\t%s\n", difference.Path, difference.Prefix1)
+	default:
+		var details string
+		if difference.Status&repo.DiffModified != 0 {
+			if difference.Prefix2 == cas.File && difference.SharedParts+difference.NewParts == 0 {
+				// files of different sizes are known to differ without counting their parts
+				details = "size changed"
+			} else if difference.Prefix2 == cas.File {
+				details = fmt.Sprintf("%d parts shared, %d new", difference.SharedParts, difference.NewParts)
+			} else {
+				details = difference.Prefix2.String()
+			}
+		}
+		if difference.Status&repo.DiffModeChanged != 0 {
+			if details != "" {
+				details += ", "
+			}
+			details += fmt.Sprintf("mode %s -> %s", difference.Mode1, difference.Mode2)
+		}
+		status := "modified"
+		if difference.Status&repo.DiffModified == 0 {
+			status = "mode"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", status, difference.Path, details)
+	}
+}
+
+// Diff is the implementation of the command "faws diff"
+//
+// It displays the paths that were added, removed or changed between two commits or trees.
+func Diff(params *DiffParams) {
+	app.Open()
+	defer func() {
+		app.Close()
+	}()
+
+	if err := Open(params.Directory); err != nil {
+		app.Fatal(err)
+	}
+
+	object1, err := Repo.ParseRef(params.Ref1)
+	if err != nil {
+		app.Fatal(err)
+	}
+	object2, err := Repo.ParseRef(params.Ref2)
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	differences, err := Repo.DiffTrees(object1, object2)
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	if params.NameStatus {
+		for _, difference := range differences {
+			app.Info(fmt.Sprintf("%s\t%s", difference.Status, difference.Path))
+		}
+	} else {
+		var (
+			tw                       tabwriter.Writer
+			added, removed, modified int
+			shared_parts, new_parts  int
+		)
+		tw.Init(os.Stdout, 0, 0, 2, ' ', 0)
+		for i := range differences {
+			difference := &differences[i]
+			display_difference(&tw, difference)
+			switch {
+			case difference.Status&repo.DiffAdded != 0:
+				added++
+			case difference.Status&repo.DiffRemoved != 0:
+				removed++
+			default:
+				modified++
+			}
+			shared_parts += difference.SharedParts
+			new_parts += difference.NewParts
+		}
+		tw.Flush()
+
+		if len(differences) == 0 {
+			app.Info("no differences")
+		} else {
+			app.Info()
+			app.Info(fmt.Sprintf("%d added, %d removed, %d changed (%d parts shared, %d new)", added, removed, modified, shared_parts, new_parts))
+		}
+	}
+
+	if err := Close(); err != nil {
+		app.Fatal(err)
+	}
+}
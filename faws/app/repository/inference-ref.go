@@ -2,9 +2,11 @@ package repository
 
 import (
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/repo/cas"
 	"github.com/faws-vcs/faws/faws/validate"
 	"github.com/spf13/cobra"
 )
@@ -20,6 +22,20 @@ func InferenceRef(params *InferenceRefParams) {
 		app.Fatal(err)
 	}
 
+	if strings.ContainsAny(params.Ref, "~^@:") {
+		infer_ref_suffix(params)
+	} else {
+		infer_ref_name(params)
+	}
+
+	if err := Close(); err != nil {
+		app.Fatal(err)
+		return
+	}
+}
+
+// completes a tag or an abbreviated hash
+func infer_ref_name(params *InferenceRefParams) {
 	tags, err := Repo.Tags()
 	if err != nil {
 		app.Fatal(err)
@@ -36,15 +52,47 @@ func InferenceRef(params *InferenceRefParams) {
 			params.Inferences = append(params.Inferences, parsed_ref.String())
 		}
 	}
-	if err := Close(); err != nil {
-		app.Fatal(err)
+}
+
+// completes the path after a ':' with the names in the tree, otherwise the ref is only offered if it can be resolved
+func infer_ref_suffix(params *InferenceRefParams) {
+	colon := strings.IndexByte(params.Ref, ':')
+	if colon < 0 {
+		if _, err := Repo.ParseRef(params.Ref); err == nil {
+			params.Inferences = append(params.Inferences, params.Ref)
+		}
+		return
+	}
+
+	// the tree containing the name being typed
+	directory, name := "", params.Ref[colon+1:]
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		directory, name = name[:i+1], name[i+1:]
+	}
+	tree_hash, err := Repo.ParseRef(params.Ref[:colon+1] + directory)
+	if err != nil {
 		return
 	}
+	tree, err := Repo.Tree(tree_hash)
+	if err != nil {
+		return
+	}
+	for _, entry := range tree.Entries {
+		if !strings.HasPrefix(entry.Name, name) {
+			continue
+		}
+		inference := params.Ref[:colon+1] + directory + entry.Name
+		if entry.Prefix == cas.Tree {
+			inference += "/"
+		}
+		params.Inferences = append(params.Inferences, inference)
+	}
 }
 
-func InferenceRefArg(n int) cobra.CompletionFunc {
+// InferenceRefArg completes a ref, if it is typed at one of the argument positions
+func InferenceRefArg(positions ...int) cobra.CompletionFunc {
 	return func(cmd *cobra.Command, args []string, toComplete string) (completion []cobra.Completion, shell_comp_directive cobra.ShellCompDirective) {
-		if len(args) != n {
+		if !slices.Contains(positions, len(args)) {
 			return
 		}
 		working_directory, err := os.Getwd()
@@ -1,8 +1,17 @@
 package repository
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+
 	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/app/identities"
+	"github.com/faws-vcs/faws/faws/multipart"
 	"github.com/faws-vcs/faws/faws/repo"
+	"github.com/faws-vcs/faws/faws/repo/remote"
 )
 
 // InitParams are the input parameters to the command "faws init", [Init]
@@ -10,6 +19,61 @@ type InitParams struct {
 	Directory string
 	Remote    string
 	Force     bool
+	// The sizes of file parts, e.g. "64KiB". If any of these or ChunkPolynomial are set, files added to the repository
+	// are split according to them, and the rest keep their defaults
+	ChunkMin     string
+	ChunkAverage string
+	ChunkMax     string
+	// The polynomial of the rolling hash in hexadecimal, or "random"
+	ChunkPolynomial string
+}
+
+// returns the chunking parameters if any were given
+func (p *InitParams) chunking_params() (chunking *multipart.ChunkingParams, err error) {
+	if p.ChunkMin == "" && p.ChunkAverage == "" && p.ChunkMax == "" && p.ChunkPolynomial == "" {
+		return
+	}
+
+	// anything not given keeps its default
+	chunking = new(multipart.ChunkingParams)
+	*chunking = multipart.DefaultChunkingParams
+
+	for _, size_param := range []struct {
+		value string
+		size  *int64
+	}{
+		{p.ChunkMin, &chunking.MinSize},
+		{p.ChunkAverage, &chunking.AverageSize},
+		{p.ChunkMax, &chunking.MaxSize},
+	} {
+		if size_param.value == "" {
+			continue
+		}
+		var size uint64
+		size, err = humanize.ParseBytes(size_param.value)
+		if err != nil {
+			err = fmt.Errorf("%w: %q is not a size", multipart.ErrBadChunkingParams, size_param.value)
+			return
+		}
+		*size_param.size = int64(size)
+	}
+
+	switch p.ChunkPolynomial {
+	case "":
+	case "random":
+		chunking.Polynomial, err = multipart.RandomPolynomial()
+	default:
+		chunking.Polynomial, err = strconv.ParseUint(strings.TrimPrefix(p.ChunkPolynomial, "0x"), 16, 64)
+		if err != nil {
+			err = fmt.Errorf("%w: %q is not a hexadecimal polynomial", multipart.ErrBadChunkingParams, p.ChunkPolynomial)
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	err = chunking.Check()
+	return
 }
 
 // Init is the implementation of the command "faws init"
@@ -21,6 +85,11 @@ func Init(p *InitParams) {
 		app.Close()
 	}()
 
+	chunking, err := p.chunking_params()
+	if err != nil {
+		app.Fatal(err)
+	}
+
 	initialized := repo.Exists(p.Directory)
 	reinitialize := false
 
@@ -28,7 +97,19 @@ func Init(p *InitParams) {
 		reinitialize = true
 	}
 
-	if err := repo.Initialize(p.Directory, p.Remote, reinitialize, p.Force); err != nil {
+	var o []repo.InitializeOption
+	if p.Remote != "" {
+		credentials, err := origin_credentials(p.Remote)
+		if err != nil {
+			app.Fatal(err)
+		}
+		o = append(o, repo.WithOriginOptions(remote.WithCredentials(credentials), remote.WithTrust(identities.NewRingTrust(app.Configuration.Ring()).Check)))
+	}
+	if chunking != nil {
+		o = append(o, repo.WithChunkingParams(*chunking))
+	}
+
+	if err := repo.Initialize(p.Directory, p.Remote, reinitialize, p.Force, o...); err != nil {
 		app.Fatal(err)
 	}
 	if reinitialize {
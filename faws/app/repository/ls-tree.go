@@ -17,7 +17,17 @@ func list_tree_object(recurse bool, tree *revision.Tree, path string) {
 	for _, entry := range tree.Entries {
 		switch entry.Prefix {
 		case cas.File:
-			app.Info(entry.Mode, "file", entry.Content, "  ", path+entry.Name)
+			if entry.Metadata.IsZero() {
+				app.Info(entry.Mode, "file", entry.Content, "  ", path+entry.Name)
+			} else {
+				app.Info(entry.Mode, "file", entry.Content, "  ", path+entry.Name, " ", entry.Metadata)
+			}
+		case cas.Link:
+			target, err := Repo.Link(entry.Content)
+			if err != nil {
+				app.Fatal(err)
+			}
+			app.Info(entry.Mode, "link", entry.Content, "  ", path+entry.Name, "->", target)
 		case cas.Tree:
 			if recurse {
 				sub_tree, err := Repo.Tree(entry.Content)
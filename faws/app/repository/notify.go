@@ -2,6 +2,8 @@ package repository
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
@@ -18,17 +20,23 @@ const (
 	summarize_pruning = 1 << iota
 )
 
+const (
+	// the most files being cached that are displayed at once
+	max_caching_files_shown = 8
+)
+
 var (
 	stages_text = map[event.Stage]string{
 		event.StagePullObjects:  "Retrieve objects",
 		event.StagePullTags:     "Retrieve tags",
 		event.StageCacheFiles:   "Cache files",
-		event.StageCacheFile:    "Cache file",
 		event.StageWriteTree:    "Write tree",
 		event.StageCheckout:     "Checkout",
 		event.StageServeObjects: "Distribute objects",
 		event.StageVisitObjects: "Visit objects",
 		event.StagePackObjects:  "Pack objects",
+		event.StageCompareFiles: "Compare files",
+		event.StagePushObjects:  "Send objects",
 	}
 
 	scrn activity_screen
@@ -40,6 +48,13 @@ type activity_stage struct {
 	is_child bool
 }
 
+// a file being cached by one of the staging workers
+type caching_file struct {
+	origin   string
+	size     int64
+	progress int64
+}
+
 type activity_screen struct {
 	guard sync.RWMutex
 
@@ -62,6 +77,9 @@ type activity_screen struct {
 	current_file_origin   string
 	current_file_name     string
 
+	// files being cached at the same time, by destination
+	caching_files map[string]*caching_file
+
 	connected_peers int
 
 	received_messages              int64
@@ -70,6 +88,10 @@ type activity_screen struct {
 	duplicate_object_downloads     int64
 	duplicate_object_download_size uint64
 
+	objects_sent    int
+	objects_to_send int
+	bytes_sent      uint64
+
 	objects_visited        uint64
 	objects_in_visit_queue uint64
 	objects_pruned         uint64
@@ -129,6 +151,8 @@ func prefix(p cas.Prefix) string {
 		return "file"
 	case cas.Part:
 		return "part"
+	case cas.Link:
+		return "link"
 	default:
 		return ""
 	}
@@ -146,19 +170,40 @@ func notify(ev event.Notification, params *event.NotifyParams) {
 		// don't try to call app.Info while modifying the activity screen state : it will lead to DEADLOCK
 		// as the hud is already trying to get a lock when an update is being notified, these can never become unlocked
 		scrn.guard.Lock()
-		scrn.current_file_size = params.Count
-		scrn.current_file_progress = 0
-		scrn.current_file_origin = params.Name2
+		if params.Count > 0 {
+			if scrn.caching_files == nil {
+				scrn.caching_files = make(map[string]*caching_file)
+			}
+			scrn.caching_files[params.Name1] = &caching_file{origin: params.Name2, size: params.Count}
+		}
 		scrn.guard.Unlock()
 
 	case event.NotifyCacheFilePart:
 
 		scrn.guard.Lock()
-		scrn.current_file_progress += params.Count
+		if file, ok := scrn.caching_files[params.Name1]; ok {
+			file.progress += params.Count
+			if file.progress >= file.size {
+				delete(scrn.caching_files, params.Name1)
+			}
+		}
+		scrn.guard.Unlock()
+
+	case event.NotifyCacheFileFailed:
+		// the file won't make any more progress
+		scrn.guard.Lock()
+		delete(scrn.caching_files, params.Name1)
 		scrn.guard.Unlock()
 
 	case event.NotifyCacheUsedLazySignature:
+		scrn.guard.Lock()
+		delete(scrn.caching_files, params.Name1)
+		scrn.guard.Unlock()
 		app.Info("using precached file (--lazy)", params.Name1, params.Name2)
+	case event.NotifyCacheUsedStat:
+		if scrn.verbose {
+			app.Info("unchanged", params.Name1)
+		}
 	case event.NotifyIndexRemoveFile:
 		app.Info(fmt.Sprintf("rm '%s'", params.Name1))
 	case event.NotifyPullTag:
@@ -184,6 +229,24 @@ func notify(ev event.Notification, params *event.NotifyParams) {
 		)
 
 		update_pull_info(int(object_size), object_prefix, object_hash)
+	case event.NotifyPushObject:
+		scrn.guard.Lock()
+		scrn.objects_sent++
+		scrn.bytes_sent += uint64(params.Count)
+		scrn.guard.Unlock()
+		if scrn.verbose {
+			app.Info("sent", params.Prefix, params.Object1)
+		}
+	case event.NotifyPushQueueCount:
+		scrn.guard.Lock()
+		scrn.objects_to_send = int(params.Count)
+		scrn.guard.Unlock()
+	case event.NotifyPushTag:
+		if params.Object1 == cas.Nil {
+			app.Info("created tag", params.Name1+":", params.Object2)
+		} else {
+			app.Info("updated tag", params.Name1+":", params.Object1, "=>", params.Object2)
+		}
 	case event.NotifyTagQueueCount:
 		scrn.guard.Lock()
 		scrn.tags_in_queue = int(params.Count)
@@ -195,6 +258,8 @@ func notify(ev event.Notification, params *event.NotifyParams) {
 		scrn.guard.Unlock()
 	case event.NotifyCorruptedObject:
 		app.Warning("corrupted object", params.Prefix, params.Object1)
+	case event.NotifyChunkerFallback:
+		app.Warning(fmt.Sprintf("'%s' could not be split by the %s chunker, using the generic chunker instead: %s", params.Name1, params.Name2, params.Err))
 	case event.NotifyRemovedCorruptedObject:
 		app.Warning("removed corrupted object", params.Prefix, params.Object1)
 	case event.NotifyPruneObject:
@@ -391,21 +456,45 @@ func render_activity_screen(hud *console.Hud) {
 		progress_bar.Stylesheet.Width = console.Width()
 		progress_bar.Progress = float64(scrn.objects_received) / float64(scrn.objects_in_queue)
 		hud.Line(&progress_bar)
-	case event.StageCacheFile:
-		var file_name_text console.Text
-		file_name_text.Stylesheet.Width = console.Width()
-		file_name_text.Add(scrn.current_file_origin, 0, 0)
+	case event.StagePushObjects:
+		var usage_text console.Text
+		usage_text.Stylesheet.Width = console.Width()
+		usage_text.Stylesheet.Margin[console.Left] = 1
+		usage_text.Add(fmt.Sprintf("%d/%d objects sent, %s total", scrn.objects_sent, scrn.objects_to_send, humanize.Bytes(scrn.bytes_sent)), 0, 0)
+		hud.Line(&usage_text)
 
-		var progress_text console.Text
-		progress_text.Stylesheet.Width = 16
-		progress_text.Add(fmt.Sprintf("%s/%s", humanize.Bytes(uint64(scrn.current_file_progress)), humanize.Bytes(uint64(scrn.current_file_size))), 0, 0)
+		if scrn.objects_to_send > 0 {
+			progress_bar.Stylesheet.Width = console.Width()
+			progress_bar.Progress = float64(scrn.objects_sent) / float64(scrn.objects_to_send)
+			hud.Line(&progress_bar)
+		}
+	case event.StageCacheFiles, event.StageCompareFiles:
+		caching_file_names := slices.Sorted(maps.Keys(scrn.caching_files))
+		for i, name := range caching_file_names {
+			if i == max_caching_files_shown {
+				var more_text console.Text
+				more_text.Stylesheet.Width = console.Width()
+				more_text.Add(fmt.Sprintf("and %d more", len(caching_file_names)-i), 0, 0)
+				hud.Line(&more_text)
+				break
+			}
+			file := scrn.caching_files[name]
 
-		progress_bar.Stylesheet.Width = console.Width() - 16
-		progress_bar.Stylesheet.Alignment = console.Right
-		progress_bar.Progress = float64(scrn.current_file_progress) / float64(scrn.current_file_size)
+			var progress_text console.Text
+			progress_text.Stylesheet.Width = 16
+			progress_text.Add(fmt.Sprintf("%s/%s", humanize.Bytes(uint64(file.progress)), humanize.Bytes(uint64(file.size))), 0, 0)
 
-		hud.Line(&file_name_text)
-		hud.Line(&progress_text, &progress_bar)
+			var file_progress_bar = progress_bar
+			file_progress_bar.Stylesheet.Width = 24
+			file_progress_bar.Progress = float64(file.progress) / float64(file.size)
+
+			var file_name_text console.Text
+			file_name_text.Stylesheet.Width = max(console.Width()-progress_text.Stylesheet.Width-file_progress_bar.Stylesheet.Width-1, 0)
+			file_name_text.Stylesheet.Margin[console.Left] = 1
+			file_name_text.Add(file.origin, 0, 0)
+
+			hud.Line(&progress_text, &file_progress_bar, &file_name_text)
+		}
 	case event.StageCheckout:
 		var file_name_text console.Text
 		file_name_text.Stylesheet.Width = console.Width()
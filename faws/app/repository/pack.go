@@ -1,6 +1,9 @@
 package repository
 
-import "github.com/faws-vcs/faws/faws/app"
+import (
+	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/repo/cas"
+)
 
 type PackParams struct {
 	// The directory where the repository is located
@@ -11,6 +14,11 @@ type PackParams struct {
 
 	// Sets the maximum file size of an archive (pack.XXXXXX)
 	MaxArchiveSize int64
+	// If greater than zero, similar parts are stored as deltas, in chains no deeper than this
+	DeltaChainDepth int
+
+	// Only pack cached objects, adding the new pack to the repository
+	Incremental bool
 }
 
 func Pack(params *PackParams) {
@@ -19,11 +27,20 @@ func Pack(params *PackParams) {
 		app.Close()
 	}()
 
+	if err := cas.CheckDeltaChainDepth(params.DeltaChainDepth); err != nil {
+		app.Fatal(err)
+	}
+
 	if err := Open(params.Directory); err != nil {
 		app.Fatal(err)
 	}
 
-	if err := Repo.Pack(params.Name, params.MaxArchiveSize); err != nil {
+	var options []cas.PackWriterOption
+	if params.DeltaChainDepth > 0 {
+		options = append(options, cas.WithDeltas(params.DeltaChainDepth))
+	}
+
+	if err := Repo.Pack(params.Name, params.MaxArchiveSize, params.Incremental, options...); err != nil {
 		app.Fatal(err)
 	}
 
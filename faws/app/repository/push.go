@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"github.com/faws-vcs/faws/faws/app"
+)
+
+// PushParams are the input parameters to the command "faws push", [Push]
+type PushParams struct {
+	Directory string
+	// The tags to push. If empty, every tag is pushed
+	Tags []string
+	// Replace tags in the origin even if they point to commits which aren't ancestors of ours
+	Force   bool
+	Verbose bool
+	Quiet   bool
+}
+
+// Push is the implementation of the command "faws push"
+//
+// It uploads the objects of the tagged commits which the origin is missing, then updates the tags in the origin.
+func Push(params *PushParams) {
+	quiet = params.Quiet
+	app.Open()
+	defer func() {
+		app.Close()
+	}()
+
+	if err := Open(params.Directory); err != nil {
+		app.Fatal(err)
+	}
+
+	scrn.verbose = params.Verbose
+
+	if err := Repo.Push(params.Force, params.Tags...); err != nil {
+		app.Fatal(err)
+	}
+
+	if err := Close(); err != nil {
+		app.Fatal(err)
+	}
+}
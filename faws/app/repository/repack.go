@@ -1,12 +1,19 @@
 package repository
 
-import "github.com/faws-vcs/faws/faws/app"
+import (
+	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/repo/cas"
+)
 
 type RepackParams struct {
 	// The directory where the repository is located
 	Directory string
 	// Sets the maximum file size of an archive (pack.XXXXXX)
 	MaxArchiveSize int64
+	// If greater than zero, similar parts are stored as deltas, in chains no deeper than this
+	DeltaChainDepth int
+	// Copy reachable objects from alternates into the pack
+	IncludeAlternates bool
 }
 
 func Repack(params *RepackParams) {
@@ -15,13 +22,22 @@ func Repack(params *RepackParams) {
 		app.Close()
 	}()
 
+	if err := cas.CheckDeltaChainDepth(params.DeltaChainDepth); err != nil {
+		app.Fatal(err)
+	}
+
 	scrn.summary_mode |= summarize_pruning
 
 	if err := Open(params.Directory); err != nil {
 		app.Fatal(err)
 	}
 
-	if err := Repo.Repack(params.MaxArchiveSize); err != nil {
+	var options []cas.PackWriterOption
+	if params.DeltaChainDepth > 0 {
+		options = append(options, cas.WithDeltas(params.DeltaChainDepth))
+	}
+
+	if err := Repo.Repack(params.MaxArchiveSize, params.IncludeAlternates, options...); err != nil {
 		app.Fatal(err)
 	}
 
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/repo/remote"
+)
+
+// ServeParams are the input parameters to the command "faws serve", [Serve]
+type ServeParams struct {
+	Directory string
+	// The TCP address to listen on, such as ":8080"
+	Listen string
+}
+
+const (
+	// how long a client has to send the headers of a request
+	serve_read_header_timeout = 10 * time.Second
+	// how long an idle keep-alive connection is kept open
+	serve_idle_timeout = 2 * time.Minute
+	// how long requests in progress are given to finish once the server is interrupted
+	serve_shutdown_timeout = 10 * time.Second
+)
+
+// Serve is the implementation of the command "faws serve"
+//
+// It serves the repository over HTTP until it is interrupted, so that other repositories can clone and pull from it.
+// Once interrupted, requests that are in progress are allowed to finish before the repository is closed.
+func Serve(params *ServeParams) {
+	app.Open()
+	defer func() {
+		app.Close()
+	}()
+
+	var server remote.Server
+	if err := server.Open(params.Directory); err != nil {
+		app.Fatal(err)
+	}
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", params.Listen)
+	if err != nil {
+		app.Fatal(err)
+	}
+	app.Info(fmt.Sprintf("serving %s at http://%s/", params.Directory, listener.Addr()))
+
+	// there is no write timeout, as a response can be an entire repository
+	var http_server http.Server
+	http_server.Handler = &server
+	http_server.ReadHeaderTimeout = serve_read_header_timeout
+	http_server.IdleTimeout = serve_idle_timeout
+
+	interrupted, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serve_err := make(chan error, 1)
+	go func() {
+		serve_err <- http_server.Serve(listener)
+	}()
+
+	select {
+	case err = <-serve_err:
+		app.Fatal(err)
+	case <-interrupted.Done():
+	}
+
+	app.Info("shutting down")
+	shutdown, cancel := context.WithTimeout(context.Background(), serve_shutdown_timeout)
+	defer cancel()
+	if err = http_server.Shutdown(shutdown); err != nil {
+		app.Warning(err)
+	}
+	if err = <-serve_err; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		app.Fatal(err)
+	}
+}
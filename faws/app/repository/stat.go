@@ -2,19 +2,30 @@ package repository
 
 import (
 	"fmt"
+	"os"
+	"text/tabwriter"
 
 	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/repo"
+	"github.com/faws-vcs/faws/faws/repo/cas"
 )
 
 // StatParams are the input parameters to the command "faws status"
 type StatParams struct {
 	Directory     string
 	ShowLazyFiles bool
+	// If true, list the paths in Source which would be ignored by "faws add", instead of the index
+	ShowIgnored bool
+	// If not empty, compare the files in Source to this commit, instead of listing the index
+	Against string
+	Source  string
 }
 
 // Stat is the implementation of the command "faws status"
 //
 // It displays the contents of the index. If ShowLazyFiles == true, lazy file signatures are also displayed.
+// If ShowIgnored == true, the paths ignored by .fawsignore are displayed instead.
+// If Against is set, the differences between the Source directory and that commit are displayed instead.
 func Stat(params *StatParams) {
 	app.Open()
 	defer func() {
@@ -27,6 +38,27 @@ func Stat(params *StatParams) {
 		return
 	}
 
+	if params.Against != "" {
+		compare_directory(params)
+		return
+	}
+
+	if params.ShowIgnored {
+		ignored_paths, err := Repo.IgnoredPaths(params.Source)
+		if err != nil {
+			app.Fatal(err)
+		}
+		if len(ignored_paths) == 0 {
+			app.Info("nothing is ignored")
+		} else {
+			app.Header(fmt.Sprintf("%d paths ignored:", len(ignored_paths)))
+			for _, ignored_path := range ignored_paths {
+				app.Info(ignored_path)
+			}
+		}
+		return
+	}
+
 	index := Repo.Index()
 	if index != nil {
 		if params.ShowLazyFiles {
@@ -43,8 +75,53 @@ func Stat(params *StatParams) {
 		} else {
 			app.Header(fmt.Sprintf("%d files to be committed:", len(index.Entries)))
 			for _, index_entry := range index.Entries {
-				app.Info(index_entry.Mode, index_entry.File, index_entry.Path)
+				if index_entry.Prefix == cas.Link {
+					app.Info("l", index_entry.File, index_entry.Path)
+				} else {
+					app.Info(index_entry.Mode, index_entry.File, index_entry.Path)
+				}
 			}
 		}
 	}
 }
+
+func compare_directory(params *StatParams) {
+	commit_hash, err := Repo.ParseRef(params.Against)
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	differences, err := Repo.CompareDirectory(commit_hash, params.Source)
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	var (
+		tw                                  tabwriter.Writer
+		added, removed, modified, unchanged int
+	)
+	tw.Init(os.Stdout, 0, 0, 2, ' ', 0)
+	for i := range differences {
+		difference := &differences[i]
+		switch {
+		case difference.Status == 0:
+			unchanged++
+			continue
+		case difference.Status&repo.DiffAdded != 0:
+			added++
+		case difference.Status&repo.DiffRemoved != 0:
+			removed++
+		default:
+			modified++
+		}
+		display_difference(&tw, difference)
+	}
+	tw.Flush()
+
+	if added+removed+modified == 0 {
+		app.Info(fmt.Sprintf("no differences (%d files unchanged)", unchanged))
+	} else {
+		app.Info()
+		app.Info(fmt.Sprintf("%d added, %d removed, %d changed, %d unchanged", added, removed, modified, unchanged))
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"github.com/faws-vcs/console"
 	"github.com/faws-vcs/faws/faws/app"
 	"github.com/faws-vcs/faws/faws/app/identities"
+	"github.com/faws-vcs/faws/faws/config"
 	"github.com/faws-vcs/faws/faws/repo"
 	"github.com/faws-vcs/faws/faws/repo/event"
 	"github.com/faws-vcs/faws/faws/repo/p2p/tracker"
+	"github.com/faws-vcs/faws/faws/repo/remote"
 )
 
 // The repository the user is accessing
@@ -30,6 +32,7 @@ func Open(directory string) (err error) {
 		repo.WithTrust(identities.NewRingTrust(app.Configuration.Ring())),
 		repo.WithNotify(notify_func),
 		repo.WithTracker(TrackerURL),
+		repo.WithCredentials(origin_credentials),
 	)
 
 	if !quiet {
@@ -39,6 +42,21 @@ func Open(directory string) (err error) {
 	return
 }
 
+// looks up the user's credentials for an origin
+func origin_credentials(uri string) (credentials remote.Credentials, err error) {
+	var user_credentials config.Credentials
+	if user_credentials, err = app.Configuration.Credentials(uri); err != nil {
+		return
+	}
+	credentials.Username = user_credentials.Username
+	credentials.Password = user_credentials.Password
+	credentials.Token = user_credentials.Token
+	credentials.CACertificate = user_credentials.CACertificate
+	credentials.ClientCertificate = user_credentials.ClientCertificate
+	credentials.ClientKey = user_credentials.ClientKey
+	return
+}
+
 // Close closes the repository
 func Close() (err error) {
 	err = Repo.Close()
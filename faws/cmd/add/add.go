@@ -2,11 +2,14 @@ package add
 
 import (
 	"os"
+	"strings"
 
 	"github.com/faws-vcs/faws/faws/app"
 	"github.com/faws-vcs/faws/faws/app/repository"
 	"github.com/faws-vcs/faws/faws/cmd/helpinfo"
 	"github.com/faws-vcs/faws/faws/cmd/root"
+	"github.com/faws-vcs/faws/faws/multipart"
+	"github.com/faws-vcs/faws/faws/repo"
 	"github.com/faws-vcs/faws/faws/repo/revision"
 	"github.com/spf13/cobra"
 )
@@ -24,7 +27,13 @@ func init() {
 	// TODO: I want to add a flag that matches each filename added as a pathspec
 	// flag.StringP("pathspec", "p", "", "pathspec")
 	flag.BoolP("lazy", "l", false, "refrain from chunking large files which share essential details with previously added files. Use this carefully, as it can introduce inconsistent information into your repository")
+	flag.IntP("jobs", "j", 0, "the number of files to cache at once (default is the number of CPUs)")
 	flag.BoolP("verbose", "v", false, "display each file that gets cached")
+	flag.Bool("no-ignore", false, "add files even if they match a pattern in "+repo.IgnoreFile)
+	flag.Bool("rehash", false, "read every file again, even if its size, modification time and inode are the same as when it was last added")
+	flag.Bool("no-metadata", false, "don't record the modification time and permissions of each file")
+	flag.String("chunker", "", "split every file with this chunker ("+strings.Join(multipart.Chunkers(), ", ")+") instead of detecting its format")
+	add_cmd.RegisterFlagCompletionFunc("chunker", cobra.FixedCompletions(multipart.Chunkers(), cobra.ShellCompDirectiveNoFileComp))
 	flag.BoolP("ref", "n", false, "if true, the source is a ref to a repository object, rather than a file name")
 	root.RootCmd.AddCommand(&add_cmd)
 }
@@ -57,6 +66,31 @@ func run_add_cmd(cmd *cobra.Command, args []string) {
 		app.Fatal(err)
 	}
 
+	no_metadata, err := flag.GetBool("no-metadata")
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	no_ignore, err := flag.GetBool("no-ignore")
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	rehash, err := flag.GetBool("rehash")
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	jobs, err := flag.GetInt("jobs")
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	chunker, err := flag.GetString("chunker")
+	if err != nil {
+		app.Fatal(err)
+	}
+
 	// use working directory as default repository location
 	working_directory, err := os.Getwd()
 	if err != nil {
@@ -69,6 +103,11 @@ func run_add_cmd(cmd *cobra.Command, args []string) {
 		Source:      args[1],
 		SourceIsRef: source_is_ref,
 		AddLazy:     lazy,
+		NoMetadata:  no_metadata,
+		Chunker:     chunker,
+		Jobs:        jobs,
+		NoIgnore:    no_ignore,
+		Rehash:      rehash,
 		Verbose:     verbose,
 	}
 
@@ -0,0 +1,15 @@
+package bundle
+
+import (
+	"github.com/faws-vcs/faws/faws/cmd/root"
+	"github.com/spf13/cobra"
+)
+
+var BundleCmd = cobra.Command{
+	Use:     "bundle",
+	GroupID: "remote",
+}
+
+func init() {
+	root.RootCmd.AddCommand(&BundleCmd)
+}
@@ -0,0 +1,90 @@
+package create
+
+import (
+	"os"
+
+	"github.com/dustin/go-humanize"
+	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/app/repository"
+	"github.com/faws-vcs/faws/faws/cmd/bundle"
+	"github.com/faws-vcs/faws/faws/cmd/helpinfo"
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/spf13/cobra"
+)
+
+var CreateCmd = cobra.Command{
+	Use:     "create [tag...]",
+	Short:   helpinfo.Text["bundle create"],
+	Example: `faws bundle create -o /mnt/usb/snapshot v1.0 v1.1`,
+	Run:     run_create_cmd,
+}
+
+func init() {
+	flags := CreateCmd.Flags()
+	flags.StringP("output", "o", "", "the directory to write the bundle into")
+	flags.StringP("sign", "s", "", "specify a signing identity other than your current primary")
+	flags.StringP("max-archive-size", "n", "", "set the maximum size of a pack archive file (e.g. 10K, 50G)")
+	flags.BoolP("delta", "d", false, "store parts as deltas against similar parts in the pack")
+	flags.Int("delta-depth", cas.DefaultDeltaChainDepth, "the maximum length of a chain of deltas")
+	bundle.BundleCmd.AddCommand(&CreateCmd)
+}
+
+func run_create_cmd(cmd *cobra.Command, args []string) {
+	flags := cmd.Flags()
+	var (
+		err               error
+		working_directory string
+	)
+	// use working directory as default repository location
+	working_directory, err = os.Getwd()
+	if err != nil {
+		app.Fatal(err)
+		return
+	}
+
+	var params repository.BundleCreateParams
+	params.Directory = working_directory
+	params.Tags = args
+	params.Output, err = flags.GetString("output")
+	if err != nil {
+		app.Fatal(err)
+	}
+	if params.Output == "" {
+		cmd.Help()
+		return
+	}
+	params.Sign, err = flags.GetString("sign")
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	var max_archive_size string
+	max_archive_size, err = flags.GetString("max-archive-size")
+	if err != nil {
+		app.Fatal(err)
+	}
+	if max_archive_size == "" {
+		params.MaxArchiveSize = -1
+	} else {
+		var max_archive_size_u64 uint64
+		max_archive_size_u64, err = humanize.ParseBytes(max_archive_size)
+		if err != nil {
+			app.Fatal(err)
+		}
+		params.MaxArchiveSize = int64(max_archive_size_u64)
+	}
+
+	var delta bool
+	delta, err = flags.GetBool("delta")
+	if err != nil {
+		app.Fatal(err)
+	}
+	if delta {
+		params.DeltaChainDepth, err = flags.GetInt("delta-depth")
+		if err != nil {
+			app.Fatal(err)
+		}
+	}
+
+	repository.BundleCreate(&params)
+}
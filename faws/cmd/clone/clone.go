@@ -18,6 +18,8 @@ var clone_cmd = cobra.Command{
 }
 
 func init() {
+	flags := clone_cmd.Flags()
+	flags.StringArray("alternate", nil, "borrow objects from another local repository instead of downloading them (may be repeated)")
 	root.RootCmd.AddCommand(&clone_cmd)
 }
 
@@ -42,6 +44,11 @@ func run_clone_cmd(cmd *cobra.Command, args []string) {
 
 	params.TrackerURL = os.Getenv("FAWS_TRACKER")
 
+	params.Alternates, err = cmd.Flags().GetStringArray("alternate")
+	if err != nil {
+		app.Fatal(err)
+	}
+
 	// use the second argument as repository location, if supplied
 	if len(args) > 1 {
 		params.Directory = args[1]
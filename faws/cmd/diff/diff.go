@@ -0,0 +1,52 @@
+package diff
+
+import (
+	"os"
+
+	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/app/repository"
+	"github.com/faws-vcs/faws/faws/cmd/helpinfo"
+	"github.com/faws-vcs/faws/faws/cmd/root"
+	"github.com/spf13/cobra"
+)
+
+var diff_cmd = cobra.Command{
+	Use:               "diff [--name-status] old-ref new-ref",
+	Short:             helpinfo.Text["diff"],
+	GroupID:           "repo",
+	Run:               run_diff_cmd,
+	ValidArgsFunction: repository.InferenceRefArg(0, 1),
+}
+
+func init() {
+	flags := diff_cmd.Flags()
+	flags.Bool("name-status", false, "show only the status letter (A, D, M, X) and path of each changed file")
+	root.RootCmd.AddCommand(&diff_cmd)
+}
+
+func run_diff_cmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		cmd.Help()
+		os.Exit(1)
+	}
+
+	name_status, err := cmd.Flags().GetBool("name-status")
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	// use working directory as default repository location
+	working_directory, err := os.Getwd()
+	if err != nil {
+		app.Fatal(err)
+		return
+	}
+
+	var params = repository.DiffParams{
+		Directory:  working_directory,
+		Ref1:       args[0],
+		Ref2:       args[1],
+		NameStatus: name_status,
+	}
+	repository.Diff(&params)
+}
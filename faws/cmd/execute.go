@@ -8,6 +8,8 @@ import (
 	_ "github.com/faws-vcs/faws/faws/cmd/id/rm"
 	_ "github.com/faws-vcs/faws/faws/cmd/id/set"
 
+	_ "github.com/faws-vcs/faws/faws/cmd/bundle/create"
+
 	_ "github.com/faws-vcs/faws/faws/cmd/add"
 	_ "github.com/faws-vcs/faws/faws/cmd/cat-file"
 	_ "github.com/faws-vcs/faws/faws/cmd/checkout"
@@ -15,6 +17,7 @@ import (
 	_ "github.com/faws-vcs/faws/faws/cmd/clone"
 	_ "github.com/faws-vcs/faws/faws/cmd/commit"
 	_ "github.com/faws-vcs/faws/faws/cmd/commit-tree"
+	_ "github.com/faws-vcs/faws/faws/cmd/diff"
 	_ "github.com/faws-vcs/faws/faws/cmd/fsck"
 	_ "github.com/faws-vcs/faws/faws/cmd/init"
 	_ "github.com/faws-vcs/faws/faws/cmd/inspect-file"
@@ -25,10 +28,12 @@ import (
 	_ "github.com/faws-vcs/faws/faws/cmd/prune"
 	_ "github.com/faws-vcs/faws/faws/cmd/publish"
 	_ "github.com/faws-vcs/faws/faws/cmd/pull"
+	_ "github.com/faws-vcs/faws/faws/cmd/push"
 	_ "github.com/faws-vcs/faws/faws/cmd/repack"
 	_ "github.com/faws-vcs/faws/faws/cmd/reset"
 	_ "github.com/faws-vcs/faws/faws/cmd/rm"
 	_ "github.com/faws-vcs/faws/faws/cmd/seed"
+	_ "github.com/faws-vcs/faws/faws/cmd/serve"
 	_ "github.com/faws-vcs/faws/faws/cmd/status"
 	_ "github.com/faws-vcs/faws/faws/cmd/tag"
 	_ "github.com/faws-vcs/faws/faws/cmd/tracker"
@@ -12,10 +12,13 @@ var Text = map[string]string{
 	"id rm":     "remove an identity from the ring",
 	"id set":    "alter various identity attributes",
 
-	"pull":    "download tags or objects into the current repository",
-	"clone":   "download an entire remote repository into a directory",
-	"publish": "upload a manifest of the repository to the tracker server",
-	"seed":    "connect directly with other computers and upload repository objects to them",
+	"pull":          "download tags or objects into the current repository",
+	"push":          "upload tags and their objects from the current repository to the origin",
+	"clone":         "download an entire remote repository into a directory",
+	"publish":       "upload a manifest of the repository to the tracker server",
+	"serve":         "make the repository available over HTTP for others to clone and pull",
+	"bundle create": "write tags and their objects into a signed bundle, which can be cloned and pulled from offline",
+	"seed":          "connect directly with other computers and upload repository objects to them",
 
 	"init":        "create an empty repository in the current directory",
 	"add":         "add a file or directory to the index",
@@ -31,6 +34,7 @@ var Text = map[string]string{
 	"cat-file":    "provide contents or details of repository objects",
 	"tag":         "list tags and their associated commit hashes",
 	"ls-tree":     "list the contents of a tree object",
+	"diff":        "show the files that changed between two commits or trees",
 	"prune":       "purge unreachable objects from the cache",
 	"pack":        "compile many repository objects into larger files",
 	"repack":      "recompile all repository objects into a pack with unreachable objects purged",
@@ -63,7 +67,10 @@ var Categories = []CategoryEntry{
 		"sync objects between local and remote repositories",
 		[]string{
 			"pull",
+			"push",
 			"clone",
+			"serve",
+			"bundle create",
 			"seed",
 			"publish",
 		},
@@ -80,6 +87,7 @@ var Categories = []CategoryEntry{
 			"status",
 			"write-tree",
 			"ls-tree",
+			"diff",
 			"commit-tree",
 			"commit",
 			"log",
@@ -20,6 +20,10 @@ var init_cmd = cobra.Command{
 func init() {
 	flags := init_cmd.Flags()
 	flags.BoolP("force", "f", false, "initialize even if directory is non-empty")
+	flags.String("chunk-min", "", "the minimum size of a file part, e.g. 64KiB. Files smaller than this are stored in one part")
+	flags.String("chunk-average", "", "the average size of a file part past the minimum, a power of two")
+	flags.String("chunk-max", "", "the maximum size of a file part")
+	flags.String("chunk-polynomial", "", "the polynomial of the rolling hash in hexadecimal, or \"random\"")
 
 	root.RootCmd.AddCommand(&init_cmd)
 }
@@ -39,5 +43,20 @@ func run_init_cmd(cmd *cobra.Command, args []string) {
 	if len(args) > 0 {
 		params.Remote = args[0]
 	}
+	flags := cmd.Flags()
+	for _, chunking_flag := range []struct {
+		name  string
+		value *string
+	}{
+		{"chunk-min", &params.ChunkMin},
+		{"chunk-average", &params.ChunkAverage},
+		{"chunk-max", &params.ChunkMax},
+		{"chunk-polynomial", &params.ChunkPolynomial},
+	} {
+		*chunking_flag.value, err = flags.GetString(chunking_flag.name)
+		if err != nil {
+			app.Fatal(err)
+		}
+	}
 	repository.Init(&params)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/faws-vcs/faws/faws/app/repository"
 	"github.com/faws-vcs/faws/faws/cmd/helpinfo"
 	"github.com/faws-vcs/faws/faws/cmd/root"
+	"github.com/faws-vcs/faws/faws/repo/cas"
 	"github.com/spf13/cobra"
 )
 
@@ -21,6 +22,9 @@ var pack_cmd = cobra.Command{
 func init() {
 	flags := pack_cmd.Flags()
 	flags.StringP("max-archive-size", "n", "", "set the maximum size of a pack archive file (e.g. 10K, 50G)")
+	flags.BoolP("delta", "d", false, "store parts as deltas against similar parts in the pack")
+	flags.Int("delta-depth", cas.DefaultDeltaChainDepth, "the maximum length of a chain of deltas")
+	flags.BoolP("incremental", "i", false, "pack only cached objects into a new pack that is added to the repository")
 	root.RootCmd.AddCommand(&pack_cmd)
 }
 
@@ -60,5 +64,22 @@ func run_pack_cmd(cmd *cobra.Command, args []string) {
 		params.MaxArchiveSize = int64(max_archive_size_u64)
 	}
 
+	params.Incremental, err = flags.GetBool("incremental")
+	if err != nil {
+		app.Fatal(err)
+	}
+
+	var delta bool
+	delta, err = flags.GetBool("delta")
+	if err != nil {
+		app.Fatal(err)
+	}
+	if delta {
+		params.DeltaChainDepth, err = flags.GetInt("delta-depth")
+		if err != nil {
+			app.Fatal(err)
+		}
+	}
+
 	repository.Pack(&params)
 }
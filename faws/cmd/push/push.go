@@ -0,0 +1,58 @@
+package push
+
+import (
+	"os"
+
+	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/app/repository"
+	"github.com/faws-vcs/faws/faws/cmd/helpinfo"
+	"github.com/faws-vcs/faws/faws/cmd/root"
+	"github.com/spf13/cobra"
+)
+
+var push_cmd = cobra.Command{
+	Use:               "push [tag...]",
+	Short:             helpinfo.Text["push"],
+	GroupID:           "remote",
+	Run:               run_push_cmd,
+	ValidArgsFunction: repository.InferenceRefLastArg,
+}
+
+func init() {
+	flag := push_cmd.Flags()
+	flag.BoolP("force", "f", false, "replace tags in the origin even if they point to commits that are not ancestors of yours")
+	flag.BoolP("verbose", "v", false, "display extra information")
+	flag.BoolP("quiet", "q", false, "shut up the interactive Hud")
+	root.RootCmd.AddCommand(&push_cmd)
+}
+
+func run_push_cmd(cmd *cobra.Command, args []string) {
+	// use working directory as default repository location
+	working_directory, err := os.Getwd()
+	if err != nil {
+		app.Fatal(err)
+		return
+	}
+
+	var params = repository.PushParams{
+		Directory: working_directory,
+		Tags:      args,
+	}
+	flag := cmd.Flags()
+	params.Force, err = flag.GetBool("force")
+	if err != nil {
+		app.Fatal(err)
+		return
+	}
+	params.Verbose, err = flag.GetBool("verbose")
+	if err != nil {
+		app.Fatal(err)
+		return
+	}
+	params.Quiet, err = flag.GetBool("quiet")
+	if err != nil {
+		app.Fatal(err)
+		return
+	}
+	repository.Push(&params)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/faws-vcs/faws/faws/app/repository"
 	"github.com/faws-vcs/faws/faws/cmd/helpinfo"
 	"github.com/faws-vcs/faws/faws/cmd/root"
+	"github.com/faws-vcs/faws/faws/repo/cas"
 	"github.com/spf13/cobra"
 )
 
@@ -21,6 +22,9 @@ var repack_cmd = cobra.Command{
 func init() {
 	flags := repack_cmd.Flags()
 	flags.StringP("max-archive-size", "n", "", "set the maximum size of a pack archive file (e.g. 10K, 50G)")
+	flags.BoolP("delta", "d", false, "store parts as deltas against similar parts in the pack")
+	flags.Int("delta-depth", cas.DefaultDeltaChainDepth, "the maximum length of a chain of deltas")
+	flags.Bool("include-alternates", false, "copy reachable objects that are only in alternates into the pack")
 	root.RootCmd.AddCommand(&repack_cmd)
 }
 
@@ -56,5 +60,22 @@ func run_repack_cmd(cmd *cobra.Command, args []string) {
 		params.MaxArchiveSize = int64(max_archive_size_u64)
 	}
 
+	var delta bool
+	delta, err = flags.GetBool("delta")
+	if err != nil {
+		app.Fatal(err)
+	}
+	if delta {
+		params.DeltaChainDepth, err = flags.GetInt("delta-depth")
+		if err != nil {
+			app.Fatal(err)
+		}
+	}
+
+	params.IncludeAlternates, err = flags.GetBool("include-alternates")
+	if err != nil {
+		app.Fatal(err)
+	}
+
 	repository.Repack(&params)
 }
@@ -0,0 +1,47 @@
+package serve
+
+import (
+	"os"
+
+	"github.com/faws-vcs/faws/faws/app"
+	"github.com/faws-vcs/faws/faws/app/repository"
+	"github.com/faws-vcs/faws/faws/cmd/helpinfo"
+	"github.com/faws-vcs/faws/faws/cmd/root"
+	"github.com/spf13/cobra"
+)
+
+var serve_cmd = cobra.Command{
+	Use:     "serve [directory]",
+	Short:   helpinfo.Text["serve"],
+	GroupID: "remote",
+	Run:     run_serve_cmd,
+}
+
+func init() {
+	flag := serve_cmd.Flags()
+	flag.StringP("listen", "l", ":8080", "the address to accept connections on")
+	root.RootCmd.AddCommand(&serve_cmd)
+}
+
+func run_serve_cmd(cmd *cobra.Command, args []string) {
+	// use working directory as default repository location
+	directory, err := os.Getwd()
+	if err != nil {
+		app.Fatal(err)
+		return
+	}
+	if len(args) > 0 {
+		directory = args[0]
+	}
+
+	var params = repository.ServeParams{
+		Directory: directory,
+	}
+	flag := cmd.Flags()
+	params.Listen, err = flag.GetString("listen")
+	if err != nil {
+		app.Fatal(err)
+		return
+	}
+	repository.Serve(&params)
+}
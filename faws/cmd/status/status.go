@@ -11,7 +11,7 @@ import (
 )
 
 var status_cmd = cobra.Command{
-	Use:     "status",
+	Use:     "status [--ignored [directory]] [--against ref [directory]]",
 	Short:   helpinfo.Text["status"],
 	GroupID: "repo",
 	Run:     run_status_cmd,
@@ -20,6 +20,9 @@ var status_cmd = cobra.Command{
 func init() {
 	flag := status_cmd.Flags()
 	flag.BoolP("show-lazy", "l", false, "show signatures of lazy files in the index")
+	flag.String("against", "", "compare the files in a directory (by default, the working directory) to a commit, without adding them")
+	flag.Bool("ignored", false, "show the paths in a directory (by default, the working directory) that would be ignored by 'faws add'")
+	status_cmd.RegisterFlagCompletionFunc("against", repository.InferenceRefLastArg)
 	root.RootCmd.AddCommand(&status_cmd)
 }
 
@@ -40,6 +43,18 @@ func run_status_cmd(cmd *cobra.Command, args []string) {
 	if err != nil {
 		return
 	}
+	params.ShowIgnored, err = flag.GetBool("ignored")
+	if err != nil {
+		return
+	}
+	params.Against, err = flag.GetString("against")
+	if err != nil {
+		return
+	}
+	params.Source = working_directory
+	if len(args) > 0 {
+		params.Source = args[0]
+	}
 
 	repository.Stat(&params)
 }
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials authenticate requests to an HTTP origin. They are kept in the "credentials" file of the user's configuration,
+// which is a JSON array of Credentials.
+type Credentials struct {
+	// The URL of the origin, or of a directory above it on the same host. The credentials with the longest matching Origin are used
+	Origin   string `json:"origin"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// A bearer token, sent instead of the username and password
+	Token string `json:"token,omitempty"`
+	// PEM files. Relative paths are relative to the configuration directory
+	CACertificate     string `json:"ca_certificate,omitempty"`
+	ClientCertificate string `json:"client_certificate,omitempty"`
+	ClientKey         string `json:"client_key,omitempty"`
+}
+
+// The environment variables which override the credentials of every origin
+const (
+	EnvHTTPUsername          = "FAWS_HTTP_USERNAME"
+	EnvHTTPPassword          = "FAWS_HTTP_PASSWORD"
+	EnvHTTPToken             = "FAWS_HTTP_TOKEN"
+	EnvHTTPCACertificate     = "FAWS_HTTP_CA_CERTIFICATE"
+	EnvHTTPClientCertificate = "FAWS_HTTP_CLIENT_CERTIFICATE"
+	EnvHTTPClientKey         = "FAWS_HTTP_CLIENT_KEY"
+)
+
+// CredentialsPath returns the path to the user's credentials file
+func (config *Configuration) CredentialsPath() string {
+	return filepath.Join(config.directory, "credentials")
+}
+
+// reads the user's credentials file, if there is one
+func (config *Configuration) read_credentials() (credentials []Credentials, err error) {
+	var data []byte
+	data, err = os.ReadFile(config.CredentialsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+		return
+	} else if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &credentials)
+	return
+}
+
+// returns the port of a URL, or the default port of its scheme
+func url_port(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch u.Scheme {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
+
+// returns true if the credentials for entry_origin may be sent to origin_url. They must have the same scheme, host and port,
+// and the path of origin_url must be the path of entry_origin, or beneath it
+func credentials_match(origin_url *url.URL, entry_origin string) bool {
+	entry_url, err := url.Parse(entry_origin)
+	if err != nil {
+		return false
+	}
+	if entry_url.Scheme != origin_url.Scheme ||
+		!strings.EqualFold(entry_url.Hostname(), origin_url.Hostname()) ||
+		url_port(entry_url) != url_port(origin_url) {
+		return false
+	}
+	entry_path := strings.TrimSuffix(entry_url.Path, "/")
+	return origin_url.Path == entry_path || strings.HasPrefix(origin_url.Path, entry_path+"/")
+}
+
+// Credentials returns the credentials for the origin at uri. In order of priority, they come from
+//  1. the environment variables, such as FAWS_HTTP_TOKEN
+//  2. the credentials file
+//  3. the netrc file named by $NETRC, or ~/.netrc
+//
+// If none are found, the credentials are empty.
+func (config *Configuration) Credentials(uri string) (credentials Credentials, err error) {
+	origin_url, parse_err := url.Parse(uri)
+	if parse_err != nil || (origin_url.Scheme != "http" && origin_url.Scheme != "https") {
+		return
+	}
+	credentials.Origin = uri
+
+	var entries []Credentials
+	if entries, err = config.read_credentials(); err != nil {
+		return
+	}
+	match_length := -1
+	for _, entry := range entries {
+		if credentials_match(origin_url, entry.Origin) && len(entry.Origin) > match_length {
+			credentials = entry
+			match_length = len(entry.Origin)
+		}
+	}
+	if match_length < 0 {
+		if login, password, found := netrc_lookup(origin_url.Hostname()); found {
+			credentials.Username = login
+			credentials.Password = password
+		}
+	}
+
+	for _, env := range []struct {
+		name  string
+		field *string
+	}{
+		{EnvHTTPUsername, &credentials.Username},
+		{EnvHTTPPassword, &credentials.Password},
+		{EnvHTTPToken, &credentials.Token},
+		{EnvHTTPCACertificate, &credentials.CACertificate},
+		{EnvHTTPClientCertificate, &credentials.ClientCertificate},
+		{EnvHTTPClientKey, &credentials.ClientKey},
+	} {
+		if value := os.Getenv(env.name); value != "" {
+			*env.field = value
+		}
+	}
+
+	for _, path := range []*string{&credentials.CACertificate, &credentials.ClientCertificate, &credentials.ClientKey} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(config.directory, *path)
+		}
+	}
+	return
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestCredentialsMatch(t *testing.T) {
+	tests := []struct {
+		origin, entry string
+		match         bool
+	}{
+		{"https://mirror.example.com/faws/repo", "https://mirror.example.com", true},
+		{"https://mirror.example.com/faws/repo", "https://mirror.example.com/", true},
+		{"https://mirror.example.com/faws/repo", "https://MIRROR.example.com:443/faws", true},
+		{"https://mirror.example.com/faws/repo", "https://mirror.example.com/faws/repo/", true},
+		{"http://mirror.example.com:8080/faws", "http://mirror.example.com:8080", true},
+		// a lookalike host
+		{"https://mirror.example.com.evil.net/faws", "https://mirror.example.com", false},
+		{"https://mirror.example.com@evil.net/faws", "https://mirror.example.com", false},
+		// another port or scheme
+		{"https://mirror.example.com:8443/faws", "https://mirror.example.com", false},
+		{"http://mirror.example.com/faws", "https://mirror.example.com", false},
+		// a path that merely begins with the same characters
+		{"https://mirror.example.com/faws-private", "https://mirror.example.com/faws", false},
+	}
+	for _, test := range tests {
+		origin_url, err := url.Parse(test.origin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if match := credentials_match(origin_url, test.entry); match != test.match {
+			t.Errorf("%s, %s: got %v", test.origin, test.entry, match)
+		}
+	}
+}
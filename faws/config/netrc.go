@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// returns the path to the user's netrc file
+func netrc_path() (path string) {
+	if path = os.Getenv("NETRC"); path != "" {
+		return
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	path = filepath.Join(home, ".netrc")
+	return
+}
+
+// finds the login and password for a machine in the user's netrc file.
+// The "default" entry is used if no machine matches
+func netrc_lookup(machine string) (login, password string, found bool) {
+	path := netrc_path()
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	login, password, found = parse_netrc(string(data), machine)
+	return
+}
+
+func parse_netrc(netrc string, machine string) (login, password string, found bool) {
+	var (
+		// the entry currently being read
+		entry_login, entry_password string
+		in_entry, entry_matches     bool
+		is_default                  bool
+		default_login               string
+		default_password            string
+		has_default                 bool
+	)
+	// ends the entry currently being read, returning true if it's the one we want
+	end_entry := func() bool {
+		if in_entry && entry_matches {
+			login, password, found = entry_login, entry_password, true
+			return true
+		}
+		if in_entry && is_default {
+			default_login, default_password, has_default = entry_login, entry_password, true
+		}
+		in_entry, entry_matches, is_default = false, false, false
+		entry_login, entry_password = "", ""
+		return false
+	}
+
+	lines := strings.Split(netrc, "\n")
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		for j := 0; j < len(fields); j++ {
+			// the value following the current token, which may be on the next line
+			next := func() (value string) {
+				if j+1 < len(fields) {
+					j++
+					value = fields[j]
+				}
+				return
+			}
+			switch fields[j] {
+			case "machine":
+				if end_entry() {
+					return
+				}
+				in_entry = true
+				entry_matches = next() == machine
+			case "default":
+				if end_entry() {
+					return
+				}
+				in_entry = true
+				is_default = true
+			case "login":
+				entry_login = next()
+			case "password":
+				entry_password = next()
+			case "account":
+				next()
+			case "macdef":
+				// a macro continues until a blank line
+				if end_entry() {
+					return
+				}
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				j = len(fields)
+			}
+		}
+	}
+	if end_entry() {
+		return
+	}
+	if has_default {
+		login, password, found = default_login, default_password, true
+	}
+	return
+}
@@ -0,0 +1,34 @@
+package config
+
+import "testing"
+
+func TestParseNetrc(t *testing.T) {
+	const netrc = `machine mirror.example.com login alice password s3cret
+machine other.example.com
+	login bob
+	password hunter2
+
+macdef init
+cd /pub
+machine macro.example.com login nobody
+
+default login anonymous password guest
+`
+	tests := []struct {
+		machine, login, password string
+	}{
+		{"mirror.example.com", "alice", "s3cret"},
+		{"other.example.com", "bob", "hunter2"},
+		{"unknown.example.com", "anonymous", "guest"},
+	}
+	for _, test := range tests {
+		login, password, found := parse_netrc(netrc, test.machine)
+		if !found || login != test.login || password != test.password {
+			t.Errorf("%s: got %q %q %v", test.machine, login, password, found)
+		}
+	}
+
+	if _, _, found := parse_netrc("machine a login b password c", "d"); found {
+		t.Error("found credentials for a machine that isn't in the file")
+	}
+}
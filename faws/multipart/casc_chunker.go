@@ -0,0 +1,293 @@
+package multipart
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// every blob in a data.NNN archive begins with this header, followed by the BLTE-encoded data
+	// 16 bytes: the encoding key, reversed
+	// 4 bytes:  the size of the blob, including this header
+	// 2 bytes:  flags
+	// 8 bytes:  checksums
+	casc_blob_header_size = 30
+)
+
+var (
+	// the magic bytes at the beginning of BLTE-encoded data
+	blte_magic = [4]byte{'B', 'L', 'T', 'E'}
+)
+
+type casc_section_type uint8
+
+const (
+	casc_section_blob casc_section_type = iota
+	casc_section_extra
+)
+
+type casc_section struct {
+	Type   casc_section_type
+	Offset int64
+	// the encoding key of the blob (only the first 9 bytes are significant to the .idx files)
+	Key [16]byte
+}
+
+// casc_chunker splits a CASC data archive (data.NNN) on the boundaries of its BLTE blobs.
+//
+// Each blob is identified by its encoding key, so a blob shared between two client builds produces the same chunk
+// no matter where it is stored in the archive. Anything which isn't a blob (free space at the end of an archive) is
+// chunked by the content-defined chunker.
+type casc_chunker struct {
+	section_chunker
+	file io.ReadSeeker
+	// sorted by offset
+	casc_sections []casc_section
+}
+
+// reads the blob headers, one after the other
+func (c *casc_chunker) detect_sections() (err error) {
+	var header [casc_blob_header_size + 4]byte
+	offset := int64(0)
+	for offset < c.file_size {
+		if c.file_size-offset < int64(len(header)) {
+			break
+		}
+		if _, err = c.file.Seek(offset, io.SeekStart); err != nil {
+			return
+		}
+		if _, err = io.ReadFull(c.file, header[:]); err != nil {
+			return
+		}
+		blob_size := int64(binary.LittleEndian.Uint32(header[16:20]))
+		if [4]byte(header[casc_blob_header_size:]) != blte_magic || blob_size < int64(len(header)) || offset+blob_size > c.file_size {
+			break
+		}
+
+		var section casc_section
+		section.Type = casc_section_blob
+		section.Offset = offset
+		// the key is stored in reverse
+		for i := range section.Key {
+			section.Key[i] = header[15-i]
+		}
+		c.casc_sections = append(c.casc_sections, section)
+
+		offset += blob_size
+	}
+
+	if len(c.casc_sections) == 0 {
+		err = fmt.Errorf("faws/multipart: casc_chunker: no BLTE blobs found")
+		return
+	}
+
+	if offset < c.file_size {
+		c.casc_sections = append(c.casc_sections, casc_section{Type: casc_section_extra, Offset: offset})
+	}
+
+	// blobs are named after their encoding key
+	c.sections = make([]file_section, len(c.casc_sections))
+	for i, section := range c.casc_sections {
+		c.sections[i].Offset = section.Offset
+		if section.Type == casc_section_extra {
+			c.sections[i].Name = "extra data"
+		} else {
+			c.sections[i].Name = "blte(" + hex.EncodeToString(section.Key[:]) + ")"
+		}
+	}
+	return
+}
+
+// the location of a blob, as recorded by an .idx file
+type casc_index_entry struct {
+	key    [9]byte
+	offset int64
+	size   uint32
+}
+
+// reads the entries of an .idx file which point into the archive
+func read_casc_index(name string, archive int64, entries []casc_index_entry) (result []casc_index_entry, err error) {
+	result = entries
+
+	var data []byte
+	data, err = os.ReadFile(name)
+	if err != nil {
+		return
+	}
+
+	// header: u32 header size, u32 header hash, then the header itself
+	if len(data) < 8 {
+		err = fmt.Errorf("faws/multipart: casc index %s is truncated", name)
+		return
+	}
+	header_size := int(binary.LittleEndian.Uint32(data[0:4]))
+	if header_size < 16 || 8+header_size > len(data) {
+		err = fmt.Errorf("faws/multipart: casc index %s has a bad header", name)
+		return
+	}
+	header := data[8 : 8+header_size]
+	var (
+		encoded_size_length   = int(header[4])
+		storage_offset_length = int(header[5])
+		key_length            = int(header[6])
+		file_offset_bits      = int(header[7])
+	)
+	if encoded_size_length != 4 || storage_offset_length > 8 || key_length != 9 || file_offset_bits > 8*storage_offset_length {
+		err = fmt.Errorf("faws/multipart: casc index %s has an unsupported layout", name)
+		return
+	}
+
+	// entries are aligned to 16 bytes: u32 size, u32 hash, then the entries
+	entries_position := (8 + header_size + 0x0F) &^ 0x0F
+	if entries_position+8 > len(data) {
+		err = fmt.Errorf("faws/multipart: casc index %s is truncated", name)
+		return
+	}
+	entries_size := int(binary.LittleEndian.Uint32(data[entries_position:]))
+	entry_data := data[entries_position+8:]
+	if entries_size > len(entry_data) {
+		err = fmt.Errorf("faws/multipart: casc index %s is truncated", name)
+		return
+	}
+	entry_data = entry_data[:entries_size]
+
+	entry_size := key_length + storage_offset_length + encoded_size_length
+	for len(entry_data) >= entry_size {
+		var storage_offset uint64
+		for _, b := range entry_data[key_length : key_length+storage_offset_length] {
+			storage_offset = storage_offset<<8 | uint64(b)
+		}
+		if int64(storage_offset>>file_offset_bits) == archive {
+			var entry casc_index_entry
+			copy(entry.key[:], entry_data[:key_length])
+			entry.offset = int64(storage_offset & (1<<file_offset_bits - 1))
+			entry.size = binary.LittleEndian.Uint32(entry_data[key_length+storage_offset_length:])
+			result = append(result, entry)
+		}
+		entry_data = entry_data[entry_size:]
+	}
+	return
+}
+
+// returns the .idx files next to the archive, only the latest version of each bucket
+func casc_index_files(directory string) (names []string, err error) {
+	var directory_entries []os.DirEntry
+	directory_entries, err = os.ReadDir(directory)
+	if err != nil {
+		return
+	}
+
+	// the names are two hex digits for the bucket, followed by eight for the version
+	latest := make(map[string]string)
+	for _, directory_entry := range directory_entries {
+		name := strings.ToLower(directory_entry.Name())
+		if len(name) != 14 || !strings.HasSuffix(name, ".idx") {
+			continue
+		}
+		if _, err := hex.DecodeString(name[:10]); err != nil {
+			continue
+		}
+		bucket := name[:2]
+		if current, ok := latest[bucket]; !ok || name > strings.ToLower(current) {
+			latest[bucket] = directory_entry.Name()
+		}
+	}
+
+	for _, name := range latest {
+		names = append(names, filepath.Join(directory, name))
+	}
+	slices.Sort(names)
+	return
+}
+
+// LazySignature hashes the locations of the archive's blobs, as recorded by the .idx files in the same directory.
+// If the .idx files can't be found, the blob headers themselves are hashed instead.
+func (c *casc_chunker) LazySignature() (s LazySignature, err error) {
+	h := sha256.New()
+	var size_bytes [8]byte
+	binary.LittleEndian.PutUint64(size_bytes[:], uint64(c.file_size))
+	// encode the size of the file
+	h.Write(size_bytes[:])
+
+	var entries []casc_index_entry
+	if named, ok := c.file.(interface{ Name() string }); ok {
+		// data.NNN
+		archive, parse_err := strconv.ParseInt(strings.TrimPrefix(filepath.Ext(named.Name()), "."), 10, 64)
+		if parse_err == nil {
+			var index_files []string
+			index_files, err = casc_index_files(filepath.Dir(named.Name()))
+			if err != nil {
+				return
+			}
+			for _, index_file := range index_files {
+				if entries, err = read_casc_index(index_file, archive, entries); err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	if len(entries) != 0 {
+		slices.SortFunc(entries, func(a, b casc_index_entry) int {
+			if n := cmp.Compare(a.offset, b.offset); n != 0 {
+				return n
+			}
+			return bytes.Compare(a.key[:], b.key[:])
+		})
+		var entry_bytes [9 + 8 + 4]byte
+		for _, entry := range entries {
+			copy(entry_bytes[:9], entry.key[:])
+			binary.LittleEndian.PutUint64(entry_bytes[9:], uint64(entry.offset))
+			binary.LittleEndian.PutUint32(entry_bytes[17:], entry.size)
+			h.Write(entry_bytes[:])
+		}
+	} else {
+		var offset int64
+		if offset, err = c.file.Seek(0, io.SeekCurrent); err != nil {
+			return
+		}
+		var header [casc_blob_header_size]byte
+		for _, section := range c.casc_sections {
+			if section.Type != casc_section_blob {
+				continue
+			}
+			if _, err = c.file.Seek(section.Offset, io.SeekStart); err != nil {
+				return
+			}
+			if _, err = io.ReadFull(c.file, header[:]); err != nil {
+				return
+			}
+			h.Write(header[:])
+		}
+		if _, err = c.file.Seek(offset, io.SeekStart); err != nil {
+			return
+		}
+	}
+
+	copy(s[:], h.Sum(nil))
+	return
+}
+
+func new_casc_chunker(file io.ReadSeeker, size int64, params ChunkingParams) (c *casc_chunker, err error) {
+	c = new(casc_chunker)
+	c.file = file
+	c.file_size = size
+
+	if err = c.detect_sections(); err != nil {
+		err = fmt.Errorf("error detecting sections: %w", err)
+		return
+	}
+
+	err = c.init_reader(file, size, params)
+	return
+}
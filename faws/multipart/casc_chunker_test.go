@@ -0,0 +1,120 @@
+package multipart
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+type test_casc_blob struct {
+	key  [16]byte
+	data []byte
+}
+
+// returns a CASC data archive of BLTE blobs, followed by extra bytes of free space
+func test_casc(blobs []test_casc_blob, extra int) (file []byte) {
+	for _, blob := range blobs {
+		var header [casc_blob_header_size]byte
+		// the key is stored in reverse
+		for i := range blob.key {
+			header[15-i] = blob.key[i]
+		}
+		binary.LittleEndian.PutUint32(header[16:20], uint32(casc_blob_header_size+len(blte_magic)+len(blob.data)))
+		file = append(file, header[:]...)
+		file = append(file, blte_magic[:]...)
+		file = append(file, blob.data...)
+	}
+	file = append(file, make([]byte, extra)...)
+	return
+}
+
+func TestCASCChunker(t *testing.T) {
+	blobs := []test_casc_blob{
+		{[16]byte{1, 2, 3}, test_random_data(1, 200)},
+		{[16]byte{4, 5, 6}, test_random_data(2, test_large_size)},
+		{[16]byte{7, 8, 9}, test_random_data(3, 300)},
+	}
+	file := test_casc(blobs, 1000)
+
+	chunker := new_test_chunker(t, file)
+	if _, ok := chunker.(*casc_chunker); !ok {
+		t.Fatalf("expected the casc chunker, got %T", chunker)
+	}
+	chunks := read_test_chunks(t, chunker, file)
+
+	var expected_names []string
+	for _, blob := range blobs {
+		expected_names = append(expected_names, "blte("+hex.EncodeToString(blob.key[:])+")")
+	}
+	expected_names = append(expected_names, "extra data")
+	names := test_section_names(chunks)
+	if !slices.Equal(names, expected_names) {
+		t.Fatal(names)
+	}
+	if n := len(test_section_chunks(chunks, expected_names[1])); n < 2 {
+		t.Fatalf("the large blob was split into %d chunks", n)
+	}
+	if n := len(test_section_chunks(chunks, expected_names[0])); n != 1 {
+		t.Fatalf("the small blob was split into %d chunks", n)
+	}
+
+	// without .idx files, the lazy signature only depends on the size of the archive and the blob headers
+	signature, err := chunker.(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	same_size := slices.Clone(blobs)
+	same_size[0].data = test_random_data(4, 200)
+	same_signature, err := new_test_chunker(t, test_casc(same_size, 1000)).(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same_signature != signature {
+		t.Fatal("the lazy signature depends on the contents of the blobs")
+	}
+	different_key := slices.Clone(blobs)
+	different_key[2].key[0] = 10
+	different_signature, err := new_test_chunker(t, test_casc(different_key, 1000)).(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if different_signature == signature {
+		t.Fatal("the lazy signature of a different archive is the same")
+	}
+
+	// an archive on disk with no .idx files next to it falls back to the blob headers too
+	name := filepath.Join(t.TempDir(), "data.000")
+	if err = os.WriteFile(name, file, 0644); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	file_chunker, err := new_casc_chunker(archive, int64(len(file)), test_chunking_params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file_signature, err := file_chunker.LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file_signature != signature {
+		t.Fatal("the lazy signature of the archive on disk is different")
+	}
+}
+
+func TestCASCChunkerMalformed(t *testing.T) {
+	// the first blob claims to be larger than the archive, so no blobs can be found
+	file := test_casc([]test_casc_blob{{[16]byte{1}, test_random_data(1, test_large_size)}}, 0)
+	binary.LittleEndian.PutUint32(file[16:20], uint32(len(file)+1))
+	test_fallback(t, "casc", file)
+
+	// truncated in the middle of the first blob
+	file = test_casc([]test_casc_blob{{[16]byte{1}, test_random_data(1, test_large_size)}, {[16]byte{2}, test_random_data(2, 2000)}}, 0)
+	test_fallback(t, "casc", file[:test_large_size/2])
+}
@@ -3,10 +3,6 @@ package multipart
 import (
 	"bytes"
 	"encoding/hex"
-	"io"
-
-	mpqinfo "github.com/Gophercraft/mpq/info"
-	"github.com/faws-vcs/faws/faws/app"
 )
 
 type Chunker interface {
@@ -43,43 +39,3 @@ type LazyChunker interface {
 	// this can be very dangerous if you aren't careful
 	LazySignature() (signature LazySignature, err error)
 }
-
-func NewChunker(file io.ReadSeeker) (chunker Chunker, err error) {
-	var size int64
-	size, err = file.Seek(0, io.SeekEnd)
-	if err != nil {
-		return
-	}
-	if _, err = file.Seek(0, io.SeekStart); err != nil {
-		return
-	}
-	if size < min_chunk_size {
-		chunker = single_chunk{file}
-		return
-	}
-
-	// detect magic
-	var magic [4]byte
-	if _, err = io.ReadFull(file, magic[:]); err != nil {
-		return
-	}
-	if _, err = file.Seek(0, io.SeekStart); err != nil {
-		return
-	}
-
-	switch magic {
-	case mpqinfo.HeaderDataSignature:
-		chunker, err = new_mpq_chunker(file, size)
-		if err == nil {
-			return
-		}
-		if err != nil {
-			panic(err)
-		}
-		app.Info("mpq failed to start: ", err)
-	default:
-	}
-
-	chunker = new_generic_chunker(file)
-	return
-}
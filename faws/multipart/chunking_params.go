@@ -0,0 +1,78 @@
+package multipart
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/restic/chunker"
+)
+
+const (
+	// the smallest chunk boundaries a repository may use
+	min_chunk_size_limit = 0x200
+	// every chunk becomes a part, which can't be larger than an object may be.
+	// the generic chunker also buffers two chunks of the maximum size on each worker
+	max_chunk_size_limit = cas.MaxObjectSize
+	// the degree of the polynomials used by the rolling hash
+	chunker_polynomial_degree = 53
+)
+
+var (
+	ErrBadChunkingParams = errors.New("faws/multipart: bad chunking parameters")
+)
+
+// ChunkingParams control where the content-defined chunker splits files.
+//
+// Files that are split with different parameters will (almost) never share parts, so a repository should choose them once.
+type ChunkingParams struct {
+	// No chunk is smaller than this, except the last chunk of a file. Files smaller than this are never split.
+	MinSize int64
+	// The expected distance between boundaries, past MinSize. Must be a power of two.
+	AverageSize int64
+	// No chunk is larger than this.
+	MaxSize int64
+	// An irreducible polynomial of degree 53, used by the rolling hash
+	Polynomial uint64
+}
+
+// DefaultChunkingParams are used by repositories that haven't chosen their own
+var DefaultChunkingParams = ChunkingParams{
+	MinSize:     min_chunk_size,
+	AverageSize: average_chunk_size,
+	MaxSize:     max_chunk_size,
+	Polynomial:  uint64(chunker_polynomial),
+}
+
+// Check returns an error if the parameters can't be used
+func (params ChunkingParams) Check() (err error) {
+	switch {
+	case params.MinSize < min_chunk_size_limit:
+		err = fmt.Errorf("%w: the minimum chunk size must be at least %d bytes", ErrBadChunkingParams, min_chunk_size_limit)
+	case params.MaxSize > max_chunk_size_limit:
+		err = fmt.Errorf("%w: the maximum chunk size must be at most %d bytes", ErrBadChunkingParams, max_chunk_size_limit)
+	case params.MaxSize < params.MinSize:
+		err = fmt.Errorf("%w: the maximum chunk size is less than the minimum", ErrBadChunkingParams)
+	case params.AverageSize <= 0 || params.AverageSize&(params.AverageSize-1) != 0:
+		err = fmt.Errorf("%w: the average chunk size must be a power of two", ErrBadChunkingParams)
+	case chunker.Pol(params.Polynomial).Deg() != chunker_polynomial_degree || !chunker.Pol(params.Polynomial).Irreducible():
+		err = fmt.Errorf("%w: %x is not an irreducible polynomial of degree %d", ErrBadChunkingParams, params.Polynomial, chunker_polynomial_degree)
+	}
+	return
+}
+
+// returns the number of bits in the split mask of the rolling hash
+func (params ChunkingParams) average_bits() (bits int) {
+	for size := params.AverageSize; size > 1; size >>= 1 {
+		bits++
+	}
+	return
+}
+
+// RandomPolynomial returns a new polynomial that can be used in [ChunkingParams]
+func RandomPolynomial() (polynomial uint64, err error) {
+	var pol chunker.Pol
+	pol, err = chunker.RandomPolynomial()
+	polynomial = uint64(pol)
+	return
+}
@@ -0,0 +1,21 @@
+package multipart
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+)
+
+func TestChunkingParamsMaxSize(t *testing.T) {
+	params := DefaultChunkingParams
+	if err := params.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	// parts larger than an object may be can't be stored
+	params.MaxSize = cas.MaxObjectSize + 1
+	if err := params.Check(); !errors.Is(err, ErrBadChunkingParams) {
+		t.Fatalf("expected ErrBadChunkingParams, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package multipart
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// chunking parameters small enough to split the sections of a test fixture
+var test_chunking_params = ChunkingParams{
+	MinSize:     0x200,
+	AverageSize: 0x200,
+	MaxSize:     0x800,
+	Polynomial:  uint64(chunker_polynomial),
+}
+
+// the size of a section large enough to be split by the content-defined chunker
+const test_large_size = 6000
+
+type test_chunk struct {
+	section  string
+	position int64
+	data     []byte
+}
+
+// returns size bytes of random data, which is the same for each seed
+func test_random_data(seed int64, size int) (data []byte) {
+	data = make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return
+}
+
+// detects the format of file, and returns its chunker
+func new_test_chunker(t *testing.T, file []byte) (chunker Chunker) {
+	t.Helper()
+	chunker, err := NewChunker(bytes.NewReader(file), WithChunkingParams(test_chunking_params), WithFallback(func(name string, err error) {
+		t.Fatalf("%s chunker failed: %s", name, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// reads every chunk, checking that together they make up the whole file
+func read_test_chunks(t *testing.T, chunker Chunker, file []byte) (chunks []test_chunk) {
+	t.Helper()
+	var position int64
+	for {
+		section := chunker.Section()
+		chunk_position, data, err := chunker.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if chunk_position != position {
+			t.Fatalf("chunk of %s is at %d, expected %d", section, chunk_position, position)
+		}
+		if int64(len(data)) > test_chunking_params.MaxSize {
+			t.Fatalf("chunk of %s is %d bytes", section, len(data))
+		}
+		if position+int64(len(data)) > int64(len(file)) || !bytes.Equal(data, file[position:position+int64(len(data))]) {
+			t.Fatalf("chunk of %s at %d doesn't match the file", section, position)
+		}
+		chunks = append(chunks, test_chunk{section, position, slices.Clone(data)})
+		position += int64(len(data))
+	}
+	if position != int64(len(file)) {
+		t.Fatalf("chunks end at %d, expected %d", position, len(file))
+	}
+	return
+}
+
+// returns the name of each section, in order
+func test_section_names(chunks []test_chunk) (names []string) {
+	for _, chunk := range chunks {
+		if len(names) == 0 || names[len(names)-1] != chunk.section {
+			names = append(names, chunk.section)
+		}
+	}
+	return
+}
+
+// returns the chunks of a section
+func test_section_chunks(chunks []test_chunk, section string) (data [][]byte) {
+	for _, chunk := range chunks {
+		if chunk.section == section {
+			data = append(data, chunk.data)
+		}
+	}
+	return
+}
+
+// checks that a malformed file is given to the generic chunker, after the named chunker fails to start
+func test_fallback(t *testing.T, name string, file []byte) {
+	t.Helper()
+	var failed []string
+	chunker, err := NewChunker(bytes.NewReader(file), WithChunkingParams(test_chunking_params), WithFallback(func(name string, err error) {
+		failed = append(failed, name)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(failed, name) {
+		t.Fatalf("the %s chunker accepted the file", name)
+	}
+	if _, ok := chunker.(*generic_chunker); !ok {
+		t.Fatalf("expected the generic chunker, got %T", chunker)
+	}
+	read_test_chunks(t, chunker, file)
+}
@@ -9,7 +9,9 @@ import (
 
 const (
 	min_chunk_size = 0x800000
-	max_chunk_size = 0x1000000
+	// the split mask the rolling hash has always used
+	average_chunk_size = 0x100000
+	max_chunk_size     = 0x1000000
 
 	chunker_polynomial chunker.Pol = 0x3DA3358B4DC173
 )
@@ -21,11 +23,12 @@ type generic_chunker struct {
 	position uint64
 }
 
-func new_generic_chunker(file io.Reader) (c *generic_chunker) {
+func new_generic_chunker(file io.Reader, params ChunkingParams) (c *generic_chunker) {
 	c = new(generic_chunker)
-	c.buffer = make([]byte, max_chunk_size*2)
-	c.impl = chunker.New(file, chunker_polynomial, chunker.WithBoundaries(min_chunk_size, max_chunk_size))
-	// c.impl = chunker.New(file, chunker_polynomial)
+	c.buffer = make([]byte, params.MaxSize*2)
+	c.impl = chunker.New(file, chunker.Pol(params.Polynomial),
+		chunker.WithBoundaries(uint(params.MinSize), uint(params.MaxSize)),
+		chunker.WithAverageBits(params.average_bits()))
 	return
 }
 
@@ -0,0 +1,228 @@
+package multipart
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	iso9660_sector_size = 2048
+	// the volume descriptors begin at sector 16, after the system area
+	iso9660_volume_descriptors_offset = 16 * iso9660_sector_size
+	// each volume descriptor has "CD001" after its type byte
+	iso9660_magic_offset = iso9660_volume_descriptors_offset + 1
+
+	iso9660_volume_descriptor_primary    = 1
+	iso9660_volume_descriptor_terminator = 255
+
+	iso9660_directory_record_size = 33
+	iso9660_flag_directory        = 0x02
+
+	// refuse to follow directory hierarchies deeper than this
+	iso9660_max_depth = 64
+)
+
+var (
+	iso9660_magic = [5]byte{'C', 'D', '0', '0', '1'}
+)
+
+// an extent of a file or directory, which becomes a section
+type iso9660_extent struct {
+	offset int64
+	size   int64
+	name   string
+}
+
+// iso9660_chunker splits an ISO9660 disc image along the extents of its files.
+//
+// Each file's data becomes its own chunk (or several if it is very large), so two images which contain the
+// same file will share its parts, wherever the file was placed on the disc.
+type iso9660_chunker struct {
+	section_chunker
+	file       io.ReadSeeker
+	block_size int64
+	extents    []iso9660_extent
+	visited    map[int64]bool
+	// the volume descriptors and every directory, for the lazy signature
+	tables []byte
+}
+
+func (c *iso9660_chunker) read_at(offset int64, data []byte) (err error) {
+	if offset < 0 || offset+int64(len(data)) > c.file_size {
+		err = fmt.Errorf("faws/multipart: iso9660_chunker: read out of bounds at %d", offset)
+		return
+	}
+	if _, err = c.file.Seek(offset, io.SeekStart); err != nil {
+		return
+	}
+	_, err = io.ReadFull(c.file, data)
+	return
+}
+
+// returns the extent described by a directory record
+func (c *iso9660_chunker) record_extent(record []byte) (offset, size int64) {
+	offset = int64(binary.LittleEndian.Uint32(record[2:6])) * c.block_size
+	size = int64(binary.LittleEndian.Uint32(record[10:14]))
+	return
+}
+
+// reads a directory and all of its subdirectories
+func (c *iso9660_chunker) walk_directory(record []byte, path string, depth int) (err error) {
+	if depth > iso9660_max_depth {
+		err = fmt.Errorf("faws/multipart: iso9660_chunker: directory hierarchy is too deep")
+		return
+	}
+
+	offset, size := c.record_extent(record)
+	if c.visited[offset] {
+		return
+	}
+	c.visited[offset] = true
+
+	directory := make([]byte, size)
+	if err = c.read_at(offset, directory); err != nil {
+		return
+	}
+	c.tables = append(c.tables, directory...)
+	c.extents = append(c.extents, iso9660_extent{offset, size, "directory " + path + "/"})
+
+	for position := 0; position < len(directory); {
+		length := int(directory[position])
+		if length == 0 {
+			// records never cross a sector boundary, the rest of this sector is padding
+			position = (position/iso9660_sector_size + 1) * iso9660_sector_size
+			continue
+		}
+		if length < iso9660_directory_record_size || position+length > len(directory) {
+			err = fmt.Errorf("faws/multipart: iso9660_chunker: bad directory record in %s", path)
+			return
+		}
+		child := directory[position : position+length]
+		position += length
+
+		name_size := int(child[32])
+		if iso9660_directory_record_size+name_size > len(child) {
+			err = fmt.Errorf("faws/multipart: iso9660_chunker: bad directory record in %s", path)
+			return
+		}
+		name := string(child[iso9660_directory_record_size : iso9660_directory_record_size+name_size])
+		if name == "\x00" || name == "\x01" {
+			// . and ..
+			continue
+		}
+		// remove the version number
+		name, _, _ = strings.Cut(name, ";")
+		child_path := path + "/" + name
+
+		if child[25]&iso9660_flag_directory != 0 {
+			if err = c.walk_directory(child, child_path, depth+1); err != nil {
+				return
+			}
+			continue
+		}
+
+		child_offset, child_size := c.record_extent(child)
+		if child_size > 0 {
+			c.extents = append(c.extents, iso9660_extent{child_offset, child_size, child_path})
+		}
+	}
+
+	return
+}
+
+func (c *iso9660_chunker) detect_sections() (err error) {
+	c.visited = make(map[int64]bool)
+
+	var (
+		descriptor [iso9660_sector_size]byte
+		root       []byte
+		offset     int64 = iso9660_volume_descriptors_offset
+	)
+	for {
+		if err = c.read_at(offset, descriptor[:]); err != nil {
+			return
+		}
+		if [5]byte(descriptor[1:6]) != iso9660_magic {
+			err = fmt.Errorf("faws/multipart: iso9660_chunker: bad volume descriptor at %d", offset)
+			return
+		}
+		c.tables = append(c.tables, descriptor[:]...)
+		offset += iso9660_sector_size
+
+		if descriptor[0] == iso9660_volume_descriptor_terminator {
+			break
+		}
+		if descriptor[0] == iso9660_volume_descriptor_primary && root == nil {
+			c.block_size = int64(binary.LittleEndian.Uint16(descriptor[128:130]))
+			root = slices.Clone(descriptor[156 : 156+34])
+		}
+	}
+	if root == nil || c.block_size == 0 {
+		err = fmt.Errorf("faws/multipart: iso9660_chunker: no primary volume descriptor")
+		return
+	}
+
+	// the system area and volume descriptors
+	c.extents = append(c.extents, iso9660_extent{0, offset, "volume descriptors"})
+
+	if err = c.walk_directory(root, "", 0); err != nil {
+		return
+	}
+
+	// files may share an extent, or even overlap. only the first extent to begin at an offset is kept
+	slices.SortStableFunc(c.extents, func(a, b iso9660_extent) int {
+		return cmp.Compare(a.offset, b.offset)
+	})
+
+	var end int64
+	for _, extent := range c.extents {
+		if extent.offset < end || extent.offset >= c.file_size {
+			continue
+		}
+		if extent.offset > end {
+			// padding, path tables, or anything else not belonging to a file
+			c.sections = append(c.sections, file_section{Offset: end, Name: "extra data"})
+		}
+		c.sections = append(c.sections, file_section{Offset: extent.offset, Name: extent.name})
+		end = min(extent.offset+extent.size, c.file_size)
+	}
+	if end < c.file_size {
+		c.sections = append(c.sections, file_section{Offset: end, Name: "extra data"})
+	}
+
+	c.extents = nil
+	c.visited = nil
+	return
+}
+
+func (c *iso9660_chunker) LazySignature() (s LazySignature, err error) {
+	h := sha256.New()
+	var size_bytes [8]byte
+	binary.LittleEndian.PutUint64(size_bytes[:], uint64(c.file_size))
+	// encode the size of the file
+	h.Write(size_bytes[:])
+	// the directories contain the location, size and date of every file
+	h.Write(c.tables)
+
+	copy(s[:], h.Sum(nil))
+	return
+}
+
+func new_iso9660_chunker(file io.ReadSeeker, size int64, params ChunkingParams) (c *iso9660_chunker, err error) {
+	c = new(iso9660_chunker)
+	c.file = file
+	c.file_size = size
+
+	if err = c.detect_sections(); err != nil {
+		err = fmt.Errorf("error detecting sections: %w", err)
+		return
+	}
+
+	err = c.init_reader(file, size, params)
+	return
+}
@@ -0,0 +1,141 @@
+package multipart
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+)
+
+// writes a directory record to the beginning of record, and returns its length
+func test_iso9660_record(record []byte, name string, sector, size uint32, flags byte) (length int) {
+	length = iso9660_directory_record_size + len(name)
+	length += length % 2
+	record[0] = byte(length)
+	binary.LittleEndian.PutUint32(record[2:6], sector)
+	binary.BigEndian.PutUint32(record[6:10], sector)
+	binary.LittleEndian.PutUint32(record[10:14], size)
+	binary.BigEndian.PutUint32(record[14:18], size)
+	record[25] = flags
+	record[32] = byte(len(name))
+	copy(record[iso9660_directory_record_size:], name)
+	return
+}
+
+// writes a directory into a sector, holding . and .. and its children
+func test_iso9660_directory(sector []byte, self, parent uint32, children func(record []byte)) {
+	position := test_iso9660_record(sector, "\x00", self, iso9660_sector_size, iso9660_flag_directory)
+	position += test_iso9660_record(sector[position:], "\x01", parent, iso9660_sector_size, iso9660_flag_directory)
+	children(sector[position:])
+}
+
+// returns a disc image holding /A.TXT and /SUB/B.BIN
+//
+//	sector 16: primary volume descriptor
+//	sector 17: terminator
+//	sector 18: the root directory
+//	sector 19: A.TXT
+//	sector 20: SUB
+//	sector 21: B.BIN
+func test_iso9660(a, b []byte) (file []byte) {
+	b_sectors := (len(b) + iso9660_sector_size - 1) / iso9660_sector_size
+	file = make([]byte, (21+b_sectors)*iso9660_sector_size)
+	sector := func(n int) []byte {
+		return file[n*iso9660_sector_size : (n+1)*iso9660_sector_size]
+	}
+
+	primary := sector(16)
+	primary[0] = iso9660_volume_descriptor_primary
+	copy(primary[1:], iso9660_magic[:])
+	binary.LittleEndian.PutUint16(primary[128:130], iso9660_sector_size)
+	binary.BigEndian.PutUint16(primary[130:132], iso9660_sector_size)
+	test_iso9660_record(primary[156:], "\x00", 18, iso9660_sector_size, iso9660_flag_directory)
+
+	terminator := sector(17)
+	terminator[0] = iso9660_volume_descriptor_terminator
+	copy(terminator[1:], iso9660_magic[:])
+
+	test_iso9660_directory(sector(18), 18, 18, func(record []byte) {
+		position := test_iso9660_record(record, "A.TXT;1", 19, uint32(len(a)), 0)
+		test_iso9660_record(record[position:], "SUB", 20, iso9660_sector_size, iso9660_flag_directory)
+	})
+	copy(sector(19), a)
+	test_iso9660_directory(sector(20), 20, 18, func(record []byte) {
+		test_iso9660_record(record, "B.BIN;1", 21, uint32(len(b)), 0)
+	})
+	copy(file[21*iso9660_sector_size:], b)
+	return
+}
+
+func TestISO9660Chunker(t *testing.T) {
+	a := test_random_data(1, 100)
+	// B.BIN doesn't fill its last sector
+	b := test_random_data(2, test_large_size+1000)
+	file := test_iso9660(a, b)
+
+	chunker := new_test_chunker(t, file)
+	if _, ok := chunker.(*iso9660_chunker); !ok {
+		t.Fatalf("expected the iso9660 chunker, got %T", chunker)
+	}
+	chunks := read_test_chunks(t, chunker, file)
+
+	names := test_section_names(chunks)
+	expected_names := []string{
+		"volume descriptors",
+		"directory /",
+		"/A.TXT",
+		// the rest of A.TXT's sector
+		"extra data",
+		"directory /SUB/",
+		"/SUB/B.BIN",
+		// the rest of B.BIN's last sector
+		"extra data",
+	}
+	if !slices.Equal(names, expected_names) {
+		t.Fatal(names)
+	}
+	if a_chunks := test_section_chunks(chunks, "/A.TXT"); len(a_chunks) != 1 || !slices.Equal(a_chunks[0], a) {
+		t.Fatal("A.TXT is not a chunk of its own")
+	}
+	if n := len(test_section_chunks(chunks, "/SUB/B.BIN")); n < 2 {
+		t.Fatalf("B.BIN was split into %d chunks", n)
+	}
+
+	// the lazy signature depends on the directories, not the data of the files
+	signature, err := chunker.(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	same_signature, err := new_test_chunker(t, test_iso9660(test_random_data(3, 100), b)).(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same_signature != signature {
+		t.Fatal("the lazy signature depends on the data of the files")
+	}
+	different_signature, err := new_test_chunker(t, test_iso9660(test_random_data(3, 200), b)).(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if different_signature == signature {
+		t.Fatal("the lazy signature of a different image is the same")
+	}
+}
+
+func TestISO9660ChunkerMalformed(t *testing.T) {
+	file := test_iso9660(test_random_data(1, 100), test_random_data(2, test_large_size))
+
+	// there is no terminator
+	no_terminator := slices.Clone(file)
+	no_terminator[17*iso9660_sector_size+1] = 'X'
+	test_fallback(t, "iso9660", no_terminator)
+
+	// the record of B.BIN, after . and .., is too short to be a directory record
+	bad_record := slices.Clone(file)
+	bad_record[20*iso9660_sector_size+2*(iso9660_directory_record_size+1)] = 1
+	test_fallback(t, "iso9660", bad_record)
+
+	// the root directory is beyond the end of the image
+	bad_root := slices.Clone(file)
+	binary.LittleEndian.PutUint32(bad_root[16*iso9660_sector_size+156+2:], uint32(len(file)/iso9660_sector_size))
+	test_fallback(t, "iso9660", bad_root)
+}
@@ -73,6 +73,8 @@ type mpq_chunker struct {
 	file        io.ReadSeeker
 	file_reader io.Reader
 	// file_reader  *bufio.Reader
+	// for large sections
+	params       ChunkingParams
 	chunk_reader Chunker
 	// sorted by offset
 	sections         []mpq_section
@@ -531,9 +533,9 @@ func (c *mpq_chunker) Next() (start int64, data []byte, err error) {
 	length := end - start
 
 	// CDC-chunk the large file
-	if length >= min_chunk_size {
+	if length >= c.params.MinSize {
 		section := &c.sections[c.index]
-		c.chunk_reader = new_generic_chunker(io.LimitReader(c.file_reader, length))
+		c.chunk_reader = new_generic_chunker(io.LimitReader(c.file_reader, length), c.params)
 		start, data, err = c.chunk_reader.Next()
 		if err != nil && errors.Is(err, io.EOF) {
 			c.chunk_reader = nil
@@ -614,8 +616,9 @@ func (c *mpq_chunker) LazySignature() (s LazySignature, err error) {
 	return
 }
 
-func new_mpq_chunker(file io.ReadSeeker, size int64) (c *mpq_chunker, err error) {
+func new_mpq_chunker(file io.ReadSeeker, size int64, params ChunkingParams) (c *mpq_chunker, err error) {
 	c = new(mpq_chunker)
+	c.params = params
 
 	c.attributes_block_entry = -1
 	c.attributes_block_offset = -1
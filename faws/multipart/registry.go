@@ -0,0 +1,239 @@
+package multipart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	mpqinfo "github.com/Gophercraft/mpq/info"
+)
+
+// DetectHeaderSize is the most bytes from the beginning of a file which are given to a [DetectFunc].
+// Some formats have their magic far from the beginning of the file.
+const DetectHeaderSize = 0x10000
+
+var (
+	ErrUnknownChunker = errors.New("faws/multipart: unknown chunker")
+)
+
+// A DetectFunc returns true if a file is in a format understood by a chunker.
+// header is the beginning of the file, and is shorter than [DetectHeaderSize] only if the file is.
+type DetectFunc func(header []byte, size int64) bool
+
+// A NewChunkerFunc creates a chunker for a file of the given size. The file is positioned at its beginning.
+// Any part of the file which is split by content should be split according to params.
+//
+// If the file turns out not to be in the expected format, an error should be returned, and the generic chunker will be used instead.
+type NewChunkerFunc func(file io.ReadSeeker, size int64, params ChunkingParams) (Chunker, error)
+
+type registered_chunker struct {
+	name        string
+	detect      DetectFunc
+	new_chunker NewChunkerFunc
+}
+
+var (
+	registry_guard sync.RWMutex
+	// in the order of registration, which is also the order of detection
+	registry []registered_chunker
+)
+
+// Register makes a chunker available by name to [NewChunker].
+//
+// If detect is nil, the chunker is never chosen automatically, and must be requested with [WithChunker].
+// Otherwise, detectors are consulted in the order they were registered, after the built-in chunkers.
+// Register panics if a chunker is already registered with the same name.
+func Register(name string, detect DetectFunc, new_chunker NewChunkerFunc) {
+	registry_guard.Lock()
+	defer registry_guard.Unlock()
+
+	if name == "" || new_chunker == nil {
+		panic("faws/multipart: Register: chunker must have a name and a constructor")
+	}
+	if slices.ContainsFunc(registry, func(r registered_chunker) bool { return r.name == name }) {
+		panic("faws/multipart: Register: chunker registered twice: " + name)
+	}
+	registry = append(registry, registered_chunker{name, detect, new_chunker})
+}
+
+// Chunkers returns the names of all registered chunkers
+func Chunkers() (names []string) {
+	registry_guard.RLock()
+	defer registry_guard.RUnlock()
+
+	names = make([]string, len(registry))
+	for i := range registry {
+		names[i] = registry[i].name
+	}
+	return
+}
+
+func lookup_chunker(name string) (r registered_chunker, ok bool) {
+	registry_guard.RLock()
+	defer registry_guard.RUnlock()
+
+	i := slices.IndexFunc(registry, func(r registered_chunker) bool { return r.name == name })
+	if i < 0 {
+		return
+	}
+	r = registry[i]
+	ok = true
+	return
+}
+
+// returns the chunkers which claim to understand the file
+func detect_chunkers(header []byte, size int64) (detected []registered_chunker) {
+	registry_guard.RLock()
+	defer registry_guard.RUnlock()
+
+	for _, r := range registry {
+		if r.detect != nil && r.detect(header, size) {
+			detected = append(detected, r)
+		}
+	}
+	return
+}
+
+type chunker_options struct {
+	name     string
+	params   ChunkingParams
+	fallback func(name string, err error)
+}
+
+// A ChunkerOption changes how [NewChunker] chooses a chunker
+type ChunkerOption func(*chunker_options)
+
+// WithChunker is a [ChunkerOption] that uses the chunker registered as name, instead of detecting the format of the file.
+// An empty name means the format is detected as usual.
+func WithChunker(name string) ChunkerOption {
+	return func(o *chunker_options) {
+		o.name = name
+	}
+}
+
+// WithChunkingParams is a [ChunkerOption] that splits the file according to params, rather than [DefaultChunkingParams]
+func WithChunkingParams(params ChunkingParams) ChunkerOption {
+	return func(o *chunker_options) {
+		o.params = params
+	}
+}
+
+// WithFallback is a [ChunkerOption] that calls fallback whenever a chunker fails to start,
+// before the file is given to the generic chunker instead.
+func WithFallback(fallback func(name string, err error)) ChunkerOption {
+	return func(o *chunker_options) {
+		o.fallback = fallback
+	}
+}
+
+// detects magic at an offset in the header
+func magic_at(header []byte, offset int, magic []byte) bool {
+	return len(header) >= offset+len(magic) && string(header[offset:offset+len(magic)]) == string(magic)
+}
+
+func init() {
+	Register("generic", nil, func(file io.ReadSeeker, size int64, params ChunkingParams) (Chunker, error) {
+		return new_generic_chunker(file, params), nil
+	})
+	// a part can't be larger than the maximum chunk size, or it might not fit in an object
+	Register("single", nil, func(file io.ReadSeeker, size int64, params ChunkingParams) (Chunker, error) {
+		if size > params.MaxSize {
+			return nil, fmt.Errorf("faws/multipart: a file of %d bytes is larger than the maximum chunk size", size)
+		}
+		return single_chunk{file}, nil
+	})
+	// CASC data archives begin with a blob header, rather than magic
+	Register("casc", func(header []byte, size int64) bool {
+		return magic_at(header, casc_blob_header_size, blte_magic[:])
+	}, func(file io.ReadSeeker, size int64, params ChunkingParams) (Chunker, error) {
+		return new_casc_chunker(file, size, params)
+	})
+	Register("tar", func(header []byte, size int64) bool {
+		return magic_at(header, tar_magic_offset, tar_magic[:])
+	}, func(file io.ReadSeeker, size int64, params ChunkingParams) (Chunker, error) {
+		return new_tar_chunker(file, size, params)
+	})
+	Register("iso9660", func(header []byte, size int64) bool {
+		return magic_at(header, iso9660_magic_offset, iso9660_magic[:])
+	}, func(file io.ReadSeeker, size int64, params ChunkingParams) (Chunker, error) {
+		return new_iso9660_chunker(file, size, params)
+	})
+	Register("mpq", func(header []byte, size int64) bool {
+		return magic_at(header, 0, mpqinfo.HeaderDataSignature[:])
+	}, func(file io.ReadSeeker, size int64, params ChunkingParams) (Chunker, error) {
+		return new_mpq_chunker(file, size, params)
+	})
+	// not every file that begins with a local file header is a well-formed ZIP archive
+	Register("zip", func(header []byte, size int64) bool {
+		return magic_at(header, 0, zip_magic[:])
+	}, func(file io.ReadSeeker, size int64, params ChunkingParams) (Chunker, error) {
+		return new_zip_chunker(file, size, params)
+	})
+}
+
+// NewChunker returns a chunker for the file.
+//
+// Small files are stored in a single chunk. Otherwise, the format of the file is detected by the registered chunkers,
+// and if none of them accept it, the generic content-defined chunker is used.
+func NewChunker(file io.ReadSeeker, options ...ChunkerOption) (chunker Chunker, err error) {
+	var o chunker_options
+	o.params = DefaultChunkingParams
+	for _, option := range options {
+		option(&o)
+	}
+	if err = o.params.Check(); err != nil {
+		return
+	}
+
+	var size int64
+	size, err = file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+
+	var candidates []registered_chunker
+	if o.name != "" {
+		r, ok := lookup_chunker(o.name)
+		if !ok {
+			err = fmt.Errorf("%w: %s", ErrUnknownChunker, o.name)
+			return
+		}
+		candidates = append(candidates, r)
+	} else {
+		if size < o.params.MinSize {
+			chunker = single_chunk{file}
+			return
+		}
+
+		header := make([]byte, min(size, DetectHeaderSize))
+		if _, err = io.ReadFull(file, header); err != nil {
+			return
+		}
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return
+		}
+		candidates = detect_chunkers(header, size)
+	}
+
+	for _, candidate := range candidates {
+		var start_err error
+		chunker, start_err = candidate.new_chunker(file, size, o.params)
+		if start_err == nil {
+			return
+		}
+		if o.fallback != nil {
+			o.fallback(candidate.name, start_err)
+		}
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return
+		}
+	}
+
+	chunker = new_generic_chunker(file, o.params)
+	return
+}
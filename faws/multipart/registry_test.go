@@ -0,0 +1,35 @@
+package multipart
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSingleChunkerTooLarge(t *testing.T) {
+	file := test_random_data(1, int(test_chunking_params.MaxSize)+1)
+
+	var failed []string
+	chunker, err := NewChunker(bytes.NewReader(file), WithChunkingParams(test_chunking_params), WithChunker("single"), WithFallback(func(name string, err error) {
+		failed = append(failed, name)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0] != "single" {
+		t.Fatalf("the single chunker accepted a file larger than the maximum chunk size: %v", failed)
+	}
+	if _, ok := chunker.(*generic_chunker); !ok {
+		t.Fatalf("expected the generic chunker, got %T", chunker)
+	}
+	read_test_chunks(t, chunker, file)
+
+	// a file of the maximum chunk size is still one chunk
+	file = file[:test_chunking_params.MaxSize]
+	chunker, err = NewChunker(bytes.NewReader(file), WithChunkingParams(test_chunking_params), WithChunker("single"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks := read_test_chunks(t, chunker, file); len(chunks) != 1 {
+		t.Fatalf("the file was split into %d chunks", len(chunks))
+	}
+}
@@ -0,0 +1,119 @@
+package multipart
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// a region of a file which is chunked separately from its neighbours
+type file_section struct {
+	// where the section begins. it ends where the next section begins
+	Offset int64
+	// the name returned by Chunker.Section()
+	Name string
+}
+
+// section_chunker emits one chunk for each section of a file, in order. Sections too large for a single chunk
+// are split further by the content-defined chunker.
+//
+// Format-specific chunkers only need to find the sections; the first must begin at 0.
+type section_chunker struct {
+	file_size   int64
+	file_reader io.Reader
+	// for large sections
+	params       ChunkingParams
+	chunk_reader Chunker
+	// the bytes of the section being split by chunk_reader which it hasn't returned yet
+	section_remaining int64
+	// sorted by offset
+	sections []file_section
+	index    int
+}
+
+// begins reading the file from the start
+func (c *section_chunker) init_reader(file io.ReadSeeker, size int64, params ChunkingParams) (err error) {
+	c.file_size = size
+	c.params = params
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	c.file_reader = bufio.NewReaderSize(file, default_bs)
+	return
+}
+
+// returns the name of the section the next chunk belongs to
+func (c *section_chunker) Section() string {
+	c.skip_empty_sections()
+	if c.index >= len(c.sections) {
+		return ""
+	}
+	return c.sections[c.index].Name
+}
+
+func (c *section_chunker) slice_section(index int) (i, j int64) {
+	i = c.sections[index].Offset
+	if index+1 == len(c.sections) {
+		j = c.file_size
+	} else {
+		j = c.sections[index+1].Offset
+	}
+	return
+}
+
+// moves past sections with no data, which produce no chunks
+func (c *section_chunker) skip_empty_sections() {
+	if c.chunk_reader != nil {
+		return
+	}
+	for c.index < len(c.sections) {
+		if i, j := c.slice_section(c.index); j > i {
+			return
+		}
+		c.index++
+	}
+}
+
+func (c *section_chunker) Next() (start int64, data []byte, err error) {
+	c.skip_empty_sections()
+	if c.index >= len(c.sections) {
+		err = io.EOF
+		return
+	}
+
+	section := &c.sections[c.index]
+
+	if c.chunk_reader == nil {
+		var end int64
+		start, end = c.slice_section(c.index)
+		length := end - start
+
+		if length < c.params.MinSize {
+			data = make([]byte, length)
+			_, err = io.ReadFull(c.file_reader, data)
+			c.index++
+			return
+		}
+
+		// large sections are split further by the content-defined chunker
+		c.chunk_reader = new_generic_chunker(io.LimitReader(c.file_reader, length), c.params)
+		c.section_remaining = length
+	}
+
+	start, data, err = c.chunk_reader.Next()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			// the file ended before the section did
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+	start += section.Offset
+	c.section_remaining -= int64(len(data))
+	if c.section_remaining <= 0 {
+		// move on to the next section straight away, so that Section() names the next chunk
+		c.chunk_reader = nil
+		c.index++
+	}
+	return
+}
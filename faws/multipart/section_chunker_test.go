@@ -0,0 +1,53 @@
+package multipart
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestSectionChunker(t *testing.T) {
+	file := test_random_data(1, 100+test_large_size+50)
+
+	var c section_chunker
+	c.sections = []file_section{
+		{Offset: 0, Name: "empty"},
+		{Offset: 0, Name: "small"},
+		{Offset: 100, Name: "large"},
+		{Offset: 100 + test_large_size, Name: "tail"},
+	}
+	if err := c.init_reader(bytes.NewReader(file), int64(len(file)), test_chunking_params); err != nil {
+		t.Fatal(err)
+	}
+	chunks := read_test_chunks(t, &c, file)
+
+	// empty sections produce no chunks, and every chunk is named after the section it belongs to
+	names := test_section_names(chunks)
+	if !slices.Equal(names, []string{"small", "large", "tail"}) {
+		t.Fatal(names)
+	}
+
+	// a section of at least MinSize is split exactly as the generic chunker would split it alone
+	var expected [][]byte
+	generic := new_generic_chunker(bytes.NewReader(file[100:100+test_large_size]), test_chunking_params)
+	for {
+		_, data, err := generic.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, slices.Clone(data))
+	}
+	if len(expected) < 2 {
+		t.Fatalf("the large section was split into %d chunks", len(expected))
+	}
+	if !slices.EqualFunc(test_section_chunks(chunks, "large"), expected, bytes.Equal) {
+		t.Fatal("the large section was split differently from the generic chunker")
+	}
+	if c.Section() != "" {
+		t.Fatalf("Section() is %q after the last chunk", c.Section())
+	}
+}
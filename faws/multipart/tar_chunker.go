@@ -0,0 +1,211 @@
+package multipart
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	tar_block_size = 512
+	// ustar (and GNU tar) headers have this magic at offset 257
+	tar_magic_offset = 257
+)
+
+var (
+	tar_magic = [5]byte{'u', 's', 't', 'a', 'r'}
+)
+
+// tar_chunker splits a tar archive along the boundaries of its members.
+//
+// The headers of each member (including any pax or GNU long name headers) become one chunk, and the member's data
+// becomes another, so that a file's content produces the same chunks even when its header (e.g. the modification time) changes.
+type tar_chunker struct {
+	section_chunker
+	// all headers, for the lazy signature
+	headers []byte
+}
+
+// parses an octal (or base-256) numeric header field
+func tar_number(field []byte) (n int64, err error) {
+	if len(field) > 0 && field[0]&0x80 != 0 {
+		// base-256, used by GNU tar for very large files
+		n = int64(field[0] & 0x7f)
+		for _, b := range field[1:] {
+			n = n<<8 | int64(b)
+		}
+		return
+	}
+	s := strings.Trim(string(field), " \x00")
+	if s == "" {
+		return
+	}
+	n, err = strconv.ParseInt(s, 8, 64)
+	return
+}
+
+// returns true if the header's checksum is correct
+func tar_checksum_ok(header []byte) bool {
+	recorded, err := tar_number(header[148:156])
+	if err != nil {
+		return false
+	}
+	var sum int64
+	for i, b := range header {
+		if i >= 148 && i < 156 {
+			b = ' '
+		}
+		sum += int64(b)
+	}
+	return sum == recorded
+}
+
+// returns a NUL-terminated string
+func tar_string(field []byte) string {
+	if i := bytes.IndexByte(field, 0); i >= 0 {
+		field = field[:i]
+	}
+	return string(field)
+}
+
+// returns the path from pax extended header records ("length path=value\n")
+func tar_pax_path(records []byte) (path string) {
+	for len(records) > 0 {
+		space := bytes.IndexByte(records, ' ')
+		if space < 0 {
+			return
+		}
+		length, err := strconv.Atoi(string(records[:space]))
+		if err != nil || length <= space || length > len(records) {
+			return
+		}
+		record := strings.TrimSuffix(string(records[space+1:length]), "\n")
+		if key, value, found := strings.Cut(record, "="); found && key == "path" {
+			path = value
+		}
+		records = records[length:]
+	}
+	return
+}
+
+func (c *tar_chunker) detect_sections(file io.ReadSeeker) (err error) {
+	var (
+		header    [tar_block_size]byte
+		offset    int64
+		long_name string
+		// the offset where the current member's headers began
+		headers_offset int64 = -1
+	)
+
+	for offset+tar_block_size <= c.file_size {
+		if _, err = file.Seek(offset, io.SeekStart); err != nil {
+			return
+		}
+		if _, err = io.ReadFull(file, header[:]); err != nil {
+			return
+		}
+
+		if header == [tar_block_size]byte{} {
+			// end of archive
+			break
+		}
+		if !tar_checksum_ok(header[:]) {
+			err = fmt.Errorf("faws/multipart: tar_chunker: bad header checksum at %d", offset)
+			return
+		}
+
+		var size int64
+		if size, err = tar_number(header[124:136]); err != nil {
+			return
+		}
+		data_offset := offset + tar_block_size
+		padded_size := (size + tar_block_size - 1) / tar_block_size * tar_block_size
+		if size < 0 || data_offset+padded_size > c.file_size {
+			err = fmt.Errorf("faws/multipart: tar_chunker: member at %d extends past the end of the file", offset)
+			return
+		}
+
+		if headers_offset < 0 {
+			headers_offset = offset
+		}
+		c.headers = append(c.headers, header[:]...)
+
+		switch typeflag := header[156]; typeflag {
+		case 'x', 'g', 'L', 'K':
+			// these headers carry information about the next member in their data
+			extended := make([]byte, size)
+			if _, err = io.ReadFull(file, extended); err != nil {
+				return
+			}
+			c.headers = append(c.headers, extended...)
+			switch typeflag {
+			case 'x':
+				if path := tar_pax_path(extended); path != "" {
+					long_name = path
+				}
+			case 'L':
+				long_name = tar_string(extended)
+			}
+			offset = data_offset + padded_size
+			continue
+		}
+
+		name := long_name
+		if name == "" {
+			name = tar_string(header[0:100])
+			if prefix := tar_string(header[345:500]); prefix != "" && string(header[tar_magic_offset:tar_magic_offset+6]) == "ustar\x00" {
+				// POSIX ustar splits long names into a prefix and name
+				name = prefix + "/" + name
+			}
+		}
+		long_name = ""
+
+		c.sections = append(c.sections, file_section{Offset: headers_offset, Name: name + " (header)"})
+		if size > 0 {
+			c.sections = append(c.sections, file_section{Offset: data_offset, Name: name})
+		}
+		headers_offset = -1
+		offset = data_offset + padded_size
+	}
+
+	if len(c.sections) == 0 {
+		err = fmt.Errorf("faws/multipart: tar_chunker: no members found")
+		return
+	}
+
+	// end of archive blocks, and the padding to the end of the last record
+	if offset < c.file_size {
+		c.sections = append(c.sections, file_section{Offset: offset, Name: "end of archive"})
+	}
+	return
+}
+
+func (c *tar_chunker) LazySignature() (s LazySignature, err error) {
+	h := sha256.New()
+	var size_bytes [8]byte
+	binary.LittleEndian.PutUint64(size_bytes[:], uint64(c.file_size))
+	// encode the size of the file
+	h.Write(size_bytes[:])
+	// the headers contain the name, size and modification time of each member
+	h.Write(c.headers)
+
+	copy(s[:], h.Sum(nil))
+	return
+}
+
+func new_tar_chunker(file io.ReadSeeker, size int64, params ChunkingParams) (c *tar_chunker, err error) {
+	c = new(tar_chunker)
+	c.file_size = size
+
+	if err = c.detect_sections(file); err != nil {
+		err = fmt.Errorf("error detecting sections: %w", err)
+		return
+	}
+
+	err = c.init_reader(file, size, params)
+	return
+}
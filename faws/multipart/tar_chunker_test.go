@@ -0,0 +1,123 @@
+package multipart
+
+import (
+	"archive/tar"
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type test_tar_member struct {
+	name     string
+	data     []byte
+	mod_time time.Time
+}
+
+// returns a tar archive of regular files
+func test_tar(t *testing.T, format tar.Format, members ...test_tar_member) []byte {
+	var b bytes.Buffer
+	w := tar.NewWriter(&b)
+	for _, member := range members {
+		header := tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     member.name,
+			Size:     int64(len(member.data)),
+			Mode:     0644,
+			ModTime:  member.mod_time,
+			Format:   format,
+		}
+		if err := w.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+		w.Write(member.data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestTarChunker(t *testing.T) {
+	mod_time := time.Unix(1700000000, 0)
+	// too long for the name field, so it is stored in a pax header
+	long_name := strings.Repeat("directory/", 12) + "d.txt"
+	members := []test_tar_member{
+		{"a.txt", test_random_data(1, 100), mod_time},
+		{"b.bin", test_random_data(2, test_large_size), mod_time},
+		{"empty", nil, mod_time},
+		{long_name, test_random_data(3, 300), mod_time},
+	}
+	file := test_tar(t, tar.FormatPAX, members...)
+
+	chunker := new_test_chunker(t, file)
+	if _, ok := chunker.(*tar_chunker); !ok {
+		t.Fatalf("expected the tar chunker, got %T", chunker)
+	}
+	chunks := read_test_chunks(t, chunker, file)
+
+	names := test_section_names(chunks)
+	expected_names := []string{
+		"a.txt (header)", "a.txt",
+		"b.bin (header)", "b.bin",
+		"empty (header)",
+		long_name + " (header)", long_name,
+		"end of archive",
+	}
+	if !slices.Equal(names, expected_names) {
+		t.Fatal(names)
+	}
+	if n := len(test_section_chunks(chunks, "b.bin")); n < 2 {
+		t.Fatalf("b.bin was split into %d chunks", n)
+	}
+
+	// a header that changes leaves the member's data alone
+	touched := slices.Clone(members)
+	touched[0].mod_time = mod_time.Add(time.Hour)
+	touched_file := test_tar(t, tar.FormatPAX, touched...)
+	touched_chunker := new_test_chunker(t, touched_file)
+	touched_chunks := read_test_chunks(t, touched_chunker, touched_file)
+	if slices.EqualFunc(test_section_chunks(chunks, "a.txt (header)"), test_section_chunks(touched_chunks, "a.txt (header)"), bytes.Equal) {
+		t.Fatal("the header of a.txt didn't change")
+	}
+	for _, name := range []string{"a.txt", "b.bin", long_name} {
+		if !slices.EqualFunc(test_section_chunks(chunks, name), test_section_chunks(touched_chunks, name), bytes.Equal) {
+			t.Fatalf("the chunks of %s changed", name)
+		}
+	}
+
+	// the lazy signature depends on the headers, not the data
+	signature, err := chunker.(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	same_headers := slices.Clone(members)
+	same_headers[1].data = test_random_data(4, test_large_size)
+	same_signature, err := new_test_chunker(t, test_tar(t, tar.FormatPAX, same_headers...)).(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same_signature != signature {
+		t.Fatal("the lazy signature depends on the data of the members")
+	}
+	touched_signature, err := touched_chunker.(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if touched_signature == signature {
+		t.Fatal("the lazy signature of a different archive is the same")
+	}
+}
+
+func TestTarChunkerMalformed(t *testing.T) {
+	file := test_tar(t, tar.FormatUSTAR, test_tar_member{"a.txt", test_random_data(1, 2000), time.Unix(0, 0)}, test_tar_member{"b.txt", test_random_data(2, test_large_size), time.Unix(0, 0)})
+
+	// the checksum of the first header is wrong
+	bad_checksum := slices.Clone(file)
+	bad_checksum[0] = 'A'
+	test_fallback(t, "tar", bad_checksum)
+
+	// truncated in the middle of the last member
+	test_fallback(t, "tar", file[:len(file)-test_large_size/2])
+}
@@ -0,0 +1,323 @@
+package multipart
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+)
+
+const (
+	zip_central_directory_header_signature           = 0x02014b50
+	zip_end_of_central_directory_signature           = 0x06054b50
+	zip64_end_of_central_directory_signature         = 0x06064b50
+	zip64_end_of_central_directory_locator_signature = 0x07064b50
+
+	zip_end_of_central_directory_size           = 22
+	zip64_end_of_central_directory_locator_size = 20
+	zip64_end_of_central_directory_size         = 56
+	zip_central_directory_header_size           = 46
+	// the end of central directory record may be followed by a comment of up to 65535 bytes
+	zip_max_comment_size = 0xFFFF
+
+	zip64_extra_field_id = 0x0001
+)
+
+var (
+	// the magic bytes at the beginning of an ordinary ZIP file
+	zip_magic = [4]byte{'P', 'K', 3, 4}
+)
+
+type zip_section_type uint8
+
+const (
+	zip_section_extra zip_section_type = iota
+	zip_section_member
+	zip_section_central_directory
+)
+
+var zip_section_type_names = []string{
+	"extra data",
+	"member",
+	"central directory",
+}
+
+func (t zip_section_type) String() string {
+	return zip_section_type_names[t]
+}
+
+type zip_section struct {
+	Type   zip_section_type
+	Offset int64
+	// the name of the member, if Type == zip_section_member
+	Name string
+}
+
+// zip_chunker splits a ZIP archive (.zip, .pk3, .pak, .jar...) along the boundaries of its members.
+//
+// Each member (its local file header, compressed data and data descriptor) becomes one chunk, or several if
+// it is very large. The central directory and end of central directory records become the last chunk.
+// When a ZIP file is rebuilt with only a few changed members, the remaining members still produce the same chunks.
+type zip_chunker struct {
+	section_chunker
+	file io.ReadSeeker
+	// sorted by offset
+	zip_sections []zip_section
+	// the offset of the first byte of the archive, which is not always 0 (e.g. self-extracting archives)
+	archive_position int64
+	// the offset of the central directory
+	central_directory_position int64
+	// the central directory records, followed by the zip64 end of central directory, its locator, and the end of central directory
+	directory_data []byte
+}
+
+func (c *zip_chunker) read_at(offset int64, data []byte) (err error) {
+	if offset < 0 || offset+int64(len(data)) > c.file_size {
+		err = fmt.Errorf("faws/multipart: zip_chunker: read out of bounds at %d", offset)
+		return
+	}
+	if _, err = c.file.Seek(offset, io.SeekStart); err != nil {
+		return
+	}
+	_, err = io.ReadFull(c.file, data)
+	return
+}
+
+// locates the end of central directory record, which is somewhere in the last 65557 bytes of the file
+func (c *zip_chunker) find_end_of_central_directory() (offset int64, err error) {
+	tail_size := min(c.file_size, zip_end_of_central_directory_size+zip_max_comment_size)
+	tail := make([]byte, tail_size)
+	if err = c.read_at(c.file_size-tail_size, tail); err != nil {
+		return
+	}
+
+	for i := len(tail) - zip_end_of_central_directory_size; i >= 0; i-- {
+		if binary.LittleEndian.Uint32(tail[i:]) != zip_end_of_central_directory_signature {
+			continue
+		}
+		// the comment length must reach exactly to the end of the file
+		comment_size := int(binary.LittleEndian.Uint16(tail[i+20:]))
+		if i+zip_end_of_central_directory_size+comment_size != len(tail) {
+			continue
+		}
+		offset = c.file_size - tail_size + int64(i)
+		return
+	}
+
+	err = fmt.Errorf("faws/multipart: zip_chunker: end of central directory not found")
+	return
+}
+
+func (c *zip_chunker) insert_section(section zip_section) (err error) {
+	if section.Offset < 0 || section.Offset > c.central_directory_position {
+		err = fmt.Errorf("faws/multipart: zip_chunker: %s at %d is outside of the archive", section.Type, section.Offset)
+		return
+	}
+
+	i := sort.Search(len(c.zip_sections), func(i int) bool {
+		return c.zip_sections[i].Offset >= section.Offset
+	})
+	if i < len(c.zip_sections) && c.zip_sections[i].Offset == section.Offset {
+		if c.zip_sections[i].Type == section.Type {
+			return
+		}
+		err = fmt.Errorf("faws/multipart: zip_chunker: duplicate section: new: %s, current %s, offset %d", section.Type, c.zip_sections[i].Type, section.Offset)
+		return
+	}
+
+	c.zip_sections = slices.Insert(c.zip_sections, i, section)
+	return
+}
+
+// reads the local header offset of a central directory record, which may be stored in a zip64 extra field
+func zip_local_header_offset(record []byte, extra []byte) (offset int64, err error) {
+	offset = int64(binary.LittleEndian.Uint32(record[42:]))
+	if offset != 0xFFFFFFFF {
+		return
+	}
+
+	uncompressed_size := binary.LittleEndian.Uint32(record[24:])
+	compressed_size := binary.LittleEndian.Uint32(record[20:])
+
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:])
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		extra = extra[4:]
+		if size > len(extra) {
+			break
+		}
+		field := extra[:size]
+		extra = extra[size:]
+		if id != zip64_extra_field_id {
+			continue
+		}
+
+		// the zip64 extra field only contains the values which overflowed, in this order
+		if uncompressed_size == 0xFFFFFFFF {
+			field = field[min(8, len(field)):]
+		}
+		if compressed_size == 0xFFFFFFFF {
+			field = field[min(8, len(field)):]
+		}
+		if len(field) < 8 {
+			break
+		}
+		offset = int64(binary.LittleEndian.Uint64(field))
+		return
+	}
+
+	err = fmt.Errorf("faws/multipart: zip_chunker: missing zip64 local header offset")
+	return
+}
+
+func (c *zip_chunker) detect_sections() (err error) {
+	var end_of_central_directory_position int64
+	end_of_central_directory_position, err = c.find_end_of_central_directory()
+	if err != nil {
+		return
+	}
+
+	var end_of_central_directory [zip_end_of_central_directory_size]byte
+	if err = c.read_at(end_of_central_directory_position, end_of_central_directory[:]); err != nil {
+		return
+	}
+
+	var (
+		disk_number              = binary.LittleEndian.Uint16(end_of_central_directory[4:])
+		entry_count              = uint64(binary.LittleEndian.Uint16(end_of_central_directory[10:]))
+		central_directory_size   = uint64(binary.LittleEndian.Uint32(end_of_central_directory[12:]))
+		central_directory_offset = uint64(binary.LittleEndian.Uint32(end_of_central_directory[16:]))
+		central_directory_end    = end_of_central_directory_position
+	)
+
+	// zip64 archives store the real values in another record, found through a locator just before the end of central directory
+	if (entry_count == 0xFFFF || central_directory_size == 0xFFFFFFFF || central_directory_offset == 0xFFFFFFFF) && end_of_central_directory_position >= zip64_end_of_central_directory_locator_size {
+		var locator [zip64_end_of_central_directory_locator_size]byte
+		if err = c.read_at(end_of_central_directory_position-zip64_end_of_central_directory_locator_size, locator[:]); err != nil {
+			return
+		}
+		if binary.LittleEndian.Uint32(locator[0:]) == zip64_end_of_central_directory_locator_signature {
+			// the zip64 record immediately precedes the locator
+			zip64_position := end_of_central_directory_position - zip64_end_of_central_directory_locator_size - zip64_end_of_central_directory_size
+			var zip64_record [zip64_end_of_central_directory_size]byte
+			if err = c.read_at(zip64_position, zip64_record[:]); err != nil {
+				return
+			}
+			if binary.LittleEndian.Uint32(zip64_record[0:]) != zip64_end_of_central_directory_signature {
+				err = fmt.Errorf("faws/multipart: zip_chunker: bad zip64 end of central directory")
+				return
+			}
+			disk_number = uint16(binary.LittleEndian.Uint32(zip64_record[16:]))
+			entry_count = binary.LittleEndian.Uint64(zip64_record[32:])
+			central_directory_size = binary.LittleEndian.Uint64(zip64_record[40:])
+			central_directory_offset = binary.LittleEndian.Uint64(zip64_record[48:])
+			central_directory_end = zip64_position
+		}
+	}
+
+	if disk_number != 0 {
+		err = fmt.Errorf("faws/multipart: zip_chunker: multi-disk archives are not supported")
+		return
+	}
+	if central_directory_size > uint64(central_directory_end) {
+		err = fmt.Errorf("faws/multipart: zip_chunker: central directory is larger than the file")
+		return
+	}
+
+	// offsets in the archive are relative to its beginning, which is where the central directory says it should be
+	c.central_directory_position = central_directory_end - int64(central_directory_size)
+	c.archive_position = c.central_directory_position - int64(central_directory_offset)
+	if c.archive_position < 0 {
+		err = fmt.Errorf("faws/multipart: zip_chunker: bad central directory offset")
+		return
+	}
+
+	// everything from the central directory to the end of the file is hashed for the lazy signature
+	c.directory_data = make([]byte, c.file_size-c.central_directory_position)
+	if err = c.read_at(c.central_directory_position, c.directory_data); err != nil {
+		return
+	}
+
+	c.zip_sections = append(c.zip_sections, zip_section{Type: zip_section_central_directory, Offset: c.central_directory_position})
+
+	records := c.directory_data[:central_directory_size]
+	for i := uint64(0); i < entry_count; i++ {
+		if len(records) < zip_central_directory_header_size || binary.LittleEndian.Uint32(records) != zip_central_directory_header_signature {
+			err = fmt.Errorf("faws/multipart: zip_chunker: bad central directory record %d", i)
+			return
+		}
+		name_size := int(binary.LittleEndian.Uint16(records[28:]))
+		extra_size := int(binary.LittleEndian.Uint16(records[30:]))
+		comment_size := int(binary.LittleEndian.Uint16(records[32:]))
+		record_size := zip_central_directory_header_size + name_size + extra_size + comment_size
+		if record_size > len(records) {
+			err = fmt.Errorf("faws/multipart: zip_chunker: central directory record %d is truncated", i)
+			return
+		}
+
+		var section zip_section
+		section.Type = zip_section_member
+		section.Name = string(records[zip_central_directory_header_size : zip_central_directory_header_size+name_size])
+		var local_header_offset int64
+		local_header_offset, err = zip_local_header_offset(records[:zip_central_directory_header_size], records[zip_central_directory_header_size+name_size:zip_central_directory_header_size+name_size+extra_size])
+		if err != nil {
+			return
+		}
+		section.Offset = c.archive_position + local_header_offset
+		if err = c.insert_section(section); err != nil {
+			return
+		}
+
+		records = records[record_size:]
+	}
+
+	// anything before the first member (e.g. the stub of a self-extracting archive)
+	if c.zip_sections[0].Offset != 0 {
+		if err = c.insert_section(zip_section{Type: zip_section_extra, Offset: 0}); err != nil {
+			return
+		}
+	}
+
+	// members are named after their path in the archive
+	c.sections = make([]file_section, len(c.zip_sections))
+	for i, section := range c.zip_sections {
+		c.sections[i].Offset = section.Offset
+		if section.Type == zip_section_member {
+			c.sections[i].Name = section.Name
+		} else {
+			c.sections[i].Name = section.Type.String()
+		}
+	}
+
+	return
+}
+
+func (c *zip_chunker) LazySignature() (s LazySignature, err error) {
+	h := sha256.New()
+	var size_bytes [8]byte
+	binary.LittleEndian.PutUint64(size_bytes[:], uint64(c.file_size))
+	// encode the size of the file
+	h.Write(size_bytes[:])
+	// the central directory records contain the CRC-32, sizes and offsets of every member,
+	// and the end of central directory records describe the directory itself
+	h.Write(c.directory_data)
+
+	copy(s[:], h.Sum(nil))
+	return
+}
+
+func new_zip_chunker(file io.ReadSeeker, size int64, params ChunkingParams) (c *zip_chunker, err error) {
+	c = new(zip_chunker)
+	c.file = file
+	c.file_size = size
+
+	if err = c.detect_sections(); err != nil {
+		err = fmt.Errorf("error detecting sections: %w", err)
+		return
+	}
+
+	err = c.init_reader(file, size, params)
+	return
+}
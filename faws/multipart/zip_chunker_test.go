@@ -0,0 +1,103 @@
+package multipart
+
+import (
+	"archive/zip"
+	"bytes"
+	"slices"
+	"testing"
+)
+
+type test_zip_member struct {
+	name string
+	data []byte
+}
+
+// returns a ZIP archive of uncompressed members
+func test_zip(t *testing.T, members ...test_zip_member) []byte {
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for _, member := range members {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: member.name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(member.data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestZipChunker(t *testing.T) {
+	small := test_zip_member{"a.txt", test_random_data(1, 100)}
+	large := test_zip_member{"b.bin", test_random_data(2, test_large_size)}
+	last := test_zip_member{"c.txt", test_random_data(3, 200)}
+	file := test_zip(t, small, large, last)
+
+	chunker := new_test_chunker(t, file)
+	zip_chunker, ok := chunker.(*zip_chunker)
+	if !ok {
+		t.Fatalf("expected the zip chunker, got %T", chunker)
+	}
+	chunks := read_test_chunks(t, chunker, file)
+
+	names := test_section_names(chunks)
+	if !slices.Equal(names, []string{"a.txt", "b.bin", "c.txt", "central directory"}) {
+		t.Fatal(names)
+	}
+	// the large member is split by the generic chunker, the others are whole
+	if n := len(test_section_chunks(chunks, "b.bin")); n < 2 {
+		t.Fatalf("b.bin was split into %d chunks", n)
+	}
+	if n := len(test_section_chunks(chunks, "a.txt")); n != 1 {
+		t.Fatalf("a.txt was split into %d chunks", n)
+	}
+
+	// changing one member leaves the chunks of the others alone
+	changed_small := test_zip_member{"a.txt", test_random_data(4, 150)}
+	changed_file := test_zip(t, changed_small, large, last)
+	changed_chunks := read_test_chunks(t, new_test_chunker(t, changed_file), changed_file)
+	for _, name := range []string{"b.bin", "c.txt"} {
+		if !slices.EqualFunc(test_section_chunks(chunks, name), test_section_chunks(changed_chunks, name), bytes.Equal) {
+			t.Fatalf("the chunks of %s changed", name)
+		}
+	}
+
+	// the lazy signature only depends on the central directory and the size of the file
+	signature, err := zip_chunker.LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	same_signature, err := new_test_chunker(t, test_zip(t, small, large, last)).(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same_signature != signature {
+		t.Fatal("the lazy signature of the same archive changed")
+	}
+	changed_signature, err := new_test_chunker(t, changed_file).(LazyChunker).LazySignature()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed_signature == signature {
+		t.Fatal("the lazy signature of a different archive is the same")
+	}
+}
+
+func TestZipChunkerMalformed(t *testing.T) {
+	file := test_zip(t, test_zip_member{"a.txt", test_random_data(1, 1000)}, test_zip_member{"b.txt", test_random_data(2, test_large_size)})
+
+	// the end of central directory is missing
+	test_fallback(t, "zip", file[:len(file)-10])
+
+	// the archive would have to begin before the beginning of the file
+	bad_offset := slices.Clone(file)
+	bad_offset[len(bad_offset)-3] = 0x7F
+	test_fallback(t, "zip", bad_offset)
+
+	// the central directory record has a bad signature
+	bad_record := slices.Clone(file)
+	bad_record[bytes.LastIndex(bad_record, []byte("PK\x01\x02"))] = 'X'
+	test_fallback(t, "zip", bad_record)
+}
@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/repo/config"
+)
+
+var (
+	ErrAlternateNotDirectory = fmt.Errorf("faws/repo: alternate is not a directory")
+)
+
+// returns the absolute path of an alternate object directory listed in the config
+func (repo *Repository) alternate_path(alternate string) string {
+	if filepath.IsAbs(alternate) {
+		return alternate
+	}
+	return filepath.Join(repo.directory, alternate)
+}
+
+// returns the options used to read objects from the config's alternates
+func (repo *Repository) alternate_set_options() (options []cas.SetOption) {
+	if len(repo.config.Alternates) == 0 {
+		return
+	}
+	alternates := make([]string, len(repo.config.Alternates))
+	for i, alternate := range repo.config.Alternates {
+		alternates[i] = repo.alternate_path(alternate)
+	}
+	options = append(options, cas.WithAlternates(alternates...))
+	return
+}
+
+// AddAlternate adds the object directory of another repository as a read-only source of objects, and records it in the config.
+// If directory is a repository, its object directory is used.
+func (repo *Repository) AddAlternate(directory string) (err error) {
+	directory, err = filepath.Abs(directory)
+	if err != nil {
+		return
+	}
+	if Exists(directory) {
+		directory = filepath.Join(directory, "objects")
+	}
+
+	var info os.FileInfo
+	info, err = os.Stat(directory)
+	if err != nil {
+		return
+	}
+	if !info.IsDir() {
+		err = fmt.Errorf("%w: %s", ErrAlternateNotDirectory, directory)
+		return
+	}
+
+	if slices.Contains(repo.objects.Alternates(), directory) {
+		return
+	}
+
+	if err = repo.objects.AddAlternate(directory); err != nil {
+		return
+	}
+
+	repo.config.Alternates = append(repo.config.Alternates, directory)
+	err = config.WriteConfig(filepath.Join(repo.directory, "config"), &repo.config)
+	return
+}
+
+// Alternates returns the object directories that are consulted for objects missing from the repository
+func (repo *Repository) Alternates() (directories []string) {
+	directories = repo.objects.Alternates()
+	return
+}
@@ -0,0 +1,131 @@
+package repo
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/faws-vcs/faws/faws/fs"
+	"github.com/faws-vcs/faws/faws/identity"
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/repo/remote"
+)
+
+// Bundle writes a bundle of tags into directory, which can be cloned and pulled from with a bundle:// URI.
+//
+// The bundle holds a pack of every object reachable from the tags, including objects that are only found in alternates,
+// alongside a manifest of the tags signed by signing_identity. If no tags are given, every tag in the repository is bundled.
+// Options are passed along to the [cas.PackWriter], and can be used to enable delta compression with [cas.WithDeltas].
+func (repo *Repository) Bundle(directory string, signing_identity *identity.Pair, publisher_attributes *identity.Attributes, max_archive_size int64, tags []string, options ...cas.PackWriterOption) (err error) {
+	// a bundle is never written over another one
+	manifest_name := filepath.Join(directory, remote.BundleManifestName)
+	pack_name := filepath.Join(directory, remote.BundlePackName)
+	for _, name := range []string{manifest_name, pack_name} {
+		if _, err = os.Stat(name); err == nil {
+			err = fmt.Errorf("%w: %s", ErrBundleExists, directory)
+			return
+		}
+	}
+
+	if len(tags) == 0 {
+		var all_tags []string
+		all_tags, err = repo.tag_names()
+		if err != nil {
+			return
+		}
+		tags = all_tags
+	}
+	slices.Sort(tags)
+	tags = slices.Compact(tags)
+
+	var info remote.BundleInfo
+	info.Date = time.Now().Unix()
+	info.UUID = repo.config.UUID
+	info.Pack = remote.BundlePackName
+	if publisher_attributes != nil {
+		info.PublisherAttributes = *publisher_attributes
+	}
+
+	var vq visitor_queue
+	vq.init()
+	vq.track_alternates = true
+
+	for _, tag := range tags {
+		var commit_hash cas.ContentID
+		commit_hash, err = repo.read_tag(tag)
+		if err != nil {
+			vq.destroy()
+			return
+		}
+		vq.object_queue.Push(commit_hash)
+
+		var bundle_tag remote.BundleTag
+		bundle_tag.Name = tag
+		bundle_tag.Commit = hex.EncodeToString(commit_hash[:])
+		info.Tags = append(info.Tags, bundle_tag)
+	}
+
+	if err = repo.visit_objects(&vq); err != nil {
+		vq.destroy()
+		return
+	}
+
+	if err = os.MkdirAll(directory, fs.DefaultPublicDirPerm); err != nil {
+		vq.destroy()
+		return
+	}
+
+	var writer cas.PackWriter
+	if err = writer.Open(pack_name, max_archive_size, options...); err != nil {
+		vq.destroy()
+		return
+	}
+
+	_, err = repo.pack_visited_objects(&writer, &vq, false)
+	vq.destroy()
+	if err != nil {
+		return
+	}
+
+	if err = writer.Close(); err != nil {
+		return
+	}
+
+	// count the archives the pack was split into
+	for {
+		_, err = os.Stat(fmt.Sprintf("%s.%06d", pack_name, info.Archives))
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+			break
+		} else if err != nil {
+			return
+		}
+		info.Archives++
+	}
+
+	// the manifest is written last, so an incomplete bundle can't be opened
+	var manifest []byte
+	manifest, err = remote.EncodeBundleManifest(signing_identity, &info)
+	if err != nil {
+		return
+	}
+	err = os.WriteFile(manifest_name, manifest, fs.DefaultPublicPerm)
+	return
+}
+
+// returns the names of every tag in the repository
+func (repo *Repository) tag_names() (names []string, err error) {
+	tags, err := repo.Tags()
+	if err != nil {
+		return
+	}
+	names = make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return
+}
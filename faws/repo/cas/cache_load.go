@@ -20,6 +20,7 @@ func (cache *cache) Load(id ContentID) (prefix Prefix, data []byte, err error) {
 	// ensure consistency
 	disk_id := hash_content(prefix, data)
 	if id != disk_id {
+		data = nil
 		err = object_error{ErrObjectCorrupted, id}
 		return
 	}
@@ -0,0 +1,36 @@
+package cas
+
+import (
+	"io"
+	"os"
+)
+
+func (cache *cache) OpenObject(id ContentID) (prefix Prefix, content io.ReadCloser, size int64, err error) {
+	var file *os.File
+	file, err = os.Open(cache.path(id))
+	if err != nil {
+		err = object_error{ErrObjectNotFound, id}
+		return
+	}
+
+	var info os.FileInfo
+	info, err = file.Stat()
+	if err != nil {
+		file.Close()
+		return
+	}
+	if info.Size() < PrefixSize {
+		file.Close()
+		err = object_error{ErrObjectCorrupted, id}
+		return
+	}
+
+	if _, err = io.ReadFull(file, prefix[:]); err != nil {
+		file.Close()
+		return
+	}
+
+	size = info.Size() - PrefixSize
+	content = new_object_reader(id, prefix, file)
+	return
+}
@@ -3,12 +3,18 @@ package cas
 import (
 	"bytes"
 	"os"
+	"path/filepath"
 
 	"github.com/faws-vcs/faws/faws/fs"
 )
 
 // Store attempts to store data, returning the associated ContentID.
 func (cache *cache) Store(prefix Prefix, data []byte) (new bool, id ContentID, err error) {
+	// packs can't hold anything larger, so it could never be packed
+	if len(data) > MaxObjectSize {
+		err = ErrObjectTooLarge
+		return
+	}
 	id = hash_content(prefix, data)
 	var (
 		path string
@@ -42,23 +48,28 @@ func (cache *cache) Store(prefix Prefix, data []byte) (new bool, id ContentID, e
 
 	new = true
 
-	part_path := path + ".part"
-
-	// open file.part
+	// open file.*.part
+	// the name is unique, so that the same object can be stored by several goroutines at once
 	var file *os.File
-	file, err = os.OpenFile(part_path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fs.DefaultPublicPerm)
+	file, err = os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.part")
 	if err != nil {
 		return
 	}
-	_, err = file.Write(prefix[:])
-	if err != nil {
+	part_path := file.Name()
+	if err = file.Chmod(fs.DefaultPublicPerm); err != nil {
+		file.Close()
+		os.Remove(part_path)
 		return
 	}
-	if _, err = file.Write(data[:]); err != nil {
-		return
+	_, err = file.Write(prefix[:])
+	if err == nil {
+		_, err = file.Write(data[:])
+	}
+	if close_err := file.Close(); err == nil {
+		err = close_err
 	}
-	err = file.Close()
 	if err != nil {
+		os.Remove(part_path)
 		return
 	}
 
@@ -41,3 +41,9 @@ func hash_content(prefix Prefix, data []byte) (id ContentID) {
 	copy(id[:], checksum[:ContentIDSize])
 	return
 }
+
+// Hash returns the ContentID that an object would be stored under, without storing it
+func Hash(prefix Prefix, data []byte) (id ContentID) {
+	id = hash_content(prefix, data)
+	return
+}
@@ -15,15 +15,22 @@ var (
 	ErrAbbreviationNotHex    = fmt.Errorf("%s: abbreviation is not hexadecimal", package_id)
 
 	ErrObjectNotFound = fmt.Errorf("%s: object not found", package_id)
+	ErrObjectTooLarge = fmt.Errorf("%s: object is larger than the maximum object size", package_id)
 
 	ErrInvalidPackIndexFile = fmt.Errorf("%s: invalid index file in pack", package_id)
 	ErrPackIndexNotExist    = fmt.Errorf("%s: index file in pack does not exist", package_id)
 
-	ErrPackArchiveNotExist      = fmt.Errorf("%s: the archive does not exist")
+	ErrPackArchiveNotExist      = fmt.Errorf("%s: the archive does not exist", package_id)
 	ErrPackArchiveEntryNotExist = fmt.Errorf("%s: the archive entry does not exist", package_id)
 	ErrPackArchiveBadEntry      = fmt.Errorf("%s: the archive entry is badly formed", package_id)
+	ErrPackArchiveUnknownCodec  = fmt.Errorf("%s: the archive entry is compressed with an unknown codec", package_id)
+	ErrPackDeltaChainTooDeep    = fmt.Errorf("%s: the archive entry is at the end of a delta chain that is too deep", package_id)
+	ErrPackDeltaChainDepth      = fmt.Errorf("%s: the delta chain depth must be between 0 and %d", package_id, MaxDeltaChainDepth)
+	ErrPackDeltaBaseNotExist    = fmt.Errorf("%s: the base of a delta archive entry does not exist", package_id)
+	ErrPackReadOnly             = fmt.Errorf("%s: the pack was opened read-only", package_id)
+	ErrPackExists               = fmt.Errorf("%s: a pack with that name already exists", package_id)
 	ErrPackMissingArchive       = fmt.Errorf("%s: the pack index points to an archive that is missing", package_id)
-	ErrPackFileCannotRemove     = fmt.Errorf("%s: packed files may not be removed this way. Running 'faws gc' will take care of unused files")
+	ErrPackFileCannotRemove     = fmt.Errorf("%s: packed files may not be removed this way. Running 'faws gc' will take care of unused files", package_id)
 )
 
 type object_error struct {
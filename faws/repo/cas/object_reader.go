@@ -0,0 +1,42 @@
+package cas
+
+import (
+	"crypto/sha256"
+	"hash"
+	"io"
+)
+
+// object_reader hashes the content of an object as it is streamed, so that corruption is still detected
+// without holding the entire object in memory. The hash is checked once the content has been read to the end.
+type object_reader struct {
+	id      ContentID
+	content io.ReadCloser
+	hash    hash.Hash
+}
+
+func new_object_reader(id ContentID, prefix Prefix, content io.ReadCloser) (reader *object_reader) {
+	reader = new(object_reader)
+	reader.id = id
+	reader.content = content
+	reader.hash = sha256.New()
+	reader.hash.Write(prefix[:])
+	return
+}
+
+func (reader *object_reader) Read(b []byte) (n int, err error) {
+	n, err = reader.content.Read(b)
+	reader.hash.Write(b[:n])
+	if err == io.EOF {
+		var id ContentID
+		copy(id[:], reader.hash.Sum(nil))
+		if id != reader.id {
+			err = object_error{ErrObjectCorrupted, reader.id}
+		}
+	}
+	return
+}
+
+func (reader *object_reader) Close() (err error) {
+	err = reader.content.Close()
+	return
+}
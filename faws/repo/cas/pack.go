@@ -22,6 +22,13 @@ type Pack struct {
 	// .
 	index    pack_index
 	archives []*pack_archive
+	// the pack belongs to some other Set, and must never be modified
+	read_only bool
+}
+
+// Name returns the name of the pack's index file
+func (pack *Pack) Name() string {
+	return pack.name
 }
 
 func (pack *Pack) index_get(name ContentID) (entry pack_index_entry, err error) {
@@ -46,7 +53,7 @@ func (pack *Pack) get_archive(archive_id int) (archive *pack_archive, err error)
 
 func (pack *Pack) open_archive(archive_id int) (err error) {
 	archive := new(pack_archive)
-	if err = archive.Open(fmt.Sprintf("%s/%s.%06d", pack.parent_directory, pack.name, archive_id)); err != nil {
+	if err = archive.Open(fmt.Sprintf("%s/%s.%06d", pack.parent_directory, pack.name, archive_id), pack.read_only); err != nil {
 		return
 	}
 	if archive_id >= len(pack.archives) {
@@ -2,6 +2,7 @@ package cas
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -32,12 +33,21 @@ const (
 	pack_commit_prefix
 	// the entry uses some other prefix, which will be specified.
 	pack_tbd_prefix
+	// the entry's data is encoded with a codec, which will be specified along with the decoded size.
+	// if the codec is pack_codec_delta, the ContentID of the base object follows the decoded size.
+	pack_compressed
 )
 
 type pack_archive_entry struct {
 	Flag      pack_archive_entry_flag
 	TBDPrefix Prefix
-	Content   []byte
+	// if pack_compressed, the codec used to encode Content
+	Codec pack_codec
+	// if pack_compressed, the size of Content once it has been decoded
+	DecodedSize int64
+	// if Codec is pack_codec_delta, the object that Content is a delta against
+	Base    ContentID
+	Content []byte
 }
 
 func pack_archive_entry_size(entry pack_archive_entry) (size int64) {
@@ -48,7 +58,14 @@ func pack_archive_entry_size(entry pack_archive_entry) (size int64) {
 	}
 
 	if entry.Flag&pack_contains_data != 0 {
-		var content_len [8]byte
+		var content_len [binary.MaxVarintLen64]byte
+		if entry.Flag&pack_compressed != 0 {
+			size += 1
+			size += int64(binary.PutUvarint(content_len[:], uint64(entry.DecodedSize)))
+			if entry.Codec == pack_codec_delta {
+				size += ContentIDSize
+			}
+		}
 		size += int64(binary.PutUvarint(content_len[:], uint64(len(entry.Content))))
 		size += int64(len(entry.Content))
 	}
@@ -67,6 +84,7 @@ type pack_archive struct {
 	header    pack_archive_header
 	file      *os.File
 	file_size int64
+	read_only bool
 }
 
 func (pack_archive *pack_archive) read_header() (err error) {
@@ -82,8 +100,13 @@ func (pack_archive *pack_archive) write_header() (err error) {
 	return
 }
 
-func (pack_archive *pack_archive) Open(name string) (err error) {
-	pack_archive.file, err = os.OpenFile(name, os.O_CREATE|os.O_RDWR, fs.DefaultPublicPerm)
+func (pack_archive *pack_archive) Open(name string, read_only bool) (err error) {
+	pack_archive.read_only = read_only
+	if read_only {
+		pack_archive.file, err = os.Open(name)
+	} else {
+		pack_archive.file, err = os.OpenFile(name, os.O_CREATE|os.O_RDWR, fs.DefaultPublicPerm)
+	}
 	if err != nil {
 		return
 	}
@@ -94,6 +117,10 @@ func (pack_archive *pack_archive) Open(name string) (err error) {
 	}
 
 	if pack_archive.file_size < pack_archive_header_size {
+		if read_only {
+			err = ErrPackArchiveBadEntry
+			return
+		}
 		pack_archive.header.Prefix = archive_prefix
 		err = pack_archive.write_header()
 		if err != nil {
@@ -110,7 +137,62 @@ func (pack_archive *pack_archive) Open(name string) (err error) {
 	return
 }
 
-func (pack_archive *pack_archive) ReadEntry(offset int64) (entry pack_archive_entry, err error) {
+// pack_delta_resolver returns the original content of the base object of a delta entry
+type pack_delta_resolver func(base ContentID) (content []byte, err error)
+
+// ReadEntry reads the entry at offset and decodes its content. If the entry is a delta,
+// resolve is used to obtain the content of its base.
+func (pack_archive *pack_archive) ReadEntry(offset int64, resolve pack_delta_resolver) (entry pack_archive_entry, err error) {
+	entry, err = pack_archive.read_entry(offset)
+	if err != nil {
+		return
+	}
+
+	// the entry is decoded transparently, callers only ever see the original content.
+	// the archive is not locked at this point, as resolving a base may read from this archive again.
+	if entry.Flag&pack_contains_data != 0 && entry.Flag&pack_compressed != 0 {
+		if entry.Codec == pack_codec_delta {
+			entry.Content, err = decode_delta(entry.Base, entry.DecodedSize, entry.Content, resolve)
+		} else {
+			entry.Content, err = decode_content(entry.Codec, entry.DecodedSize, entry.Content)
+		}
+		if err != nil {
+			err = fmt.Errorf("cas: in decoding content from pack entry @%d: %w", offset, err)
+			return
+		}
+		entry.Flag &^= pack_compressed
+		entry.Codec = pack_codec_stored
+		entry.DecodedSize = 0
+		entry.Base = ContentID{}
+	}
+
+	return
+}
+
+// reads the entry at offset without decoding its content
+func (pack_archive *pack_archive) read_entry(offset int64) (entry pack_archive_entry, err error) {
+	var content_offset, content_length int64
+	entry, content_offset, content_length, err = pack_archive.read_entry_header(offset)
+	if err != nil {
+		return
+	}
+
+	// it's technically valid if an object contains no data
+	if entry.Flag&pack_contains_data != 0 {
+		entry.Content = make([]byte, content_length)
+		_, err = pack_archive.file.ReadAt(entry.Content, content_offset)
+		if err != nil {
+			err = fmt.Errorf("cas: in reading content from pack entry @%d: %w", offset, err)
+			return
+		}
+	}
+
+	return
+}
+
+// reads everything in the entry at offset that comes before its content.
+// the content is not read, instead its location in the archive file is returned
+func (pack_archive *pack_archive) read_entry_header(offset int64) (entry pack_archive_entry, content_offset, content_length int64, err error) {
 	pack_archive.guard.RLock()
 	defer pack_archive.guard.RUnlock()
 
@@ -120,7 +202,8 @@ func (pack_archive *pack_archive) ReadEntry(offset int64) (entry pack_archive_en
 		return
 	}
 
-	reader := bufio.NewReader(io.NewSectionReader(pack_archive.file, offset, pack_archive.file_size-offset))
+	section := io.NewSectionReader(pack_archive.file, offset, pack_archive.file_size-offset)
+	reader := bufio.NewReaderSize(section, 64)
 
 	var flag_byte uint8
 	if flag_byte, err = reader.ReadByte(); err != nil {
@@ -131,7 +214,7 @@ func (pack_archive *pack_archive) ReadEntry(offset int64) (entry pack_archive_en
 	entry.Flag = pack_archive_entry_flag(flag_byte)
 	if entry.Flag&pack_exists == 0 {
 		// the entry was deleted
-		err = ErrPackArchiveEntryNotExist
+		err = fmt.Errorf("%w: %d %d", ErrPackArchiveEntryNotExist, offset, entry.Flag)
 		return
 	}
 
@@ -142,22 +225,38 @@ func (pack_archive *pack_archive) ReadEntry(offset int64) (entry pack_archive_en
 		}
 	}
 
-	// it's technically valid if an object contains no data
 	if entry.Flag&pack_contains_data != 0 {
-		var content_length uint64
-		content_length, err = binary.ReadUvarint(reader)
+		if entry.Flag&pack_compressed != 0 {
+			if entry.Codec, entry.DecodedSize, err = read_entry_codec(reader); err != nil {
+				err = fmt.Errorf("cas: in reading codec from pack entry @%d: %w", offset, err)
+				return
+			}
+			if entry.Codec == pack_codec_delta {
+				if _, err = io.ReadFull(reader, entry.Base[:]); err != nil {
+					err = fmt.Errorf("cas: in reading delta base from pack entry @%d: %w", offset, err)
+					return
+				}
+			}
+		}
+
+		var content_length_u64 uint64
+		content_length_u64, err = binary.ReadUvarint(reader)
 		if err != nil {
 			err = fmt.Errorf("cas: in reading length from pack entry @%d: %w", offset, err)
 			return
 		}
-		if content_length > MaxObjectSize {
+		if content_length_u64 > MaxObjectSize {
 			err = ErrPackArchiveBadEntry
 			return
 		}
-		entry.Content = make([]byte, content_length)
-		_, err = io.ReadFull(reader, entry.Content)
-		if err != nil {
-			err = fmt.Errorf("cas: in reading content from pack entry @%d: %w", offset, err)
+		content_length = int64(content_length_u64)
+
+		// whatever was read from the section but is still buffered belongs to the content
+		var section_position int64
+		section_position, _ = section.Seek(0, io.SeekCurrent)
+		content_offset = offset + section_position - int64(reader.Buffered())
+		if content_offset+content_length > pack_archive.file_size {
+			err = ErrPackArchiveBadEntry
 			return
 		}
 	}
@@ -165,11 +264,100 @@ func (pack_archive *pack_archive) ReadEntry(offset int64) (entry pack_archive_en
 	return
 }
 
+// OpenEntry returns a reader over the decoded content of the entry at offset. Stored and Zstandard-compressed
+// content is streamed from the archive file. If the entry is a delta, resolve is used to obtain the content of its base.
+func (pack_archive *pack_archive) OpenEntry(offset int64, resolve pack_delta_resolver) (entry pack_archive_entry, content io.ReadCloser, size int64, err error) {
+	var content_offset, content_length int64
+	entry, content_offset, content_length, err = pack_archive.read_entry_header(offset)
+	if err != nil {
+		return
+	}
+
+	section := io.NewSectionReader(pack_archive.file, content_offset, content_length)
+	if entry.Flag&pack_compressed == 0 {
+		content = io.NopCloser(section)
+		size = content_length
+		return
+	}
+
+	size = entry.DecodedSize
+	switch entry.Codec {
+	case pack_codec_stored:
+		content = io.NopCloser(section)
+	case pack_codec_zstd:
+		content, err = open_zstd_stream(section)
+	case pack_codec_delta:
+		// deltas copy from anywhere in their base, so they can only be reconstructed in memory
+		encoded := make([]byte, content_length)
+		if _, err = section.ReadAt(encoded, 0); err != nil {
+			break
+		}
+		var decoded []byte
+		decoded, err = decode_delta(entry.Base, entry.DecodedSize, encoded, resolve)
+		content = io.NopCloser(bytes.NewReader(decoded))
+	default:
+		err = fmt.Errorf("%w: %d", ErrPackArchiveUnknownCodec, entry.Codec)
+	}
+	if err != nil {
+		err = fmt.Errorf("cas: in opening content from pack entry @%d: %w", offset, err)
+		return
+	}
+
+	entry.Flag &^= pack_compressed
+	entry.Codec = pack_codec_stored
+	entry.DecodedSize = 0
+	entry.Base = ContentID{}
+	return
+}
+
+// returns the prefix of the object stored in the entry
+func pack_archive_entry_prefix(entry pack_archive_entry) (prefix Prefix, err error) {
+	if entry.Flag&pack_tbd_prefix != 0 {
+		prefix = entry.TBDPrefix
+	} else if entry.Flag&pack_part_prefix != 0 {
+		prefix = Part
+	} else if entry.Flag&pack_file_prefix != 0 {
+		prefix = File
+	} else if entry.Flag&pack_tree_prefix != 0 {
+		prefix = Tree
+	} else if entry.Flag&pack_commit_prefix != 0 {
+		prefix = Commit
+	} else {
+		err = ErrObjectCorrupted
+	}
+	return
+}
+
+// reads the codec byte and decoded size that precede the length of a compressed entry
+func read_entry_codec(reader io.ByteReader) (codec pack_codec, decoded_size int64, err error) {
+	var codec_byte byte
+	if codec_byte, err = reader.ReadByte(); err != nil {
+		return
+	}
+	codec = pack_codec(codec_byte)
+
+	var decoded_size_u64 uint64
+	if decoded_size_u64, err = binary.ReadUvarint(reader); err != nil {
+		return
+	}
+	if decoded_size_u64 > MaxObjectSize {
+		err = ErrPackArchiveBadEntry
+		return
+	}
+	decoded_size = int64(decoded_size_u64)
+	return
+}
+
 // appends an object to the pack archive
 func (pack_archive *pack_archive) WriteEntry(entry pack_archive_entry) (offset int64, err error) {
 	pack_archive.guard.Lock()
 	defer pack_archive.guard.Unlock()
 
+	if pack_archive.read_only {
+		err = ErrPackReadOnly
+		return
+	}
+
 	offset = pack_archive.file_size
 
 	// the archive's file offset is always at the end
@@ -192,7 +380,22 @@ func (pack_archive *pack_archive) WriteEntry(entry pack_archive_entry) (offset i
 
 	// the entry contains data: we must encode its length and data
 	if entry.Flag&pack_contains_data != 0 {
-		var content_length [10]byte
+		// the entry is compressed: we must also encode the codec and the decoded length
+		if entry.Flag&pack_compressed != 0 {
+			var codec [1 + binary.MaxVarintLen64 + ContentIDSize]byte
+			codec[0] = byte(entry.Codec)
+			codec_width := 1 + binary.PutUvarint(codec[1:], uint64(entry.DecodedSize))
+			// a delta also names its base
+			if entry.Codec == pack_codec_delta {
+				codec_width += copy(codec[codec_width:], entry.Base[:])
+			}
+			if _, err = writer.Write(codec[:codec_width]); err != nil {
+				return
+			}
+			pack_archive.file_size += int64(codec_width)
+		}
+
+		var content_length [binary.MaxVarintLen64]byte
 		content_length_width := binary.PutUvarint(content_length[:], uint64(len(entry.Content)))
 		_, err = writer.Write(content_length[:content_length_width])
 		if err != nil {
@@ -210,52 +413,24 @@ func (pack_archive *pack_archive) WriteEntry(entry pack_archive_entry) (offset i
 }
 
 func (pack_archive *pack_archive) StatEntry(offset int64) (size int64, err error) {
-	// we don't want to create a sparse file by seeking out too far
-	if offset >= pack_archive.file_size {
-		err = ErrPackArchiveEntryNotExist
-		return
-	}
-	reader := bufio.NewReader(io.NewSectionReader(pack_archive.file, offset, pack_archive.file_size-offset))
-
-	var flag_byte uint8
-	flag_byte, err = reader.ReadByte()
+	var (
+		entry          pack_archive_entry
+		content_length int64
+	)
+	entry, _, content_length, err = pack_archive.read_entry_header(offset)
 	if err != nil {
-		err = fmt.Errorf("cas: in stat entry flag @%d: %w", offset, err)
-		return
-	}
-
-	flag := pack_archive_entry_flag(flag_byte)
-	if flag&pack_exists == 0 {
-		// the entry was deleted
-		err = fmt.Errorf("%w: %d %d", ErrPackArchiveEntryNotExist, offset, flag)
 		return
 	}
 
-	if flag&pack_tbd_prefix != 0 {
-		var tbd_prefix Prefix
-		if _, err = io.ReadFull(reader, tbd_prefix[:]); err != nil {
-			err = fmt.Errorf("cas: in stat entry tbd prefix @%d: %w", offset, err)
-			return
-		}
-	}
-
-	if flag&pack_contains_data != 0 {
+	// a compressed entry already knows the size of its decoded content
+	if entry.Flag&pack_compressed != 0 {
+		size = entry.DecodedSize
+	} else {
 		// it's okay if an object is just a prefix and nothing else.
-		var content_length uint64
-		content_length, err = binary.ReadUvarint(reader)
-		if err != nil {
-			err = fmt.Errorf("cas: in stat entry data length @%d: %w", offset, err)
-			return
-		}
-		if content_length > MaxObjectSize {
-			err = ErrPackArchiveBadEntry
-			return
-		}
-		size = int64(content_length)
+		size = content_length
 	}
 
 	return
-
 }
 
 func (pack_archive *pack_archive) Size() (n int64) {
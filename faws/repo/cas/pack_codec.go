@@ -0,0 +1,118 @@
+package cas
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// pack_codec identifies the method used to encode the content of a compressed archive entry
+type pack_codec uint8
+
+const (
+	// the content is stored as-is
+	pack_codec_stored pack_codec = iota
+	// the content is compressed with Zstandard
+	pack_codec_zstd
+	// the content is a Zstandard-compressed list of delta instructions against another object in the same pack
+	pack_codec_delta
+)
+
+var (
+	pack_codec_once     sync.Once
+	pack_zstd_encoder   *zstd.Encoder
+	pack_zstd_decoder   *zstd.Decoder
+	pack_codec_init_err error
+)
+
+// the encoder and decoder are safe for concurrent use with EncodeAll/DecodeAll,
+// so they are shared by every pack in the process
+func init_pack_codecs() (err error) {
+	pack_codec_once.Do(func() {
+		pack_zstd_encoder, pack_codec_init_err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+		if pack_codec_init_err != nil {
+			return
+		}
+		pack_zstd_decoder, pack_codec_init_err = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxObjectSize*2))
+	})
+	err = pack_codec_init_err
+	return
+}
+
+// returns true if objects with this prefix are worth trying to compress.
+// commits are small, signed and high-entropy, so they are always stored as-is.
+func pack_codec_compressible(prefix Prefix) bool {
+	switch prefix {
+	case Part, Tree, File:
+		return true
+	}
+	return false
+}
+
+// compress_entry attempts to shrink the content of the entry. If the encoded form does not save space,
+// the entry is left untouched.
+func compress_entry(entry *pack_archive_entry) (err error) {
+	if entry.Flag&pack_contains_data == 0 || entry.Flag&pack_compressed != 0 {
+		return
+	}
+
+	if err = init_pack_codecs(); err != nil {
+		return
+	}
+
+	encoded := pack_zstd_encoder.EncodeAll(entry.Content, nil)
+
+	// the codec byte and the decoded size are paid for by compressed entries only
+	var compressed_entry pack_archive_entry
+	compressed_entry.Flag = entry.Flag | pack_compressed
+	compressed_entry.TBDPrefix = entry.TBDPrefix
+	compressed_entry.Codec = pack_codec_zstd
+	compressed_entry.DecodedSize = int64(len(entry.Content))
+	compressed_entry.Content = encoded
+
+	if pack_archive_entry_size(compressed_entry) >= pack_archive_entry_size(*entry) {
+		return
+	}
+
+	*entry = compressed_entry
+	return
+}
+
+// decode_content returns the original content of an entry that was read with the pack_compressed flag
+func decode_content(codec pack_codec, decoded_size int64, encoded []byte) (content []byte, err error) {
+	switch codec {
+	case pack_codec_stored:
+		content = encoded
+	case pack_codec_zstd:
+		if err = init_pack_codecs(); err != nil {
+			return
+		}
+		content, err = pack_zstd_decoder.DecodeAll(encoded, make([]byte, 0, decoded_size))
+		if err != nil {
+			err = fmt.Errorf("%w: %w", ErrPackArchiveBadEntry, err)
+			return
+		}
+	default:
+		err = fmt.Errorf("%w: %d", ErrPackArchiveUnknownCodec, codec)
+		return
+	}
+
+	if int64(len(content)) != decoded_size {
+		err = ErrPackArchiveBadEntry
+		return
+	}
+	return
+}
+
+// open_zstd_stream returns a reader that decompresses encoded as it is read
+func open_zstd_stream(encoded io.Reader) (stream io.ReadCloser, err error) {
+	var decoder *zstd.Decoder
+	decoder, err = zstd.NewReader(encoded, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(MaxObjectSize*2))
+	if err != nil {
+		return
+	}
+	stream = decoder.IOReadCloser()
+	return
+}
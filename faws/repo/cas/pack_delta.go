@@ -0,0 +1,284 @@
+package cas
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+const (
+	// the width of the rolling hash window used to find matching regions between parts
+	pack_delta_window = 32
+	// the number of window hashes kept in a part's sketch
+	pack_delta_sketch_size = 8
+	// a base must share at least this many sketch values with a part to be considered similar
+	pack_delta_min_resemblance = 2
+	// MaxDeltaChainDepth is the deepest delta chain that can be reconstructed, regardless of how the pack was written
+	MaxDeltaChainDepth = 64
+	// DefaultDeltaChainDepth is the default limit on how many deltas may be stacked on top of a part stored in full
+	DefaultDeltaChainDepth = 8
+
+	// multiplier for the rolling hash
+	pack_delta_hash_base uint64 = 0x100000001b3
+)
+
+// delta instructions
+const (
+	// copy a range of bytes from the base: uvarint offset, uvarint length
+	pack_delta_copy byte = iota
+	// insert literal bytes: uvarint length, then the bytes
+	pack_delta_insert
+)
+
+// pack_delta_hash_base ^ pack_delta_window, which removes the outgoing byte from the rolling hash
+var pack_delta_hash_base_pow = func() (pow uint64) {
+	pow = 1
+	for range pack_delta_window {
+		pow *= pack_delta_hash_base
+	}
+	return
+}()
+
+func delta_window_hash(window []byte) (h uint64) {
+	for _, c := range window {
+		h = h*pack_delta_hash_base + uint64(c)
+	}
+	return
+}
+
+func delta_roll_hash(h uint64, out, in byte) uint64 {
+	return h*pack_delta_hash_base + uint64(in) - uint64(out)*pack_delta_hash_base_pow
+}
+
+// scrambles the bits of a window hash so that the smallest hashes are evenly distributed
+func delta_mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// delta_sketch returns the smallest window hashes of data. Parts which differ only in a few places
+// will share most of their sketch, which makes it a cheap way to find a similar base.
+func delta_sketch(data []byte) (sketch []uint64) {
+	if len(data) < pack_delta_window {
+		return
+	}
+
+	sketch = make([]uint64, 0, pack_delta_sketch_size+1)
+	consider := func(h uint64) {
+		h = delta_mix(h)
+		if len(sketch) == pack_delta_sketch_size && h >= sketch[len(sketch)-1] {
+			return
+		}
+		i, found := slices.BinarySearch(sketch, h)
+		if found {
+			return
+		}
+		sketch = slices.Insert(sketch, i, h)
+		if len(sketch) > pack_delta_sketch_size {
+			sketch = sketch[:pack_delta_sketch_size]
+		}
+	}
+
+	h := delta_window_hash(data[:pack_delta_window])
+	consider(h)
+	for i := pack_delta_window; i < len(data); i++ {
+		h = delta_roll_hash(h, data[i-pack_delta_window], data[i])
+		consider(h)
+	}
+	return
+}
+
+func append_delta_copy(delta []byte, offset, length int) []byte {
+	delta = append(delta, pack_delta_copy)
+	delta = binary.AppendUvarint(delta, uint64(offset))
+	delta = binary.AppendUvarint(delta, uint64(length))
+	return delta
+}
+
+func append_delta_insert(delta []byte, literal []byte) []byte {
+	if len(literal) == 0 {
+		return delta
+	}
+	delta = append(delta, pack_delta_insert)
+	delta = binary.AppendUvarint(delta, uint64(len(literal)))
+	delta = append(delta, literal...)
+	return delta
+}
+
+// compute_delta returns instructions that rebuild target using the contents of base
+func compute_delta(base, target []byte) (delta []byte) {
+	if len(base) < pack_delta_window || len(target) < pack_delta_window {
+		delta = append_delta_insert(delta, target)
+		return
+	}
+
+	// index the base at window-aligned offsets
+	base_windows := make(map[uint64]int, len(base)/pack_delta_window)
+	for offset := 0; offset+pack_delta_window <= len(base); offset += pack_delta_window {
+		h := delta_window_hash(base[offset : offset+pack_delta_window])
+		if _, exists := base_windows[h]; !exists {
+			base_windows[h] = offset
+		}
+	}
+
+	// slide across every offset of the target, looking for windows that also occur in the base
+	insert_start := 0
+	i := 0
+	h := delta_window_hash(target[:pack_delta_window])
+	for i+pack_delta_window <= len(target) {
+		base_offset, found := base_windows[h]
+		if found && bytes.Equal(base[base_offset:base_offset+pack_delta_window], target[i:i+pack_delta_window]) {
+			// extend the match backwards into bytes that haven't been emitted yet
+			target_start, base_start := i, base_offset
+			for target_start > insert_start && base_start > 0 && target[target_start-1] == base[base_start-1] {
+				target_start--
+				base_start--
+			}
+			// and forwards as far as it goes
+			target_end, base_end := i+pack_delta_window, base_offset+pack_delta_window
+			for target_end < len(target) && base_end < len(base) && target[target_end] == base[base_end] {
+				target_end++
+				base_end++
+			}
+
+			delta = append_delta_insert(delta, target[insert_start:target_start])
+			delta = append_delta_copy(delta, base_start, target_end-target_start)
+
+			insert_start = target_end
+			i = target_end
+			if i+pack_delta_window <= len(target) {
+				h = delta_window_hash(target[i : i+pack_delta_window])
+			}
+			continue
+		}
+
+		if i+pack_delta_window < len(target) {
+			h = delta_roll_hash(h, target[i], target[i+pack_delta_window])
+		}
+		i++
+	}
+
+	delta = append_delta_insert(delta, target[insert_start:])
+	return
+}
+
+// apply_delta rebuilds the original content from a base and delta instructions
+func apply_delta(base, delta []byte, decoded_size int64) (content []byte, err error) {
+	content = make([]byte, 0, decoded_size)
+	reader := bytes.NewReader(delta)
+
+	for reader.Len() > 0 {
+		var instruction byte
+		if instruction, err = reader.ReadByte(); err != nil {
+			return
+		}
+
+		switch instruction {
+		case pack_delta_copy:
+			var offset, length uint64
+			if offset, err = binary.ReadUvarint(reader); err != nil {
+				return
+			}
+			if length, err = binary.ReadUvarint(reader); err != nil {
+				return
+			}
+			if offset > uint64(len(base)) || length > uint64(len(base))-offset || int64(len(content))+int64(length) > decoded_size {
+				err = ErrPackArchiveBadEntry
+				return
+			}
+			content = append(content, base[offset:offset+length]...)
+		case pack_delta_insert:
+			var length uint64
+			if length, err = binary.ReadUvarint(reader); err != nil {
+				return
+			}
+			if length > uint64(reader.Len()) || int64(len(content))+int64(length) > decoded_size {
+				err = ErrPackArchiveBadEntry
+				return
+			}
+			literal := make([]byte, length)
+			if _, err = reader.Read(literal); err != nil {
+				return
+			}
+			content = append(content, literal...)
+		default:
+			err = ErrPackArchiveBadEntry
+			return
+		}
+	}
+
+	if int64(len(content)) != decoded_size {
+		err = ErrPackArchiveBadEntry
+		return
+	}
+	return
+}
+
+// delta_entry attempts to encode the entry as a delta against base. If the delta does not save space
+// compared to the entry as it is, the entry is left untouched.
+func delta_entry(entry *pack_archive_entry, base ContentID, base_content []byte) (err error) {
+	if entry.Flag&pack_contains_data == 0 || entry.Flag&pack_compressed != 0 && entry.Codec == pack_codec_delta {
+		return
+	}
+
+	if err = init_pack_codecs(); err != nil {
+		return
+	}
+
+	// recover the original content if the entry is already compressed
+	content := entry.Content
+	if entry.Flag&pack_compressed != 0 {
+		if content, err = decode_content(entry.Codec, entry.DecodedSize, entry.Content); err != nil {
+			return
+		}
+	}
+
+	var delta_entry pack_archive_entry
+	delta_entry.Flag = entry.Flag | pack_compressed
+	delta_entry.TBDPrefix = entry.TBDPrefix
+	delta_entry.Codec = pack_codec_delta
+	delta_entry.DecodedSize = int64(len(content))
+	delta_entry.Base = base
+	delta_entry.Content = pack_zstd_encoder.EncodeAll(compute_delta(base_content, content), nil)
+
+	if pack_archive_entry_size(delta_entry) >= pack_archive_entry_size(*entry) {
+		return
+	}
+
+	*entry = delta_entry
+	return
+}
+
+// decode_delta returns the original content of a delta entry, using resolve to obtain the content of its base
+func decode_delta(base ContentID, decoded_size int64, encoded []byte, resolve pack_delta_resolver) (content []byte, err error) {
+	if resolve == nil {
+		err = ErrPackDeltaBaseNotExist
+		return
+	}
+
+	if err = init_pack_codecs(); err != nil {
+		return
+	}
+
+	var instructions []byte
+	instructions, err = pack_zstd_decoder.DecodeAll(encoded, nil)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrPackArchiveBadEntry, err)
+		return
+	}
+
+	var base_content []byte
+	base_content, err = resolve(base)
+	if err != nil {
+		err = fmt.Errorf("%w %s: %w", ErrPackDeltaBaseNotExist, base, err)
+		return
+	}
+
+	content, err = apply_delta(base_content, instructions, decoded_size)
+	return
+}
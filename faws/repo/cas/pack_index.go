@@ -4,13 +4,15 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
-	"fmt"
+	"errors"
 	"io"
+	"io/fs"
 	"os"
+	"slices"
 	"strconv"
 	"sync"
 
-	"github.com/faws-vcs/faws/faws/fs"
+	fawsfs "github.com/faws-vcs/faws/faws/fs"
 )
 
 const (
@@ -32,305 +34,237 @@ type pack_index_entry struct {
 	Name ContentID
 }
 
+// the index is made of two parts:
 //
-
+// the index file, which is a header followed by entries sorted by name. it is mapped into memory,
+// so that lookups are a binary search within one bucket of the fanout table, with no system calls.
+//
+// the journal (<index>.journal), a sidecar file of unsorted entries that were appended to the pack after
+// the index file was written. the journal is held in memory, and it is merged into the index file on Close.
 type pack_index struct {
 	guard     sync.RWMutex
+	name      string
 	header    pack_index_header
-	file      *os.File
 	file_size int64
+	// the entire index file, mapped into memory
+	mapping []byte
+	// entries which are not yet merged into the index file
+	journal      map[ContentID]pack_index_entry
+	journal_file *os.File
+	// the index belongs to some other Set, and must never be modified
+	read_only bool
 }
 
-func (pack_index *pack_index) write_header() (err error) {
-	writer := io.NewOffsetWriter(pack_index.file, 0)
-
-	if _, err = writer.Write(pack_index.header.Prefix[:]); err != nil {
-		return
-	}
-	var fanout_table [8 * 256]byte
+func encode_pack_index_header(header *pack_index_header) (data []byte) {
+	data = make([]byte, pack_index_header_size)
+	copy(data[:PrefixSize], header.Prefix[:])
 	for i := range 256 {
-		binary.LittleEndian.PutUint64(fanout_table[i*8:], pack_index.header.FanoutTable[i])
+		binary.LittleEndian.PutUint64(data[PrefixSize+(i*8):], header.FanoutTable[i])
 	}
+	return
+}
 
-	if _, err = writer.Write(fanout_table[:]); err != nil {
+func decode_pack_index_header(data []byte, header *pack_index_header) (err error) {
+	if len(data) < pack_index_header_size {
+		err = ErrInvalidPackIndexFile
 		return
 	}
+	copy(header.Prefix[:], data[:PrefixSize])
+	if header.Prefix != index_prefix {
+		err = ErrInvalidPackIndexFile
+		return
+	}
+	for i := range 256 {
+		header.FanoutTable[i] = binary.LittleEndian.Uint64(data[PrefixSize+(i*8):])
+	}
 	return
 }
 
-func (pack_index *pack_index) read_header() (err error) {
-	_, err = io.ReadFull(pack_index.file, pack_index.header.Prefix[:])
-	if err != nil {
-		return
+func encode_pack_index_entry(data []byte, entry *pack_index_entry) {
+	binary.LittleEndian.PutUint32(data[0:4], uint32(entry.ArchiveID))
+	binary.LittleEndian.PutUint64(data[4:12], uint64(entry.FileOffset))
+	copy(data[12:pack_index_entry_size], entry.Name[:])
+}
+
+func decode_pack_index_entry(data []byte, entry *pack_index_entry) {
+	entry.ArchiveID = int(binary.LittleEndian.Uint32(data[0:4]))
+	entry.FileOffset = int64(binary.LittleEndian.Uint64(data[4:12]))
+	copy(entry.Name[:], data[12:pack_index_entry_size])
+}
+
+// writes a complete index file, replacing the file at name.
+// the entries must already be sorted by name.
+func write_pack_index_file(name string, entries []pack_index_entry) (err error) {
+	var header pack_index_header
+	header.Prefix = index_prefix
+	for _, entry := range entries {
+		header.FanoutTable[entry.Name[0]]++
+	}
+	// the fanout table is cumulative
+	for i := 1; i < 256; i++ {
+		header.FanoutTable[i] += header.FanoutTable[i-1]
 	}
 
-	if pack_index.header.Prefix != index_prefix {
-		err = ErrInvalidPackIndexFile
-		return
+	data := make([]byte, pack_index_header_size+(len(entries)*pack_index_entry_size))
+	copy(data, encode_pack_index_header(&header))
+	for i := range entries {
+		encode_pack_index_entry(data[pack_index_header_size+(i*pack_index_entry_size):], &entries[i])
 	}
 
-	var fanout_table [8 * 256]byte
-	if _, err = io.ReadFull(pack_index.file, fanout_table[:]); err != nil {
+	temporary_name := name + ".tmp"
+	if err = os.WriteFile(temporary_name, data, fawsfs.DefaultPublicPerm); err != nil {
 		return
 	}
-	for i := range 256 {
-		pack_index.header.FanoutTable[i] = binary.LittleEndian.Uint64(fanout_table[i*8:])
-	}
+	err = os.Rename(temporary_name, name)
 	return
 }
 
+func (pack_index *pack_index) journal_name() string {
+	return pack_index.name + ".journal"
+}
+
 func (pack_index *pack_index) num_entries() int64 {
 	return (pack_index.file_size - pack_index_header_size) / pack_index_entry_size
 }
 
-func (pack_index *pack_index) Open(name string) (err error) {
-	pack_index.file, err = os.OpenFile(name, os.O_CREATE|os.O_RDWR, fs.DefaultPublicPerm)
-	if err != nil {
-		return
+// maps the index file into memory
+func (pack_index *pack_index) map_file() (err error) {
+	var file *os.File
+	if pack_index.read_only {
+		file, err = os.Open(pack_index.name)
+	} else {
+		file, err = os.OpenFile(pack_index.name, os.O_CREATE|os.O_RDWR, fawsfs.DefaultPublicPerm)
 	}
-
-	pack_index.file_size, err = pack_index.file.Seek(0, io.SeekEnd)
 	if err != nil {
 		return
 	}
-	_, err = pack_index.file.Seek(0, io.SeekStart)
+	defer file.Close()
+
+	pack_index.file_size, err = file.Seek(0, io.SeekEnd)
 	if err != nil {
 		return
 	}
+
 	if pack_index.file_size < pack_index_header_size {
-		pack_index.header.Prefix = index_prefix
-		err = pack_index.write_header()
-		if err != nil {
+		if pack_index.read_only {
+			err = ErrInvalidPackIndexFile
 			return
 		}
-		pack_index.file_size = pack_index_header_size
-	} else {
-		err = pack_index.read_header()
-	}
-
-	return
-}
-
-func search(n int64, f func(int64) bool) int64 {
-	// Define f(-1) == false and f(n) == true.
-	// Invariant: f(i-1) == false, f(j) == true.
-	i, j := int64(0), n
-	for i < j {
-		h := int64(uint64(i+j) >> 1) // avoid overflow when computing h
-		// i ≤ h < j
-		if !f(h) {
-			i = h + 1 // preserves f(i-1) == false
-		} else {
-			j = h // preserves f(j) == true
+		// a new index
+		pack_index.header = pack_index_header{Prefix: index_prefix}
+		if _, err = file.WriteAt(encode_pack_index_header(&pack_index.header), 0); err != nil {
+			return
 		}
+		pack_index.file_size = pack_index_header_size
 	}
-	// i == j, f(i-1) == false, and f(j) (= f(i)) == true  =>  answer is i.
-	return i
-}
 
-func (pack_index *pack_index) write_entry(index int64, entry pack_index_entry) (err error) {
-	entry_start := pack_index_header_size + (index * pack_index_entry_size)
-	entry_end := entry_start + pack_index_entry_size
-
-	var entry_bytes [pack_index_entry_size]byte
-	binary.LittleEndian.PutUint32(entry_bytes[0:4], uint32(entry.ArchiveID))
-	binary.LittleEndian.PutUint64(entry_bytes[4:12], uint64(entry.FileOffset))
-	copy(entry_bytes[12:], entry.Name[:])
-
-	if _, err = pack_index.file.WriteAt(entry_bytes[:], entry_start); err != nil {
+	pack_index.mapping, err = map_pack_index_file(file, pack_index.file_size)
+	if err != nil {
 		return
 	}
 
-	if entry_end > pack_index.file_size {
-		pack_index.file_size = entry_end
-	}
-
+	err = decode_pack_index_header(pack_index.mapping, &pack_index.header)
 	return
 }
 
-func (pack_index *pack_index) read_entry(index int64) (entry pack_index_entry, err error) {
-	if index >= pack_index.num_entries() {
-		err = ErrInvalidPackIndexFile
-		return
-	}
-
-	// var entry_bytes [pack_index_entry_size]byte
-	entry_bytes := make([]byte, pack_index_entry_size)
-	if _, err = pack_index.file.ReadAt(entry_bytes, pack_index_header_size+(pack_index_entry_size*index)); err != nil {
-		err = fmt.Errorf("cas: in reading pack index entry %d: %w", index, err)
-		return
+func (pack_index *pack_index) unmap_file() (err error) {
+	if pack_index.mapping != nil {
+		err = unmap_pack_index_file(pack_index.mapping)
+		pack_index.mapping = nil
 	}
-
-	entry.ArchiveID = int(binary.LittleEndian.Uint32(entry_bytes[:4]))
-	entry_bytes = entry_bytes[4:]
-
-	entry.FileOffset = int64(binary.LittleEndian.Uint64(entry_bytes[:8]))
-	entry_bytes = entry_bytes[8:]
-
-	copy(entry.Name[:], entry_bytes)
-
 	return
 }
 
-func (pack_index *pack_index) swap_entry(i, j int64) (err error) {
-	var (
-		i_entry, j_entry pack_index_entry
-	)
-	i_entry, err = pack_index.read_entry(i)
-	if err != nil {
-		return
-	}
-	j_entry, err = pack_index.read_entry(j)
+// replays entries that were appended to the journal
+func (pack_index *pack_index) read_journal() (err error) {
+	pack_index.journal = make(map[ContentID]pack_index_entry)
+
+	var journal_data []byte
+	journal_data, err = os.ReadFile(pack_index.journal_name())
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
 		return
 	}
-	err = pack_index.write_entry(i, j_entry)
-	if err != nil {
-		return
+
+	// an incomplete entry at the end of the journal was never acknowledged, so it is ignored
+	for len(journal_data) >= pack_index_entry_size {
+		var entry pack_index_entry
+		decode_pack_index_entry(journal_data, &entry)
+		pack_index.journal[entry.Name] = entry
+		journal_data = journal_data[pack_index_entry_size:]
 	}
-	err = pack_index.write_entry(j, i_entry)
 	return
 }
 
-func (pack_index *pack_index) add_fanout_value(bucket byte) (err error) {
-	for n := int(bucket); n < 256; n++ {
-		pack_index.header.FanoutTable[n]++
+func (pack_index *pack_index) Open(name string, read_only bool) (err error) {
+	pack_index.name = name
+	pack_index.read_only = read_only
+	if err = pack_index.map_file(); err != nil {
+		return
 	}
-	err = pack_index.write_header()
+	err = pack_index.read_journal()
 	return
 }
 
-func (pack_index *pack_index) remove_fanout_value(bucket byte) (err error) {
-	for n := int(bucket); n < 256; n++ {
-		if pack_index.header.FanoutTable[n] == 0 {
-			err = ErrInvalidPackIndexFile
-			return
+func search(n int64, f func(int64) bool) int64 {
+	// Define f(-1) == false and f(n) == true.
+	// Invariant: f(i-1) == false, f(j) == true.
+	i, j := int64(0), n
+	for i < j {
+		h := int64(uint64(i+j) >> 1) // avoid overflow when computing h
+		// i ≤ h < j
+		if !f(h) {
+			i = h + 1 // preserves f(i-1) == false
+		} else {
+			j = h // preserves f(j) == true
 		}
-		pack_index.header.FanoutTable[n]--
 	}
-	err = pack_index.write_header()
-	return
+	// i == j, f(i-1) == false, and f(j) (= f(i)) == true  =>  answer is i.
+	return i
 }
 
-func (pack_index *pack_index) Put(entry pack_index_entry) (err error) {
-	index := search(pack_index.num_entries(), func(i int64) bool {
-		entry_i, err := pack_index.read_entry(i)
-		if err != nil {
-			panic(err)
-		}
-		return !entry_i.Name.Less(entry.Name)
-	})
+func (pack_index *pack_index) read_entry(index int64) (entry pack_index_entry, err error) {
 	if index >= pack_index.num_entries() {
-		// since this is a new entry, adjust the fanout table
-		if err = pack_index.add_fanout_value(entry.Name[0]); err != nil {
-			return
-		}
-		// insert at the tail-end
-		err = pack_index.write_entry(index, entry)
-		return
-	}
-	// see if there's a current entry that we can overwrite
-	var current_entry pack_index_entry
-	current_entry, err = pack_index.read_entry(index)
-	if err != nil {
-		return
-	}
-	if current_entry.Name == entry.Name {
-		// we're replacing this entry (this should probably not ever happen)
-		err = pack_index.write_entry(index, entry)
-		return
-	}
-
-	tail := pack_index.num_entries()
-
-	// write the entry at the tail end
-	err = pack_index.write_entry(tail, entry)
-	if err != nil {
-		return
-	}
-	// but, swap all preceding elements so that it ends up at the index with all higher elements shifted up
-	for i := tail; i > index; i-- {
-		err = pack_index.swap_entry(i, i-1)
-		if err != nil {
-			return
-		}
-	}
-	// since this is a new entry, adjust the fanout table
-	if err = pack_index.add_fanout_value(entry.Name[0]); err != nil {
+		err = ErrInvalidPackIndexFile
 		return
 	}
 
-	// TODO: remove this (defensive programming)
-	chk_index, chk_err := pack_index.read_entry(index)
-	if chk_err != nil {
-		panic(err)
-	}
-	if chk_index.Name != entry.Name {
-		panic(chk_index.Name)
-	}
+	entry_start := pack_index_header_size + (pack_index_entry_size * index)
+	decode_pack_index_entry(pack_index.mapping[entry_start:], &entry)
 	return
 }
 
-func (pack_index *pack_index) Delete(name ContentID) (err error) {
+// Put appends an entry to the journal
+func (pack_index *pack_index) Put(entry pack_index_entry) (err error) {
 	pack_index.guard.Lock()
 	defer pack_index.guard.Unlock()
-	num_fanout_entries := pack_index.header.FanoutTable[int(name[0])]
-	if num_fanout_entries == 0 {
-		err = object_error{ErrObjectNotFound, name}
+
+	if pack_index.read_only {
+		err = ErrPackReadOnly
 		return
 	}
 
-	num_entries := pack_index.num_entries()
-
-	// we need the actual index, so let's search through the entire file here
-	index := search(num_entries, func(i int64) bool {
-		entry, err := pack_index.read_entry(i)
+	if pack_index.journal_file == nil {
+		pack_index.journal_file, err = os.OpenFile(pack_index.journal_name(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, fawsfs.DefaultPublicPerm)
 		if err != nil {
-			panic(err)
+			return
 		}
-		return !entry.Name.Less(name)
-	})
-
-	if index >= num_entries {
-		err = object_error{ErrObjectNotFound, name}
-		return
 	}
 
-	var index_entry pack_index_entry
-	index_entry, err = pack_index.read_entry(index)
-	if err != nil {
-		return
-	}
-
-	if index_entry.Name != name {
-		err = object_error{ErrObjectNotFound, name}
+	var entry_bytes [pack_index_entry_size]byte
+	encode_pack_index_entry(entry_bytes[:], &entry)
+	if _, err = pack_index.journal_file.Write(entry_bytes[:]); err != nil {
 		return
 	}
 
-	// shuffle entries so that the deleted entry is swapped to the end of the file
-	for i := index + 1; i < num_entries; i++ {
-		if err = pack_index.swap_entry(i-1, i); err != nil {
-			return
-		}
-	}
-
-	// then, truncate, discarding the entry.
-	err = pack_index.file.Truncate(pack_index_header_size + ((num_entries - 1) * pack_index_entry_size))
+	pack_index.journal[entry.Name] = entry
 	return
 }
 
-func (pack_index *pack_index) Get(name ContentID) (entry pack_index_entry, err error) {
-	pack_index.guard.RLock()
-	defer pack_index.guard.RUnlock()
-	num_fanout_entries := pack_index.header.FanoutTable[int(name[0])]
-	var previous_fanout_entry_count uint64
-	if name[0] != 0x00 {
-		previous_fanout_entry_count = pack_index.header.FanoutTable[name[0]-1]
-	}
-	if num_fanout_entries == previous_fanout_entry_count {
-		err = object_error{ErrObjectNotFound, name}
-		return
-	}
+// searches the sorted entries of the index file
+func (pack_index *pack_index) get_sorted(name ContentID) (entry pack_index_entry, found bool) {
 	// narrow down binary search using fanout table
 	upper := int64(pack_index.header.FanoutTable[name[0]])
 	var lower int64
@@ -338,26 +272,41 @@ func (pack_index *pack_index) Get(name ContentID) (entry pack_index_entry, err e
 		lower = int64(pack_index.header.FanoutTable[name[0]-1])
 	}
 	bucket_entries := upper - lower
+	if bucket_entries <= 0 || upper > pack_index.num_entries() {
+		return
+	}
+
 	// perform the binary search on a bucket-subset of the index
+	entries := pack_index.mapping[pack_index_header_size+(lower*pack_index_entry_size) : pack_index_header_size+(upper*pack_index_entry_size)]
 	index := search(bucket_entries, func(i int64) bool {
-		entry, err := pack_index.read_entry(lower + i)
-		if err != nil {
-			panic(err)
-		}
-		return !entry.Name.Less(name)
+		entry_name := entries[(i*pack_index_entry_size)+12 : (i+1)*pack_index_entry_size]
+		return bytes.Compare(entry_name, name[:]) >= 0
 	})
 	if index >= bucket_entries {
-		err = object_error{ErrObjectNotFound, name}
 		return
 	}
-	entry, err = pack_index.read_entry(lower + index)
-	if err != nil {
+	decode_pack_index_entry(entries[index*pack_index_entry_size:], &entry)
+	found = entry.Name == name
+	return
+}
+
+func (pack_index *pack_index) Get(name ContentID) (entry pack_index_entry, err error) {
+	pack_index.guard.RLock()
+	defer pack_index.guard.RUnlock()
+
+	// entries in the journal are newer, so they take precedence, as they do when the journal is merged
+	var found bool
+	entry, found = pack_index.journal[name]
+	if found {
 		return
 	}
-	if entry.Name != name {
-		err = object_error{ErrObjectNotFound, name}
+
+	entry, found = pack_index.get_sorted(name)
+	if found {
 		return
 	}
+
+	err = object_error{ErrObjectNotFound, name}
 	return
 }
 
@@ -390,7 +339,8 @@ func compute_abbreviation_range(text []byte) (min, max ContentID, err error) {
 	return
 }
 
-func (pack_index *pack_index) Deabbreviate(abbreviation string) (name ContentID, err error) {
+// searches the sorted entries of the index file for an abbreviation
+func (pack_index *pack_index) deabbreviate_sorted(abbreviation string) (name ContentID, err error) {
 	if len(abbreviation) < 1 {
 		err = ErrAbbreviationTooShort
 		return
@@ -431,9 +381,6 @@ func (pack_index *pack_index) Deabbreviate(abbreviation string) (name ContentID,
 		bucket_last = byte(bucket)
 	}
 
-	pack_index.guard.RLock()
-	defer pack_index.guard.RUnlock()
-
 	// using our buckets, let's decide a range of index entries to search.
 	var lower, upper int64
 	if bucket_first != 0x00 {
@@ -507,6 +454,45 @@ func (pack_index *pack_index) Deabbreviate(abbreviation string) (name ContentID,
 	return
 }
 
+func (pack_index *pack_index) Deabbreviate(abbreviation string) (name ContentID, err error) {
+	pack_index.guard.RLock()
+	defer pack_index.guard.RUnlock()
+
+	name, err = pack_index.deabbreviate_sorted(abbreviation)
+	if err != nil && !errors.Is(err, ErrObjectNotFound) {
+		return
+	}
+	hit := err == nil
+
+	if len(pack_index.journal) == 0 {
+		return
+	}
+
+	var min_value, max_value ContentID
+	min_value, max_value, err = compute_abbreviation_range([]byte(abbreviation))
+	if err != nil {
+		return
+	}
+
+	for journal_name := range pack_index.journal {
+		if bytes.Compare(journal_name[:], min_value[:]) >= 0 && bytes.Compare(journal_name[:], max_value[:]) <= 0 {
+			if hit && journal_name != name {
+				err = ErrAbbreviationAmbiguous
+				return
+			}
+			hit = true
+			name = journal_name
+		}
+	}
+
+	if !hit {
+		err = ErrObjectNotFound
+		return
+	}
+	err = nil
+	return
+}
+
 func (pack_index *pack_index) List(fn ListFunc) (err error) {
 	pack_index.guard.RLock()
 	defer pack_index.guard.RUnlock()
@@ -516,10 +502,18 @@ func (pack_index *pack_index) List(fn ListFunc) (err error) {
 		if err != nil {
 			return
 		}
+		if _, journaled := pack_index.journal[entry.Name]; journaled {
+			continue
+		}
 		if err = fn(true, entry.Name); err != nil {
 			return
 		}
 	}
+	for name := range pack_index.journal {
+		if err = fn(true, name); err != nil {
+			return
+		}
+	}
 	return
 }
 
@@ -531,9 +525,112 @@ func (pack_index *pack_index) Size() (n int64) {
 	return
 }
 
+// Rewrite replaces the contents of the index with entries, which need not be sorted.
+func (pack_index *pack_index) Rewrite(entries []pack_index_entry) (err error) {
+	pack_index.guard.Lock()
+	defer pack_index.guard.Unlock()
+
+	if pack_index.read_only {
+		err = ErrPackReadOnly
+		return
+	}
+
+	slices.SortFunc(entries, func(a, b pack_index_entry) int {
+		return bytes.Compare(a.Name[:], b.Name[:])
+	})
+
+	if err = pack_index.unmap_file(); err != nil {
+		return
+	}
+	if err = write_pack_index_file(pack_index.name, entries); err != nil {
+		return
+	}
+	err = pack_index.map_file()
+	return
+}
+
+// merges the journal into the index file
+func (pack_index *pack_index) merge_journal() (err error) {
+	journal_entries := make([]pack_index_entry, 0, len(pack_index.journal))
+	for _, entry := range pack_index.journal {
+		journal_entries = append(journal_entries, entry)
+	}
+	slices.SortFunc(journal_entries, func(a, b pack_index_entry) int {
+		return bytes.Compare(a.Name[:], b.Name[:])
+	})
+
+	num_entries := pack_index.num_entries()
+	merged_entries := make([]pack_index_entry, 0, num_entries+int64(len(journal_entries)))
+
+	// merge the two sorted lists. entries in the journal are newer, so they take precedence
+	var i int64
+	for _, journal_entry := range journal_entries {
+		for ; i < num_entries; i++ {
+			var entry pack_index_entry
+			entry, err = pack_index.read_entry(i)
+			if err != nil {
+				return
+			}
+			if !entry.Name.Less(journal_entry.Name) {
+				if entry.Name == journal_entry.Name {
+					i++
+				}
+				break
+			}
+			merged_entries = append(merged_entries, entry)
+		}
+		merged_entries = append(merged_entries, journal_entry)
+	}
+	for ; i < num_entries; i++ {
+		var entry pack_index_entry
+		entry, err = pack_index.read_entry(i)
+		if err != nil {
+			return
+		}
+		merged_entries = append(merged_entries, entry)
+	}
+
+	if err = pack_index.unmap_file(); err != nil {
+		return
+	}
+	if err = write_pack_index_file(pack_index.name, merged_entries); err != nil {
+		return
+	}
+
+	// the journal is no longer needed
+	if pack_index.journal_file != nil {
+		if err = pack_index.journal_file.Close(); err != nil {
+			return
+		}
+		pack_index.journal_file = nil
+	}
+	err = os.Remove(pack_index.journal_name())
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	return
+}
+
 func (pack_index *pack_index) Close() (err error) {
+	pack_index.guard.Lock()
+	defer pack_index.guard.Unlock()
+
+	// a read-only index leaves its journal for its owner to merge
+	if len(pack_index.journal) != 0 && !pack_index.read_only {
+		if err = pack_index.merge_journal(); err != nil {
+			return
+		}
+	}
+	if pack_index.journal_file != nil {
+		if err = pack_index.journal_file.Close(); err != nil {
+			return
+		}
+		pack_index.journal_file = nil
+	}
+
+	err = pack_index.unmap_file()
 	pack_index.file_size = 0
 	pack_index.header = pack_index_header{}
-	err = pack_index.file.Close()
+	pack_index.journal = nil
 	return
 }
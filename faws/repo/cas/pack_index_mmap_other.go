@@ -0,0 +1,19 @@
+//go:build !unix
+
+package cas
+
+import (
+	"io"
+	"os"
+)
+
+// on platforms without mmap, the index file is read into memory in its entirety
+func map_pack_index_file(file *os.File, size int64) (mapping []byte, err error) {
+	mapping = make([]byte, size)
+	_, err = io.ReadFull(io.NewSectionReader(file, 0, size), mapping)
+	return
+}
+
+func unmap_pack_index_file(mapping []byte) (err error) {
+	return
+}
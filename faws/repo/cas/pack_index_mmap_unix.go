@@ -0,0 +1,19 @@
+//go:build unix
+
+package cas
+
+import (
+	"os"
+	"syscall"
+)
+
+// maps the index file into memory as read-only
+func map_pack_index_file(file *os.File, size int64) (mapping []byte, err error) {
+	mapping, err = syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	return
+}
+
+func unmap_pack_index_file(mapping []byte) (err error) {
+	err = syscall.Munmap(mapping)
+	return
+}
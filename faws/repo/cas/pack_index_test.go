@@ -0,0 +1,81 @@
+package cas
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPackIndexJournal(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "pack")
+
+	var writer PackWriter
+	if err := writer.Open(name, -1); err != nil {
+		t.Fatal(err)
+	}
+	var ids []ContentID
+	for i := range 64 {
+		_, id, err := writer.Store(Part, fmt.Appendf(nil, "written part %d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// append to the pack after its index was written
+	var pack Pack
+	if err := pack.Open(name, -1); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 64 {
+		_, id, err := pack.Store(Part, fmt.Appendf(nil, "appended part %d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if _, err := os.Stat(name + ".journal"); err != nil {
+		t.Fatal("appended entries were not journaled", err)
+	}
+	for _, id := range ids {
+		if _, _, err := pack.Load(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	abbreviated, err := pack.Deabbreviate(ids[100].String()[:8])
+	if err != nil || abbreviated != ids[100] {
+		t.Fatal("could not deabbreviate journaled entry", err)
+	}
+	if err = pack.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the journal is merged into the sorted index on close
+	if _, err = os.Stat(name + ".journal"); err == nil {
+		t.Fatal("journal was not merged")
+	}
+	if err = pack.Open(name, -1); err != nil {
+		t.Fatal(err)
+	}
+	defer pack.Close()
+	if pack.index.num_entries() != int64(len(ids)) {
+		t.Fatalf("index has %d entries, expected %d", pack.index.num_entries(), len(ids))
+	}
+	for i := int64(1); i < pack.index.num_entries(); i++ {
+		previous, _ := pack.index.read_entry(i - 1)
+		current, _ := pack.index.read_entry(i)
+		if bytes.Compare(previous.Name[:], current.Name[:]) >= 0 {
+			t.Fatal("index is not sorted")
+		}
+	}
+	for _, id := range ids {
+		if _, _, err := pack.Load(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
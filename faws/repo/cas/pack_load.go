@@ -1,6 +1,17 @@
 package cas
 
 func (pack *Pack) Load(name ContentID) (prefix Prefix, content []byte, err error) {
+	prefix, content, err = pack.load(name, 0)
+	return
+}
+
+// load an object, which is depth deltas away from the object that was requested
+func (pack *Pack) load(name ContentID, depth int) (prefix Prefix, content []byte, err error) {
+	if depth > MaxDeltaChainDepth {
+		err = ErrPackDeltaChainTooDeep
+		return
+	}
+
 	pack.guard.RLock()
 	// lookup the hash from the index. if there's no hit, the object does not exist.
 	var (
@@ -21,31 +32,26 @@ func (pack *Pack) Load(name ContentID) (prefix Prefix, content []byte, err error
 		return
 	}
 
-	archive_entry, err = archive.ReadEntry(index_entry.FileOffset)
+	pack.guard.RUnlock()
+
+	// deltas are resolved against other objects in this pack
+	archive_entry, err = archive.ReadEntry(index_entry.FileOffset, func(base ContentID) (base_content []byte, err error) {
+		_, base_content, err = pack.load(base, depth+1)
+		return
+	})
 	if err != nil {
-		pack.guard.RUnlock()
 		return
 	}
-	pack.guard.RUnlock()
 
-	if archive_entry.Flag&pack_tbd_prefix != 0 {
-		prefix = archive_entry.TBDPrefix
-	} else if archive_entry.Flag&pack_part_prefix != 0 {
-		prefix = Part
-	} else if archive_entry.Flag&pack_file_prefix != 0 {
-		prefix = File
-	} else if archive_entry.Flag&pack_tree_prefix != 0 {
-		prefix = Tree
-	} else if archive_entry.Flag&pack_commit_prefix != 0 {
-		prefix = Commit
-	} else {
-		err = ErrObjectCorrupted
+	prefix, err = pack_archive_entry_prefix(archive_entry)
+	if err != nil {
 		return
 	}
 
 	content = archive_entry.Content
 	// check hash
 	if hash_content(prefix, content) != name {
+		content = nil
 		err = ErrObjectCorrupted
 		return
 	}
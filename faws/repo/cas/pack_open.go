@@ -9,6 +9,18 @@ import (
 )
 
 func (pack *Pack) Open(name string, max_archive_size int64) (err error) {
+	err = pack.open(name, max_archive_size, false)
+	return
+}
+
+// OpenReadOnly opens an existing pack without ever modifying it. Attempting to store objects in the pack returns [ErrPackReadOnly].
+func (pack *Pack) OpenReadOnly(name string) (err error) {
+	err = pack.open(name, -1, true)
+	return
+}
+
+func (pack *Pack) open(name string, max_archive_size int64, read_only bool) (err error) {
+	pack.read_only = read_only
 	pack.max_archive_size = max_archive_size
 	if pack.max_archive_size < 0 {
 		pack.max_archive_size = math.MaxInt64
@@ -16,7 +28,7 @@ func (pack *Pack) Open(name string, max_archive_size int64) (err error) {
 	pack.name = filepath.Base(name)
 	pack.parent_directory = filepath.Dir(name)
 	// open index
-	if err = pack.index.Open(name); err != nil {
+	if err = pack.index.Open(name, pack.read_only); err != nil {
 		return
 	}
 
@@ -0,0 +1,44 @@
+package cas
+
+import "io"
+
+func (pack *Pack) OpenObject(name ContentID) (prefix Prefix, content io.ReadCloser, size int64, err error) {
+	pack.guard.RLock()
+	// lookup the hash from the index. if there's no hit, the object does not exist.
+	var index_entry pack_index_entry
+	index_entry, err = pack.index_get(name)
+	if err != nil {
+		pack.guard.RUnlock()
+		return
+	}
+
+	var archive *pack_archive
+	archive, err = pack.get_archive(int(index_entry.ArchiveID))
+	if err != nil {
+		pack.guard.RUnlock()
+		return
+	}
+
+	pack.guard.RUnlock()
+
+	var (
+		archive_entry   pack_archive_entry
+		archive_content io.ReadCloser
+	)
+	archive_entry, archive_content, size, err = archive.OpenEntry(index_entry.FileOffset, func(base ContentID) (base_content []byte, err error) {
+		_, base_content, err = pack.load(base, 1)
+		return
+	})
+	if err != nil {
+		return
+	}
+
+	prefix, err = pack_archive_entry_prefix(archive_entry)
+	if err != nil {
+		archive_content.Close()
+		return
+	}
+
+	content = new_object_reader(name, prefix, archive_content)
+	return
+}
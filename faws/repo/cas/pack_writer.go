@@ -1,28 +1,106 @@
 package cas
 
-import (
-	"sort"
-)
-
 type pack_writer_index_entry struct {
 	archive_id  int
 	file_offset int64
+	// the number of deltas that must be applied to reconstruct this object
+	delta_depth int
+}
+
+type pack_writer_options struct {
+	deltas          bool
+	max_delta_depth int
+}
+
+// A PackWriterOption can be used to change how a [PackWriter] encodes objects
+type PackWriterOption func(*pack_writer_options)
+
+// WithDeltas is a [PackWriterOption] that stores parts as deltas against similar parts that were written earlier.
+// A part is never stored at the end of a delta chain longer than max_chain_depth, which is clamped to [MaxDeltaChainDepth]
+// so that the pack can always be read.
+func WithDeltas(max_chain_depth int) PackWriterOption {
+	return func(o *pack_writer_options) {
+		max_chain_depth = min(max_chain_depth, MaxDeltaChainDepth)
+		o.deltas = max_chain_depth > 0
+		o.max_delta_depth = max_chain_depth
+	}
+}
+
+// CheckDeltaChainDepth returns [ErrPackDeltaChainDepth] if max_chain_depth is outside the range accepted by [WithDeltas]
+func CheckDeltaChainDepth(max_chain_depth int) (err error) {
+	if max_chain_depth < 0 || max_chain_depth > MaxDeltaChainDepth {
+		err = ErrPackDeltaChainDepth
+	}
+	return
 }
 
 // PackWriter is a tool for efficiently generating pack databases
 type PackWriter struct {
-	index map[ContentID]pack_writer_index_entry
-	// stored map[ContentID]
-	pack Pack
+	options pack_writer_options
+	index   map[ContentID]pack_writer_index_entry
+	// if deltas are enabled, maps each sketch value to the last part that had it
+	sketches map[uint64]ContentID
+	pack     Pack
 }
 
-func (pack_writer *PackWriter) Open(name string, max_archive_size int64) (err error) {
+func (pack_writer *PackWriter) Open(name string, max_archive_size int64, options ...PackWriterOption) (err error) {
+	pack_writer.options = pack_writer_options{}
+	for _, option := range options {
+		option(&pack_writer.options)
+	}
 	pack_writer.index = make(map[ContentID]pack_writer_index_entry)
+	pack_writer.sketches = make(map[uint64]ContentID)
 	err = pack_writer.pack.Open(name, max_archive_size)
 	return
 }
 
+// returns the part that shares the most of sketch, if one is similar enough to be a delta base
+func (pack_writer *PackWriter) find_delta_base(sketch []uint64) (base ContentID, found bool) {
+	resemblance := make(map[ContentID]int, len(sketch))
+	best := 0
+	for _, value := range sketch {
+		candidate, ok := pack_writer.sketches[value]
+		if !ok {
+			continue
+		}
+		resemblance[candidate]++
+		if resemblance[candidate] > best {
+			best = resemblance[candidate]
+			base = candidate
+		}
+	}
+	found = best >= pack_delta_min_resemblance
+	return
+}
+
+// reads back the original content of an object that was already written
+func (pack_writer *PackWriter) load(id ContentID) (content []byte, err error) {
+	index_entry, ok := pack_writer.index[id]
+	if !ok {
+		err = ErrObjectNotFound
+		return
+	}
+
+	var archive *pack_archive
+	archive, err = pack_writer.pack.get_archive(index_entry.archive_id)
+	if err != nil {
+		return
+	}
+
+	var archive_entry pack_archive_entry
+	archive_entry, err = archive.ReadEntry(index_entry.file_offset, pack_writer.load)
+	if err != nil {
+		return
+	}
+	content = archive_entry.Content
+	return
+}
+
 func (pack_writer *PackWriter) Store(prefix Prefix, data []byte) (new bool, id ContentID, err error) {
+	if len(data) > MaxObjectSize {
+		err = ErrObjectTooLarge
+		return
+	}
 	id = hash_content(prefix, data)
 
 	pack_writer.pack.guard.Lock()
@@ -54,6 +132,38 @@ func (pack_writer *PackWriter) Store(prefix Prefix, data []byte) (new bool, id C
 	if len(data) > 0 {
 		archive_entry.Flag |= pack_contains_data
 		archive_entry.Content = data
+
+		// packs are long-term storage, so compress the entry whenever it saves space.
+		// the ContentID is always the hash of the original data.
+		if pack_codec_compressible(prefix) {
+			if err = compress_entry(&archive_entry); err != nil {
+				return
+			}
+		}
+	}
+
+	var (
+		delta_depth int
+		sketch      []uint64
+	)
+	if pack_writer.options.deltas && prefix == Part && len(data) > 0 {
+		// parts that differ only slightly from one that is already in the pack are stored as deltas
+		sketch = delta_sketch(data)
+		if base, found := pack_writer.find_delta_base(sketch); found {
+			base_depth := pack_writer.index[base].delta_depth
+			if base_depth < pack_writer.options.max_delta_depth {
+				var base_content []byte
+				if base_content, err = pack_writer.load(base); err != nil {
+					return
+				}
+				if err = delta_entry(&archive_entry, base, base_content); err != nil {
+					return
+				}
+				if archive_entry.Codec == pack_codec_delta {
+					delta_depth = base_depth + 1
+				}
+			}
+		}
 	}
 
 	// append to the last archive
@@ -91,59 +201,31 @@ func (pack_writer *PackWriter) Store(prefix Prefix, data []byte) (new bool, id C
 	var index_entry pack_writer_index_entry
 	index_entry.archive_id = archive_id
 	index_entry.file_offset = offset
+	index_entry.delta_depth = delta_depth
 
 	pack_writer.index[id] = index_entry
 
-	return
-}
-
-type index_sorter struct {
-	index *pack_index
-}
-
-func (index_sorter *index_sorter) Len() (n int) {
-	n = int(index_sorter.index.num_entries())
-	return
-}
-
-func (index_sorter *index_sorter) Less(i, j int) (less bool) {
-	i_entry, err := index_sorter.index.read_entry(int64(i))
-	if err != nil {
-		panic(err)
-	}
-	j_entry, err := index_sorter.index.read_entry(int64(j))
-	if err != nil {
-		panic(err)
+	for _, value := range sketch {
+		pack_writer.sketches[value] = id
 	}
-	less = i_entry.Name.Less(j_entry.Name)
-	return
-}
 
-func (index_sorter *index_sorter) Swap(i, j int) {
-	if err := index_sorter.index.swap_entry(int64(i), int64(j)); err != nil {
-		panic(err)
-	}
+	return
 }
 
 func (pack_writer *PackWriter) Close() (err error) {
-	var i int64
+	entries := make([]pack_index_entry, 0, len(pack_writer.index))
 	for id, entry := range pack_writer.index {
 		var real_entry pack_index_entry
 		real_entry.Name = id
 		real_entry.ArchiveID = entry.archive_id
 		real_entry.FileOffset = entry.file_offset
-		if err = pack_writer.pack.index.write_entry(i, real_entry); err != nil {
-			return
-		}
-		if err = pack_writer.pack.index.add_fanout_value(id[0]); err != nil {
-			return
-		}
-		i++
+		entries = append(entries, real_entry)
 	}
 
-	var index_sorter index_sorter
-	index_sorter.index = &pack_writer.pack.index
-	sort.Sort(&index_sorter)
+	// the index is written once, in sorted order
+	if err = pack_writer.pack.index.Rewrite(entries); err != nil {
+		return
+	}
 
 	err = pack_writer.pack.Close()
 	return
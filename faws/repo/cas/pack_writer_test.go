@@ -0,0 +1,172 @@
+package cas
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// checks that streaming an object from the pack yields the same content as loading it
+func check_pack_open_object(t *testing.T, pack *Pack, id ContentID, prefix Prefix, content []byte) {
+	t.Helper()
+	object_prefix, object_content, size, err := pack.OpenObject(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer object_content.Close()
+	streamed, err := io.ReadAll(object_content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object_prefix != prefix || size != int64(len(content)) || !bytes.Equal(streamed, content) {
+		t.Fatalf("object %s was not streamed correctly", id)
+	}
+}
+
+func TestPackWriterCompression(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "pack")
+
+	compressible := bytes.Repeat([]byte("faws compresses repetitive game assets "), 4096)
+	incompressible := make([]byte, 65536)
+	rand.Read(incompressible)
+
+	var writer PackWriter
+	if err := writer.Open(name, -1); err != nil {
+		t.Fatal(err)
+	}
+	_, compressible_id, err := writer.Store(Part, compressible)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, incompressible_id, err := writer.Store(Part, incompressible)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, commit_id, err := writer.Store(Commit, compressible)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var pack Pack
+	if err = pack.Open(name, -1); err != nil {
+		t.Fatal(err)
+	}
+	defer pack.Close()
+
+	for _, object := range []struct {
+		id      ContentID
+		prefix  Prefix
+		content []byte
+	}{
+		{compressible_id, Part, compressible},
+		{incompressible_id, Part, incompressible},
+		{commit_id, Commit, compressible},
+	} {
+		prefix, content, err := pack.Load(object.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prefix != object.prefix || !bytes.Equal(content, object.content) {
+			t.Fatalf("object %s was not decoded correctly", object.id)
+		}
+		size, err := pack.Stat(object.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != int64(len(object.content)) {
+			t.Fatalf("object %s has size %d, expected %d", object.id, size, len(object.content))
+		}
+		check_pack_open_object(t, &pack, object.id, object.prefix, object.content)
+	}
+
+	if pack.archives[0].Size() >= int64(len(compressible)*2+len(incompressible)) {
+		t.Fatal("the pack archive was not compressed")
+	}
+}
+
+func TestPackWriterDeltas(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "pack")
+
+	// random parts don't compress, so any savings must come from deltas
+	base := make([]byte, 1<<20)
+	rand.Read(base)
+	parts := [][]byte{base}
+	for i := range 3 {
+		part := bytes.Clone(parts[len(parts)-1])
+		copy(part[len(part)/(i+2):], "a small edit to an otherwise identical part")
+		parts = append(parts, part)
+	}
+
+	var writer PackWriter
+	if err := writer.Open(name, -1, WithDeltas(2)); err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]ContentID, len(parts))
+	for i, part := range parts {
+		_, id, err := writer.Store(Part, part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = id
+	}
+	// the chain is limited to two deltas, so the last part can't be stored as a third
+	if depth := writer.index[ids[2]].delta_depth; depth != 2 {
+		t.Fatalf("part 2 has delta depth %d, expected 2", depth)
+	}
+	if depth := writer.index[ids[3]].delta_depth; depth > 2 {
+		t.Fatalf("part 3 has delta depth %d, which exceeds the limit", depth)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var pack Pack
+	if err := pack.Open(name, -1); err != nil {
+		t.Fatal(err)
+	}
+	defer pack.Close()
+
+	for i, part := range parts {
+		prefix, content, err := pack.Load(ids[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prefix != Part || !bytes.Equal(content, part) {
+			t.Fatalf("part %d was not reconstructed correctly", i)
+		}
+		size, err := pack.Stat(ids[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != int64(len(part)) {
+			t.Fatalf("part %d has size %d, expected %d", i, size, len(part))
+		}
+		check_pack_open_object(t, &pack, ids[i], Part, part)
+	}
+
+	if pack.archives[0].Size() >= int64(len(base)*3) {
+		t.Fatal("the similar parts were not stored as deltas")
+	}
+}
+
+func TestPackWriterDeltaChainDepthLimit(t *testing.T) {
+	var o pack_writer_options
+	WithDeltas(MaxDeltaChainDepth + 100)(&o)
+	if !o.deltas || o.max_delta_depth != MaxDeltaChainDepth {
+		t.Fatalf("depth was not clamped: %d", o.max_delta_depth)
+	}
+	for _, depth := range []int{-1, MaxDeltaChainDepth + 1} {
+		if !errors.Is(CheckDeltaChainDepth(depth), ErrPackDeltaChainDepth) {
+			t.Fatalf("depth %d was accepted", depth)
+		}
+	}
+	if err := CheckDeltaChainDepth(MaxDeltaChainDepth); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -17,13 +17,16 @@ var (
 	Tree = Prefix{'T', 'R', 'E', 'E'}
 	// The entry is a commit object
 	Commit = Prefix{'E', 'D', 'I', 'T'}
+	// The entry contains the target of a symbolic link
+	Link = Prefix{'L', 'I', 'N', 'K'}
 )
 
 // String returns an ordinary name for each Prefix type
 // 1. File = "file"
 // 2. Part = "part"
 // 3. Tree = "tree"
-// 3. Commit = "commit"
+// 4. Commit = "commit"
+// 5. Link = "link"
 func (p Prefix) String() string {
 	switch p {
 	case File:
@@ -34,6 +37,8 @@ func (p Prefix) String() string {
 		return "tree"
 	case Commit:
 		return "commit"
+	case Link:
+		return "link"
 	}
 	return "bad prefix(" + hex.EncodeToString(p[:]) + ")"
 }
@@ -1,9 +1,34 @@
 package cas
 
+import "sync"
+
 // Set is the set of all objects held by the repository and index
 type Set struct {
 	// the location of the cas.Set. this never contains a trailing slash
 	directory string
+	options   set_options
 	cache     cache
-	pack      Pack
+	// guards the list of packs
+	guard sync.RWMutex
+	// every pack that has been loaded into the set.
+	// objects are looked up in the order that the packs were loaded
+	packs []*Pack
+	// read-only Sets that are consulted after the cache and packs
+	alternates []*Set
+}
+
+// returns a snapshot of the currently loaded alternates
+func (set *Set) loaded_alternates() (alternates []*Set) {
+	set.guard.RLock()
+	alternates = set.alternates
+	set.guard.RUnlock()
+	return
+}
+
+// returns a snapshot of the currently loaded packs
+func (set *Set) loaded_packs() (packs []*Pack) {
+	set.guard.RLock()
+	packs = set.packs
+	set.guard.RUnlock()
+	return
 }
@@ -0,0 +1,40 @@
+package cas
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// AddAlternate opens the Set in directory read-only, and consults it for objects that are missing from this Set.
+func (set *Set) AddAlternate(directory string) (err error) {
+	directory, err = filepath.Abs(directory)
+	if err != nil {
+		return
+	}
+
+	alternate := new(Set)
+	if err = alternate.Open(directory, WithReadOnly()); err != nil {
+		err = fmt.Errorf("cas: in opening alternate %s: %w", directory, err)
+		return
+	}
+
+	set.guard.Lock()
+	set.alternates = append(set.alternates, alternate)
+	set.guard.Unlock()
+	return
+}
+
+// Alternates returns the directories of every alternate Set
+func (set *Set) Alternates() (directories []string) {
+	for _, alternate := range set.loaded_alternates() {
+		directories = append(directories, alternate.directory)
+	}
+	return
+}
+
+// Local returns true if the object is stored in the Set's own cache or packs, rather than only in an alternate.
+func (set *Set) Local(id ContentID) (local bool) {
+	_, err := set.stat_local(id)
+	local = err == nil
+	return
+}
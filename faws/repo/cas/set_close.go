@@ -2,5 +2,23 @@ package cas
 
 // Close relinquishes all resources held by the Set
 func (set *Set) Close() (err error) {
+	set.guard.Lock()
+	defer set.guard.Unlock()
+
+	for _, pack := range set.packs {
+		if err = pack.Close(); err != nil {
+			return
+		}
+	}
+	set.packs = nil
+
+	for _, alternate := range set.alternates {
+		if err = alternate.Close(); err != nil {
+			return
+		}
+	}
+	set.alternates = nil
+
+	err = set.cache.Close()
 	return
 }
@@ -23,48 +23,53 @@ func (set *Set) Deabbreviate(abbreviation string) (content_id ContentID, err err
 		return
 	}
 
-	// the result depends on both the cache and the pack
-	cache_content_id, cache_err := set.cache.Deabbreviate(abbreviation)
-	if cache_err != nil {
-		// if the cache finds the abbreviation is ambiguous, then there is no point to searching the pack
-		if errors.Is(cache_err, ErrAbbreviationAmbiguous) {
-			err = cache_err
-			return
+	// the result depends on both the cache and every pack
+	found := false
+	consider := func(candidate ContentID, candidate_err error) (ambiguous bool) {
+		if candidate_err != nil {
+			// if any source finds the abbreviation is ambiguous, then there is no point to searching the others
+			return errors.Is(candidate_err, ErrAbbreviationAmbiguous)
+		}
+		if found && candidate != content_id {
+			// if the abbreviation leads to two different content IDs in different sources, then it is ambiguous
+			return true
 		}
+		// two sources may deabbreviate to the same ID. while this shouldn't ordinarily happen, it's technically valid.
+		found = true
+		content_id = candidate
+		return false
 	}
 
-	pack_content_id, pack_err := set.pack.Deabbreviate(abbreviation)
-	if pack_err != nil {
-		if errors.Is(pack_err, ErrAbbreviationAmbiguous) {
-			err = pack_err
-			return
-		}
+	if consider(set.cache.Deabbreviate(abbreviation)) {
+		content_id = Nil
+		err = ErrAbbreviationAmbiguous
+		return
 	}
-	if pack_err == nil && cache_err == nil {
-		if cache_content_id != pack_content_id {
-			// if the abbreviation leads to two different content IDs in the cache and the pack, then it is ambiguous
+
+	for _, pack := range set.loaded_packs() {
+		if consider(pack.Deabbreviate(abbreviation)) {
+			content_id = Nil
 			err = ErrAbbreviationAmbiguous
-		} else {
-			// they debbreviated to the same ID. while this shouldn't ordinarily happen, it's technically valid.
-			content_id = cache_content_id
+			return
 		}
-		return
 	}
 
-	if cache_err == nil && pack_err != nil {
-		// the cache has the only valid id
-		content_id, err = cache_content_id, nil
-		return
+	for _, alternate := range set.loaded_alternates() {
+		candidate, candidate_err := alternate.Deabbreviate(abbreviation)
+		if errors.Is(candidate_err, ErrObjectNotFound) {
+			continue
+		}
+		if consider(candidate, candidate_err) {
+			content_id = Nil
+			err = ErrAbbreviationAmbiguous
+			return
+		}
 	}
 
-	if pack_err == nil && cache_err != nil {
-		// the pack has the only valid id
-		content_id, err = pack_content_id, nil
-		return
+	if !found {
+		// none are valid
+		err = ErrObjectNotFound
 	}
 
-	// neither are valid
-	err = ErrObjectNotFound
-
 	return
 }
@@ -6,12 +6,18 @@ type ListFunc func(packed bool, id ContentID) (err error)
 // List will enumerate all objects in the Set using the supplied [ListFunc] callback.
 //
 // If the function returns non-nil, the list will be aborted.
-// You may directly read objects while using the ListFunc, but you may not write or remove objects
+// You may directly read objects while using the ListFunc, but you may not write or remove objects.
+// An object that is present in more than one pack may be enumerated more than once.
+// Objects in alternates are not local to the Set, so they are never enumerated.
 func (set *Set) List(fn ListFunc) (err error) {
 	if err = set.cache.List(fn); err != nil {
 		return
 	}
 
-	err = set.pack.List(fn)
+	for _, pack := range set.loaded_packs() {
+		if err = pack.List(fn); err != nil {
+			return
+		}
+	}
 	return
 }
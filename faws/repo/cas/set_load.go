@@ -11,10 +11,28 @@ func (set *Set) Load(id ContentID) (prefix Prefix, data []byte, err error) {
 	if err == nil {
 		return
 	} else if !errors.Is(err, ErrObjectNotFound) {
+		set.check_corrupted(prefix, id, err)
 		return
 	}
 
-	// now try to load from the pack
-	prefix, data, err = set.pack.Load(id)
+	// now try to load from each pack
+	for _, pack := range set.loaded_packs() {
+		prefix, data, err = pack.Load(id)
+		if err == nil || !errors.Is(err, ErrObjectNotFound) {
+			set.check_corrupted(prefix, id, err)
+			return
+		}
+	}
+
+	// finally, try each alternate
+	for _, alternate := range set.loaded_alternates() {
+		prefix, data, err = alternate.Load(id)
+		if err == nil || !errors.Is(err, ErrObjectNotFound) {
+			set.check_corrupted(prefix, id, err)
+			return
+		}
+	}
+
+	err = object_error{ErrObjectNotFound, id}
 	return
 }
@@ -13,12 +13,18 @@ import (
 
 // Open will start using a directory to contain the [Set]. If a directory does not exist at path, one will be created.
 // [Close] must be called when the [Set] is no longer in use.
-func (set *Set) Open(path string) (err error) {
+func (set *Set) Open(path string, options ...SetOption) (err error) {
+	set.options = set_options{}
+	for _, option := range options {
+		option(&set.options)
+	}
+
 	path = strings.TrimRight(path, "\\/")
 
 	set_fi, stat_err := os.Stat(path)
 	if stat_err != nil {
-		if !errors.Is(stat_err, fs.ErrNotExist) {
+		// a read-only Set is never created
+		if !errors.Is(stat_err, fs.ErrNotExist) || set.options.read_only {
 			err = stat_err
 			return
 		}
@@ -37,20 +43,27 @@ func (set *Set) Open(path string) (err error) {
 		return
 	}
 
-	test_path := filepath.Join(path, "testwrite")
-	if err = os.WriteFile(test_path, nil, os.ModePerm); err != nil {
-		return
+	if !set.options.read_only {
+		test_path := filepath.Join(path, "testwrite")
+		if err = os.WriteFile(test_path, nil, os.ModePerm); err != nil {
+			return
+		}
+		os.Remove(test_path)
 	}
-	os.Remove(test_path)
 
 	set.directory = path
 
 	if err = set.cache.Open(set.directory); err != nil {
 		return
 	}
-	if err = set.pack.Open(filepath.Join(set.directory, "/pack"), -1); err != nil {
+	if err = set.open_packs(); err != nil {
 		return
 	}
+	for _, alternate := range set.options.alternates {
+		if err = set.AddAlternate(alternate); err != nil {
+			return
+		}
+	}
 
 	return
 }
@@ -0,0 +1,62 @@
+package cas
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// OpenObject returns a reader over the content of an object, along with its prefix and size.
+// Unlike [Set.Load], the content is streamed from disk where possible. The content is verified
+// as it is read: if it does not match the ContentID, the final Read returns [ErrObjectCorrupted].
+//
+// If the [Set] was opened [WithParanoid], the object is read and verified in full before OpenObject returns.
+func (set *Set) OpenObject(id ContentID) (prefix Prefix, content io.ReadCloser, size int64, err error) {
+	prefix, content, size, err = set.open_object(id)
+	if err != nil || !set.options.paranoid {
+		return
+	}
+
+	var data []byte
+	data, err = io.ReadAll(content)
+	content.Close()
+	if err != nil {
+		content = nil
+		set.check_corrupted(prefix, id, err)
+		return
+	}
+	content = io.NopCloser(bytes.NewReader(data))
+	return
+}
+
+func (set *Set) open_object(id ContentID) (prefix Prefix, content io.ReadCloser, size int64, err error) {
+	// attempt to open from the cache
+	prefix, content, size, err = set.cache.OpenObject(id)
+	if err == nil {
+		return
+	} else if !errors.Is(err, ErrObjectNotFound) {
+		set.check_corrupted(prefix, id, err)
+		return
+	}
+
+	// now try to open from each pack
+	for _, pack := range set.loaded_packs() {
+		prefix, content, size, err = pack.OpenObject(id)
+		if err == nil || !errors.Is(err, ErrObjectNotFound) {
+			set.check_corrupted(prefix, id, err)
+			return
+		}
+	}
+
+	// finally, try each alternate
+	for _, alternate := range set.loaded_alternates() {
+		prefix, content, size, err = alternate.open_object(id)
+		if err == nil || !errors.Is(err, ErrObjectNotFound) {
+			set.check_corrupted(prefix, id, err)
+			return
+		}
+	}
+
+	err = object_error{ErrObjectNotFound, id}
+	return
+}
@@ -0,0 +1,53 @@
+package cas
+
+import "errors"
+
+// CorruptionFunc is called when a [Set] finds that an object's content does not match its ContentID
+type CorruptionFunc func(prefix Prefix, id ContentID)
+
+type set_options struct {
+	paranoid     bool
+	on_corrupted CorruptionFunc
+	// the directories of other Sets to read objects from
+	alternates []string
+	// the Set belongs to another repository, and must never be modified
+	read_only bool
+}
+
+// A SetOption can be passed to [Set.Open] to change how objects are read
+type SetOption func(*set_options)
+
+// WithParanoid is a [SetOption] that verifies every object in full before any of its content is handed to the caller,
+// including objects opened with [Set.OpenObject], which would otherwise only be verified once they are read to the end.
+// Whenever an object fails verification or cannot be decoded from a pack, on_corrupted is called (if it is not nil)
+// and the caller receives the error instead of the content.
+func WithParanoid(on_corrupted CorruptionFunc) SetOption {
+	return func(o *set_options) {
+		o.paranoid = true
+		o.on_corrupted = on_corrupted
+	}
+}
+
+// WithAlternates is a [SetOption] that consults the Sets in each directory, in order, for objects missing from this Set.
+// Alternate Sets are opened read-only: objects are never stored in them or removed from them.
+// Alternates of alternates are not consulted.
+func WithAlternates(directories ...string) SetOption {
+	return func(o *set_options) {
+		o.alternates = append(o.alternates, directories...)
+	}
+}
+
+// WithReadOnly is a [SetOption] that opens an existing Set only for reading, such as a Set owned by another process.
+// The directory is not created if it is missing, and packs are opened read-only. Objects should not be stored or removed.
+func WithReadOnly() SetOption {
+	return func(o *set_options) {
+		o.read_only = true
+	}
+}
+
+// reports err to the corruption handler, if it signifies a corrupted object
+func (set *Set) check_corrupted(prefix Prefix, id ContentID, err error) {
+	if set.options.paranoid && set.options.on_corrupted != nil && (errors.Is(err, ErrObjectCorrupted) || errors.Is(err, ErrPackArchiveBadEntry)) {
+		set.options.on_corrupted(prefix, id)
+	}
+}
@@ -0,0 +1,169 @@
+package cas
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	fawsfs "github.com/faws-vcs/faws/faws/fs"
+	"github.com/faws-vcs/faws/faws/validate"
+)
+
+const (
+	// the directory inside the Set that holds named packs
+	packed_directory_name = "packed"
+	// older repositories kept a single pack at the root of the Set
+	legacy_pack_name = "pack"
+)
+
+func (set *Set) packed_directory() string {
+	return filepath.Join(set.directory, packed_directory_name)
+}
+
+func (set *Set) open_pack(name string) (err error) {
+	pack := new(Pack)
+	if set.options.read_only {
+		err = pack.OpenReadOnly(name)
+	} else {
+		err = pack.Open(name, -1)
+	}
+	if err != nil {
+		return
+	}
+	set.guard.Lock()
+	set.packs = append(set.packs, pack)
+	set.guard.Unlock()
+	return
+}
+
+// discovers and loads every pack belonging to the Set
+func (set *Set) open_packs() (err error) {
+	// load the legacy pack only if it exists
+	legacy_pack := filepath.Join(set.directory, legacy_pack_name)
+	if _, stat_err := os.Stat(legacy_pack); stat_err == nil {
+		if err = set.open_pack(legacy_pack); err != nil {
+			return
+		}
+	}
+
+	packed_directory := set.packed_directory()
+	if set.options.read_only {
+		if _, stat_err := os.Stat(packed_directory); stat_err != nil {
+			return
+		}
+	} else if err = os.MkdirAll(packed_directory, fawsfs.DefaultPublicDirPerm); err != nil {
+		return
+	}
+
+	var packed_entries []os.DirEntry
+	packed_entries, err = os.ReadDir(packed_directory)
+	if err != nil {
+		return
+	}
+
+	// any file with a valid pack name is an index. archives are named <pack name>.<archive id>
+	for _, packed_entry := range packed_entries {
+		if !packed_entry.IsDir() && validate.PackName(packed_entry.Name()) == nil {
+			if err = set.open_pack(filepath.Join(packed_directory, packed_entry.Name())); err != nil {
+				err = fmt.Errorf("cas: in opening pack %s: %w", packed_entry.Name(), err)
+				return
+			}
+		}
+	}
+
+	return
+}
+
+// Packs returns the names of each pack loaded into the Set
+func (set *Set) Packs() (names []string) {
+	for _, pack := range set.loaded_packs() {
+		names = append(names, pack.Name())
+	}
+	return
+}
+
+// returns the names of the index and archive files that make up the pack named by path
+func pack_file_names(path string) (names []string, err error) {
+	directory := filepath.Dir(path)
+	pack_id := filepath.Base(path)
+	var directory_entries []os.DirEntry
+	directory_entries, err = os.ReadDir(directory)
+	if err != nil {
+		return
+	}
+
+	for _, directory_entry := range directory_entries {
+		if directory_entry.IsDir() {
+			continue
+		}
+		if directory_entry.Name() == pack_id {
+			names = append(names, pack_id)
+			continue
+		}
+		sibling_name, archive_id_name, found := strings.Cut(directory_entry.Name(), ".")
+		if found && sibling_name == pack_id {
+			if _, parse_err := strconv.ParseInt(archive_id_name, 10, 32); parse_err == nil {
+				names = append(names, directory_entry.Name())
+			}
+		}
+	}
+
+	return
+}
+
+// moves (or copies if keep == true) the pack named by source into the packed directory, renaming it to name
+func (set *Set) import_pack(source string, name string, keep bool) (err error) {
+	source_directory := filepath.Dir(source)
+	source_pack_id := filepath.Base(source)
+
+	var source_pack_names []string
+	source_pack_names, err = pack_file_names(source)
+	if err != nil {
+		return
+	}
+
+	packed_directory := set.packed_directory()
+	for _, source_pack_name := range source_pack_names {
+		set_pack_name := name
+		if source_pack_name != source_pack_id {
+			_, archive_id_name, _ := strings.Cut(source_pack_name, ".")
+			set_pack_name = name + "." + archive_id_name
+		}
+
+		if keep {
+			err = copy_file(filepath.Join(packed_directory, set_pack_name), filepath.Join(source_directory, source_pack_name))
+		} else {
+			err = move_file(filepath.Join(packed_directory, set_pack_name), filepath.Join(source_directory, source_pack_name))
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// AddPack loads the pack named by source into the Set alongside the packs that already exist.
+// The pack is stored under the same name as its index file. If keep == true, the source pack is preserved.
+func (set *Set) AddPack(source string, keep bool) (err error) {
+	name := filepath.Base(source)
+	if err = validate.PackName(name); err != nil {
+		return
+	}
+
+	if _, stat_err := os.Stat(filepath.Join(set.packed_directory(), name)); !errors.Is(stat_err, fs.ErrNotExist) {
+		err = fmt.Errorf("%w: %s", ErrPackExists, name)
+		return
+	}
+
+	if err = set.import_pack(source, name, keep); err != nil {
+		return
+	}
+
+	err = set.open_pack(filepath.Join(set.packed_directory(), name))
+	return
+}
@@ -1,13 +1,40 @@
 package cas
 
+import "errors"
+
 // Stat tests the existence of an object named by the [ContentID], and returns its size if it does exist.
 // If it does not exist, err will be [ErrObjectNotFound].
 func (set *Set) Stat(id ContentID) (size int64, err error) {
+	size, err = set.stat_local(id)
+	if err == nil || !errors.Is(err, ErrObjectNotFound) {
+		return
+	}
+
+	for _, alternate := range set.loaded_alternates() {
+		size, err = alternate.Stat(id)
+		if err == nil || !errors.Is(err, ErrObjectNotFound) {
+			return
+		}
+	}
+
+	err = object_error{ErrObjectNotFound, id}
+	return
+}
+
+// stats an object in the cache or packs, ignoring alternates
+func (set *Set) stat_local(id ContentID) (size int64, err error) {
 	size, err = set.cache.Stat(id)
 	if err == nil {
 		return
 	}
 
-	size, err = set.pack.Stat(id)
+	for _, pack := range set.loaded_packs() {
+		size, err = pack.Stat(id)
+		if err == nil || !errors.Is(err, ErrObjectNotFound) {
+			return
+		}
+	}
+
+	err = object_error{ErrObjectNotFound, id}
 	return
 }
@@ -15,6 +15,7 @@ import (
 // If id == nil, each object is checked for consistency, including orphaned objects.
 // If purge == false, [event.NotifyCorruptedObject] is generated upon encountering an inconsistent or corrupt object.
 // If purge == true,  [event.NotifyRemovedCorruptedObject] is generated upon encountering an inconsistent or corrupt object, and the object is deleted.
+// Objects in alternates are checked when they are reachable from id, but never deleted.
 func (repo *Repository) CheckObjects(id cas.ContentID, purge bool) (err error) {
 	if id == cas.Nil {
 		err = repo.objects.List(func(cache bool, id cas.ContentID) (err error) {
@@ -34,7 +35,8 @@ func (repo *Repository) CheckObjects(id cas.ContentID, purge bool) (err error) {
 						}
 
 						repo.notify(event.NotifyRemovedCorruptedObject, &notify_params)
-					} else {
+					} else if !repo.is_paranoid() {
+						// in paranoid mode, the object set has already reported it
 						repo.notify(event.NotifyCorruptedObject, &notify_params)
 					}
 					err = nil
@@ -49,6 +51,9 @@ func (repo *Repository) CheckObjects(id cas.ContentID, purge bool) (err error) {
 		return
 	}
 
+	// objects that are only in an alternate belong to another repository, so they can be reported but not removed
+	purge_object := purge && repo.objects.Local(id)
+
 	var (
 		prefix      cas.Prefix
 		object_data []byte
@@ -59,13 +64,14 @@ func (repo *Repository) CheckObjects(id cas.ContentID, purge bool) (err error) {
 			var notify_params event.NotifyParams
 			notify_params.Prefix = prefix
 			notify_params.Object1 = id
-			if purge {
+			if purge_object {
 				err = repo.RemoveObject(id)
 				if err != nil {
 					return
 				}
 				repo.notify(event.NotifyRemovedCorruptedObject, &notify_params)
-			} else {
+			} else if !repo.is_paranoid() {
+				// in paranoid mode, the object set has already reported it
 				repo.notify(event.NotifyCorruptedObject, &notify_params)
 			}
 			err = nil
@@ -83,7 +89,7 @@ func (repo *Repository) CheckObjects(id cas.ContentID, purge bool) (err error) {
 			var notify_params event.NotifyParams
 			notify_params.Prefix = prefix
 			notify_params.Object1 = id
-			if purge {
+			if purge_object {
 				repo.notify(event.NotifyRemovedCorruptedObject, &notify_params)
 				err = repo.RemoveObject(id)
 			} else {
@@ -96,7 +102,7 @@ func (repo *Repository) CheckObjects(id cas.ContentID, purge bool) (err error) {
 			var notify_params event.NotifyParams
 			notify_params.Prefix = prefix
 			notify_params.Object1 = id
-			if purge {
+			if purge_object {
 				repo.notify(event.NotifyRemovedCorruptedObject, &notify_params)
 				err = repo.RemoveObject(id)
 			} else {
@@ -111,7 +117,7 @@ func (repo *Repository) CheckObjects(id cas.ContentID, purge bool) (err error) {
 			var notify_params event.NotifyParams
 			notify_params.Prefix = prefix
 			notify_params.Object1 = id
-			if purge {
+			if purge_object {
 				repo.notify(event.NotifyRemovedCorruptedObject, &notify_params)
 				err = repo.RemoveObject(id)
 			} else {
@@ -128,7 +134,7 @@ func (repo *Repository) CheckObjects(id cas.ContentID, purge bool) (err error) {
 			var notify_params event.NotifyParams
 			notify_params.Prefix = prefix
 			notify_params.Object1 = id
-			if purge {
+			if purge_object {
 				if err = repo.RemoveObject(id); err != nil {
 					return
 				}
@@ -5,6 +5,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/faws-vcs/faws/faws/fs"
 	"github.com/faws-vcs/faws/faws/repo/cas"
@@ -14,11 +16,12 @@ import (
 )
 
 var (
-	ErrCheckoutBadPrefix = fmt.Errorf("faws/repo: bad prefix")
-	ErrCheckoutOverwrite = fmt.Errorf("faws/repo: a file exists at the destination. pass -w, --overwrite to write anyway")
+	ErrCheckoutBadPrefix   = fmt.Errorf("faws/repo: bad prefix")
+	ErrCheckoutOverwrite   = fmt.Errorf("faws/repo: a file exists at the destination. pass -w, --overwrite to write anyway")
+	ErrCheckoutLinkEscapes = fmt.Errorf("faws/repo: symbolic link target escapes the checkout directory")
 )
 
-func (repo *Repository) checkout_file(file_hash cas.ContentID, mode revision.FileMode, dest string, overwrite bool) (err error) {
+func (repo *Repository) checkout_file(file_hash cas.ContentID, mode revision.FileMode, metadata revision.FileMetadata, dest string, overwrite bool) (err error) {
 	var (
 		file_data   []byte
 		file_prefix cas.Prefix
@@ -79,38 +82,213 @@ func (repo *Repository) checkout_file(file_hash cas.ContentID, mode revision.Fil
 	}
 
 	var (
-		part_prefix cas.Prefix
-		part_data   []byte
+		part_prefix  cas.Prefix
+		part_content io.ReadCloser
 	)
 	part_hashes = file_data
 	for len(part_hashes) > 0 {
 		copy(part_hash[:], part_hashes[:cas.ContentIDSize])
 		part_hashes = part_hashes[cas.ContentIDSize:]
-		part_prefix, part_data, err = repo.objects.Load(part_hash)
+		// parts are streamed straight to the file, rather than loaded whole
+		part_prefix, part_content, part_size, err = repo.objects.OpenObject(part_hash)
 		if err != nil {
 			return
 		}
 		if part_prefix != cas.Part {
+			part_content.Close()
 			err = ErrCheckoutBadPrefix
 			return
 		}
 
-		_, err = file.Write(part_data)
+		_, err = io.Copy(file, part_content)
+		part_content.Close()
 		if err != nil {
 			return
 		}
 
 		var notify_checkout_file_part event.NotifyParams
-		notify_checkout_file_part.Count = int64(len(part_data))
+		notify_checkout_file_part.Count = part_size
 		repo.notify(event.NotifyCheckoutFilePart, &notify_checkout_file_part)
 	}
 
-	err = file.Close()
+	if err = file.Close(); err != nil {
+		return
+	}
+
+	err = restore_file_metadata(dest, mode, metadata)
+	return
+}
+
+// applies the recorded POSIX mode and modification time to a file that was checked out
+func restore_file_metadata(dest string, mode revision.FileMode, metadata revision.FileMetadata) (err error) {
+	if perm, ok := metadata.Permissions(mode); ok {
+		if err = os.Chmod(dest, perm); err != nil {
+			return
+		}
+	}
+
+	if mod_time, ok := metadata.Time(); ok {
+		// a zero access time is left unchanged
+		if err = os.Chtimes(dest, time.Time{}, mod_time); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// recreates a symbolic link at dest. The link must not point outside of root, the directory being checked out.
+func (repo *Repository) checkout_link(link_hash cas.ContentID, root, dest string, overwrite bool) (err error) {
+	var target string
+	target, err = repo.Link(link_hash)
+	if err != nil {
+		return
+	}
+
+	// resolve the target relative to the directory containing the link
+	native_target := filepath.FromSlash(target)
+	if target == "" || filepath.IsAbs(native_target) || filepath.VolumeName(native_target) != "" || strings.HasPrefix(target, "/") {
+		err = fmt.Errorf("%w: %s -> %s", ErrCheckoutLinkEscapes, dest, target)
+		return
+	}
+	var relative string
+	relative, err = filepath.Rel(root, filepath.Join(filepath.Dir(dest), native_target))
+	if err != nil {
+		return
+	}
+	if relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		err = fmt.Errorf("%w: %s -> %s", ErrCheckoutLinkEscapes, dest, target)
+		return
+	}
+
+	if _, stat_err := os.Lstat(dest); stat_err == nil {
+		if !overwrite {
+			err = ErrCheckoutOverwrite
+			return
+		}
+		if err = os.Remove(dest); err != nil {
+			return
+		}
+	}
+
+	var notify_checkout event.NotifyParams
+	notify_checkout.Name1 = dest
+	repo.notify(event.NotifyCheckoutFile, &notify_checkout)
+
+	err = os.Symlink(native_target, dest)
+	return
+}
+
+// creates the symbolic links of a checkout. A link can be made to escape root by a chain of other links,
+// which may be created after it, so each link is checked against the real filesystem once all of them exist.
+// If any of them escapes, every link that was created is removed again.
+func (repo *Repository) checkout_links(root string, links []checkout_pending_link, overwrite bool) (err error) {
+	var created []string
+	defer func() {
+		if err != nil {
+			for _, dest := range created {
+				os.Remove(dest)
+			}
+		}
+	}()
 
+	for _, link := range links {
+		if err = repo.checkout_link(link.link_hash, root, link.dest, overwrite); err != nil {
+			return
+		}
+		created = append(created, link.dest)
+	}
+
+	var resolved_root string
+	if resolved_root, err = filepath.EvalSymlinks(root); err != nil {
+		return
+	}
+	for _, dest := range created {
+		var inside bool
+		if inside, err = link_resolves_inside(resolved_root, dest); err != nil {
+			return
+		}
+		if !inside {
+			target, _ := os.Readlink(dest)
+			err = fmt.Errorf("%w: %s -> %s", ErrCheckoutLinkEscapes, dest, target)
+			return
+		}
+	}
 	return
 }
 
-func (repo *Repository) checkout_tree(tree_hash cas.ContentID, dest string, overwrite bool) (err error) {
+// the most links that are followed when resolving a link, like the limit the kernel imposes
+const max_link_hops = 40
+
+// follows the symbolic link at name the way the operating system would, returning true if it ends up inside of root.
+// root must already be resolved. Components that don't exist are resolved lexically.
+func link_resolves_inside(root, name string) (inside bool, err error) {
+	var (
+		current string
+		target  string
+	)
+	if current, err = filepath.EvalSymlinks(filepath.Dir(name)); err != nil {
+		return
+	}
+	if target, err = os.Readlink(name); err != nil {
+		return
+	}
+
+	components := strings.Split(filepath.ToSlash(target), "/")
+	if filepath.IsAbs(target) {
+		current = string(filepath.Separator)
+	}
+
+	hops := 0
+	for len(components) > 0 {
+		component := components[0]
+		components = components[1:]
+
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+			continue
+		}
+
+		next := filepath.Join(current, component)
+		fi, stat_err := os.Lstat(next)
+		if stat_err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			current = next
+			continue
+		}
+
+		hops++
+		if hops > max_link_hops {
+			// the operating system refuses to resolve it, so it can't lead anywhere
+			inside = true
+			return
+		}
+		var next_target string
+		if next_target, err = os.Readlink(next); err != nil {
+			return
+		}
+		if filepath.IsAbs(next_target) {
+			current = string(filepath.Separator)
+		}
+		components = append(strings.Split(filepath.ToSlash(next_target), "/"), components...)
+	}
+
+	var relative string
+	if relative, err = filepath.Rel(root, current); err != nil {
+		return
+	}
+	inside = relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+	return
+}
+
+// a symbolic link found in a tree, which is created once everything else has been checked out
+type checkout_pending_link struct {
+	link_hash cas.ContentID
+	dest      string
+}
+
+func (repo *Repository) checkout_tree(tree_hash cas.ContentID, dest string, overwrite bool, links *[]checkout_pending_link) (err error) {
 	var tree *revision.Tree
 	tree, err = repo.load_tree(tree_hash)
 	if err != nil {
@@ -129,13 +307,15 @@ func (repo *Repository) checkout_tree(tree_hash cas.ContentID, dest string, over
 
 		switch tree_entry.Prefix {
 		case cas.Tree:
-			if err = repo.checkout_tree(tree_entry.Content, filepath.Join(dest, tree_entry.Name), overwrite); err != nil {
+			if err = repo.checkout_tree(tree_entry.Content, filepath.Join(dest, tree_entry.Name), overwrite, links); err != nil {
 				return
 			}
 		case cas.File:
-			if err = repo.checkout_file(tree_entry.Content, tree_entry.Mode, filepath.Join(dest, tree_entry.Name), overwrite); err != nil {
+			if err = repo.checkout_file(tree_entry.Content, tree_entry.Mode, tree_entry.Metadata, filepath.Join(dest, tree_entry.Name), overwrite); err != nil {
 				return
 			}
+		case cas.Link:
+			*links = append(*links, checkout_pending_link{tree_entry.Content, filepath.Join(dest, tree_entry.Name)})
 		default:
 			err = ErrCheckoutBadPrefix
 			return
@@ -152,7 +332,17 @@ func (repo *Repository) checkout_commit(commit_hash cas.ContentID, dest string,
 		return
 	}
 
-	return repo.checkout_tree(commit_info.Tree, dest, overwrite)
+	return repo.checkout_root_tree(commit_info.Tree, dest, overwrite)
+}
+
+// checks out a tree into dest, followed by its symbolic links
+func (repo *Repository) checkout_root_tree(tree_hash cas.ContentID, dest string, overwrite bool) (err error) {
+	var links []checkout_pending_link
+	if err = repo.checkout_tree(tree_hash, dest, overwrite, &links); err != nil {
+		return
+	}
+	err = repo.checkout_links(dest, links, overwrite)
+	return
 }
 
 // Checkout exports an object (most commonly, a commit) to a destination on the host filesystem.
@@ -163,22 +353,26 @@ func (repo *Repository) Checkout(object_hash cas.ContentID, dest string, overwri
 	checkout_stage.Stage = event.StageCheckout
 	repo.notify(event.NotifyBeginStage, &checkout_stage)
 
+	// only the prefix is needed here
 	var (
-		prefix cas.Prefix
-		// data   []byte
+		prefix  cas.Prefix
+		content io.ReadCloser
 	)
-	prefix, _, err = repo.objects.Load(object_hash)
+	prefix, content, _, err = repo.objects.OpenObject(object_hash)
 	if err != nil {
 		return
 	}
+	content.Close()
 
 	switch prefix {
 	case cas.Commit:
 		err = repo.checkout_commit(object_hash, dest, overwrite)
 	case cas.Tree:
-		err = repo.checkout_tree(object_hash, dest, overwrite)
+		err = repo.checkout_root_tree(object_hash, dest, overwrite)
 	case cas.File:
-		err = repo.checkout_file(object_hash, 0, dest, overwrite)
+		err = repo.checkout_file(object_hash, 0, revision.FileMetadata{}, dest, overwrite)
+	case cas.Link:
+		err = repo.checkout_links(filepath.Dir(dest), []checkout_pending_link{{object_hash, dest}}, overwrite)
 	default:
 		err = ErrCheckoutBadPrefix
 	}
@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/faws-vcs/faws/faws/multipart"
+	"github.com/faws-vcs/faws/faws/repo/config"
+	"github.com/faws-vcs/faws/faws/repo/pathspec"
+)
+
+// a compiled config.ChunkerRule
+type chunker_rule struct {
+	pathspec *pathspec.Pathspec
+	chunker  string
+}
+
+// checks that a chunker name is registered
+func check_chunker(name string) (err error) {
+	if !slices.Contains(multipart.Chunkers(), name) {
+		err = fmt.Errorf("%w: %s", multipart.ErrUnknownChunker, name)
+	}
+	return
+}
+
+// compiles the chunker rules from the config
+func (repo *Repository) chunker_rules() (rules []chunker_rule, err error) {
+	for _, config_rule := range repo.config.Chunkers {
+		var rule chunker_rule
+		rule.pathspec, err = pathspec.Compile(config_rule.Pattern)
+		if err != nil {
+			return
+		}
+		if err = check_chunker(config_rule.Chunker); err != nil {
+			err = fmt.Errorf("faws/repo: bad chunker rule '%s': %w", config_rule.Pattern, err)
+			return
+		}
+		rule.chunker = config_rule.Chunker
+		rules = append(rules, rule)
+	}
+	return
+}
+
+// returns the name of the chunker that should be used for a destination in the index,
+// or "" to detect the format of the file
+func (o *staging_options) chunker_for(destination string) string {
+	if o.chunker != "" {
+		return o.chunker
+	}
+	for _, rule := range o.chunker_rules {
+		if rule.pathspec.MatchString(destination) {
+			return rule.chunker
+		}
+	}
+	return ""
+}
+
+// converts multipart.ChunkingParams to their config representation
+func chunking_config(params multipart.ChunkingParams) (chunking *config.Chunking) {
+	chunking = new(config.Chunking)
+	chunking.MinSize = params.MinSize
+	chunking.AverageSize = params.AverageSize
+	chunking.MaxSize = params.MaxSize
+	chunking.Polynomial = strconv.FormatUint(params.Polynomial, 16)
+	return
+}
+
+// returns the chunking parameters from a config, or the defaults if it has none
+func config_chunking_params(config_ *config.Config) (params multipart.ChunkingParams, err error) {
+	if config_.Chunking == nil {
+		params = multipart.DefaultChunkingParams
+		return
+	}
+	params.MinSize = config_.Chunking.MinSize
+	params.AverageSize = config_.Chunking.AverageSize
+	params.MaxSize = config_.Chunking.MaxSize
+	params.Polynomial, err = strconv.ParseUint(config_.Chunking.Polynomial, 16, 64)
+	if err != nil {
+		err = fmt.Errorf("%w: bad polynomial: %w", multipart.ErrBadChunkingParams, err)
+		return
+	}
+	err = params.Check()
+	return
+}
+
+// ChunkingParams returns the parameters used to split files added to the repository
+func (repo *Repository) ChunkingParams() (params multipart.ChunkingParams, err error) {
+	params, err = config_chunking_params(&repo.config)
+	return
+}
@@ -0,0 +1,381 @@
+package repo
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/faws-vcs/faws/faws/multipart"
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/repo/event"
+	"github.com/faws-vcs/faws/faws/repo/revision"
+)
+
+// a file or link found in the directory being compared
+type compare_file struct {
+	// relative to the directory, with forward slashes
+	path   string
+	source string
+	info   os.FileInfo
+}
+
+// a file or link in the tree being compared
+type compare_entry struct {
+	path  string
+	entry *revision.TreeEntry
+}
+
+// a file present in both the directory and the tree
+type compare_job struct {
+	file  *compare_file
+	entry *compare_entry
+	// the index of the difference to fill in
+	index      int
+	difference *Difference
+}
+
+// lists every file and link beneath a tree
+func (repo *Repository) flatten_tree(tree_hash cas.ContentID, path string, entries *[]compare_entry) (err error) {
+	var tree *revision.Tree
+	if tree, err = repo.diff_tree(tree_hash); err != nil {
+		return
+	}
+	if path != "" {
+		path += "/"
+	}
+	for i := range tree.Entries {
+		tree_entry := &tree.Entries[i]
+		if tree_entry.Prefix == cas.Tree {
+			if err = repo.flatten_tree(tree_entry.Content, path+tree_entry.Name, entries); err != nil {
+				return
+			}
+			continue
+		}
+		*entries = append(*entries, compare_entry{path + tree_entry.Name, tree_entry})
+	}
+	return
+}
+
+// lists every file and link in the directory that would be added by [Repository.Add]
+func (repo *Repository) walk_compare_directory(o *staging_options, source string) (files []compare_file, err error) {
+	err = filepath.WalkDir(source, func(path string, directory_entry fs.DirEntry, walk_err error) (err error) {
+		if walk_err != nil {
+			err = walk_err
+			return
+		}
+		if path == source {
+			return
+		}
+		if o.ignored(path, directory_entry.IsDir()) {
+			if directory_entry.IsDir() {
+				err = filepath.SkipDir
+			}
+			return
+		}
+		if directory_entry.IsDir() {
+			return
+		}
+
+		var file compare_file
+		file.source = path
+		if file.info, err = directory_entry.Info(); err != nil {
+			return
+		}
+		if !file.info.Mode().IsRegular() && file.info.Mode()&os.ModeSymlink == 0 {
+			// devices, sockets and pipes can't be added either
+			return
+		}
+		var relative string
+		if relative, err = filepath.Rel(source, path); err != nil {
+			return
+		}
+		file.path = filepath.ToSlash(relative)
+		files = append(files, file)
+		return
+	})
+	return
+}
+
+// returns the parts of a file, and the sum of their sizes
+func (repo *Repository) file_parts(file_hash cas.ContentID) (size int64, parts []cas.ContentID, err error) {
+	var file []byte
+	if _, file, err = repo.objects.Load(file_hash); err != nil {
+		return
+	}
+	parts = make([]cas.ContentID, len(file)/cas.ContentIDSize)
+	for i := range parts {
+		copy(parts[i][:], file[i*cas.ContentIDSize:])
+		var part_size int64
+		if part_size, err = repo.objects.Stat(parts[i]); err != nil {
+			return
+		}
+		size += part_size
+	}
+	return
+}
+
+// compares a file (or link) on disk with the tree entry at the same path, without storing anything
+func (repo *Repository) compare_file(o *staging_options, job *compare_job) (err error) {
+	var (
+		file       = job.file
+		entry      = job.entry.entry
+		difference = job.difference
+	)
+
+	if difference.Prefix2 == cas.Link {
+		var target string
+		if target, err = os.Readlink(file.source); err != nil {
+			return
+		}
+		difference.Object2 = cas.Hash(cas.Link, []byte(filepath.ToSlash(target)))
+		if difference.Object2 != difference.Object1 {
+			difference.Status |= DiffModified
+		}
+		return
+	}
+
+	var disk_entry revision.TreeEntry
+	disk_entry.Prefix = cas.File
+	disk_entry.Mode, disk_entry.Metadata = o.file_mode(file.info)
+	difference.Mode2 = disk_entry.Mode
+	if diff_mode_changed(entry, &disk_entry) {
+		difference.Status |= DiffModeChanged
+	}
+
+	// the size is the cheapest thing to compare
+	var (
+		size  int64
+		parts []cas.ContentID
+	)
+	if size, parts, err = repo.file_parts(entry.Content); err != nil {
+		return
+	}
+	if size != file.info.Size() {
+		difference.Status |= DiffModified
+		return
+	}
+
+	var source_file *os.File
+	if source_file, err = os.Open(file.source); err != nil {
+		return
+	}
+	defer source_file.Close()
+
+	var chunker multipart.Chunker
+	if chunker, err = repo.new_chunker(o, file.path, source_file); err != nil {
+		return
+	}
+
+	var notify_params event.NotifyParams
+	notify_params.Name1 = file.path
+	notify_params.Name2 = file.source
+	notify_params.Count = size
+	repo.notify(event.NotifyCacheFile, &notify_params)
+	defer repo.notify_cache_file_failed(file.path, &err)
+
+	// a lazy signature that was recorded for this very file is as good as reading it
+	if lazy_chunker, can_be_lazy := chunker.(multipart.LazyChunker); can_be_lazy {
+		var lazy_signature multipart.LazySignature
+		if lazy_signature, err = lazy_chunker.LazySignature(); err != nil {
+			return
+		}
+		if lazy_file_hash, ok := repo.index.lazy_signatures[lazy_signature]; ok && lazy_file_hash == entry.Content {
+			var notify_params event.NotifyParams
+			notify_params.Name1 = file.path
+			notify_params.Object1 = lazy_file_hash
+			repo.notify(event.NotifyCacheUsedLazySignature, &notify_params)
+
+			difference.Object2 = lazy_file_hash
+			return
+		}
+	}
+
+	var (
+		chunk     []byte
+		file_data []byte
+		new_parts []cas.ContentID
+	)
+	for {
+		_, chunk, err = chunker.Next()
+		if err != nil && errors.Is(err, io.EOF) {
+			err = nil
+			break
+		} else if err != nil {
+			return
+		}
+
+		part_id := cas.Hash(cas.Part, chunk)
+		file_data = append(file_data, part_id[:]...)
+		new_parts = append(new_parts, part_id)
+
+		var notify_part event.NotifyParams
+		notify_part.Name1 = file.path
+		notify_part.Count = int64(len(chunk))
+		repo.notify(event.NotifyCacheFilePart, &notify_part)
+	}
+
+	difference.Object2 = cas.Hash(cas.File, file_data)
+	if difference.Object2 == difference.Object1 {
+		return
+	}
+
+	difference.Status |= DiffModified
+	old_parts := make(map[cas.ContentID]struct{}, len(parts))
+	for _, part_id := range parts {
+		old_parts[part_id] = struct{}{}
+	}
+	for _, part_id := range new_parts {
+		if _, shared := old_parts[part_id]; shared {
+			difference.SharedParts++
+		} else {
+			difference.NewParts++
+		}
+	}
+	return
+}
+
+// CompareDirectory compares a directory on disk with a commit (or tree), as if the directory had been added to the root of the index
+// with the same options. Files are compared by size, then by lazy signature if one was recorded for the file in the tree,
+// and finally by hashing their chunks. No objects are stored.
+//
+// Every file is returned in path order, including unchanged files, which have a Status of 0.
+// Files that are only in the directory are not hashed, so their Object2 is cas.Nil.
+func (repo *Repository) CompareDirectory(hash cas.ContentID, source string, options ...StagingOption) (differences []Difference, err error) {
+	var o staging_options
+	if source, err = repo.init_staging_options(&o, source, options); err != nil {
+		return
+	}
+
+	var tree_hash cas.ContentID
+	if tree_hash, err = repo.diff_root(hash); err != nil {
+		return
+	}
+
+	var entries []compare_entry
+	if err = repo.flatten_tree(tree_hash, "", &entries); err != nil {
+		return
+	}
+	var files []compare_file
+	if files, err = repo.walk_compare_directory(&o, source); err != nil {
+		return
+	}
+	slices.SortFunc(entries, func(a, b compare_entry) int {
+		return strings.Compare(a.path, b.path)
+	})
+	slices.SortFunc(files, func(a, b compare_file) int {
+		return strings.Compare(a.path, b.path)
+	})
+
+	var notify_params event.NotifyParams
+	notify_params.Stage = event.StageCompareFiles
+	repo.notify(event.NotifyBeginStage, &notify_params)
+
+	// pair up the files and entries. the pairs are compared afterwards, once the differences can no longer move
+	var jobs []compare_job
+	i, j := 0, 0
+	for i < len(entries) || j < len(files) {
+		var order int
+		if i == len(entries) {
+			order = 1
+		} else if j == len(files) {
+			order = -1
+		} else {
+			order = strings.Compare(entries[i].path, files[j].path)
+		}
+
+		var (
+			removed, added bool
+			difference     Difference
+		)
+		switch {
+		case order < 0:
+			removed = true
+		case order > 0:
+			added = true
+		default:
+			file_prefix := cas.File
+			if files[j].info.Mode()&os.ModeSymlink != 0 {
+				file_prefix = cas.Link
+			}
+			if entries[i].entry.Prefix != file_prefix {
+				// a change in kind is a removal followed by an addition
+				removed = true
+				added = true
+				break
+			}
+			difference.Path = files[j].path
+			difference.Prefix1 = entries[i].entry.Prefix
+			difference.Object1 = entries[i].entry.Content
+			difference.Mode1 = entries[i].entry.Mode
+			difference.Prefix2 = file_prefix
+			jobs = append(jobs, compare_job{file: &files[j], entry: &entries[i], index: len(differences)})
+			differences = append(differences, difference)
+			i++
+			j++
+			continue
+		}
+
+		if removed {
+			err = repo.diff_one_side(entries[i].entry, entries[i].path, DiffRemoved, &differences)
+			if err != nil {
+				return
+			}
+			i++
+		}
+		if added {
+			var added_difference Difference
+			added_difference.Status = DiffAdded
+			added_difference.Path = files[j].path
+			added_difference.Prefix2 = cas.File
+			if files[j].info.Mode()&os.ModeSymlink != 0 {
+				added_difference.Prefix2 = cas.Link
+			} else {
+				added_difference.Mode2, _ = o.file_mode(files[j].info)
+			}
+			differences = append(differences, added_difference)
+			j++
+		}
+	}
+
+	// hashing files is slow, so it is done by several workers
+	var (
+		job_queue = make(chan *compare_job)
+		workers   sync.WaitGroup
+		guard     sync.Mutex
+	)
+	num_workers := o.workers
+	if num_workers <= 0 {
+		num_workers = runtime.NumCPU()
+	}
+	for range num_workers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range job_queue {
+				if job_err := repo.compare_file(&o, job); job_err != nil {
+					guard.Lock()
+					if err == nil {
+						err = job_err
+					}
+					guard.Unlock()
+				}
+			}
+		}()
+	}
+	for k := range jobs {
+		jobs[k].difference = &differences[jobs[k].index]
+		job_queue <- &jobs[k]
+	}
+	close(job_queue)
+	workers.Wait()
+
+	notify_params.Success = err == nil
+	repo.notify(event.NotifyCompleteStage, &notify_params)
+	return
+}
@@ -22,6 +22,45 @@ type Config struct {
 	UUID uuid.UUID `json:"uuid"`
 	// URL pointing to the original location of the repository
 	Origin string `json:"origin,omitempty"`
+	// If true, every object is verified in full before it is used, and corrupted objects are reported
+	Paranoid bool `json:"paranoid,omitempty"`
+	// Object directories of other repositories, which are consulted (read-only) for objects missing from this one.
+	// Relative paths are relative to the repository directory.
+	Alternates []string `json:"alternates,omitempty"`
+	// Chunkers chooses the chunker for added files by their path in the index.
+	// The first rule with a matching pattern is used, unless a chunker is chosen when adding the file.
+	Chunkers []ChunkerRule `json:"chunkers,omitempty"`
+	// Chunking controls where files are split into parts. It is chosen when the repository is initialized.
+	// If nil, the defaults from the multipart package are used.
+	Chunking *Chunking `json:"chunking,omitempty"`
+	// HTTP controls how an HTTP origin is accessed. If nil, the defaults from the remote package are used.
+	HTTP *HTTP `json:"http,omitempty"`
+}
+
+// HTTP holds the parameters of requests made to an HTTP origin. Zero values are replaced by the defaults.
+type HTTP struct {
+	// Seconds to wait for a response to begin, or for more of it to arrive, before the request is abandoned
+	Timeout int `json:"timeout,omitempty"`
+	// How many times a failed request is sent again. A negative number disables retries
+	Retries int `json:"retries,omitempty"`
+	// The most requests that may be in flight at once
+	MaxRequests int `json:"max_requests,omitempty"`
+}
+
+// Chunking holds the parameters of the content-defined chunker, see multipart.ChunkingParams
+type Chunking struct {
+	MinSize     int64 `json:"min_size"`
+	AverageSize int64 `json:"average_size"`
+	MaxSize     int64 `json:"max_size"`
+	// In hexadecimal
+	Polynomial string `json:"polynomial"`
+}
+
+// A ChunkerRule forces files matching a pathspec pattern (e.g. "*.mpq") to be split with a specific chunker
+type ChunkerRule struct {
+	Pattern string `json:"pattern"`
+	// The name of a chunker registered with multipart.Register (e.g. "generic", "single", "mpq")
+	Chunker string `json:"chunker"`
 }
 
 // ReadConfig reads a config at the filename
@@ -0,0 +1,285 @@
+package repo
+
+import (
+	"strings"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/repo/revision"
+)
+
+// A DiffStatus describes how a path differs between two trees
+type DiffStatus uint8
+
+const (
+	// the path only exists in the second tree
+	DiffAdded DiffStatus = 1 << iota
+	// the path only exists in the first tree
+	DiffRemoved
+	// the content of the path has changed
+	DiffModified
+	// the mode of the file has changed
+	DiffModeChanged
+)
+
+// String returns the status in the style of --name-status: A, D, M, X, or MX
+func (status DiffStatus) String() (s string) {
+	if status&DiffAdded != 0 {
+		s += "A"
+	}
+	if status&DiffRemoved != 0 {
+		s += "D"
+	}
+	if status&DiffModified != 0 {
+		s += "M"
+	}
+	if status&DiffModeChanged != 0 {
+		s += "X"
+	}
+	return
+}
+
+// A Difference is a path which is not the same in two trees
+type Difference struct {
+	Status DiffStatus
+	// The path, relative to the root of both trees
+	Path string
+	// The prefix of the entry in each tree (cas.File or cas.Link), or zero if absent
+	Prefix1, Prefix2 cas.Prefix
+	// The object at the path in each tree, or cas.Nil if absent
+	Object1, Object2 cas.ContentID
+	// The mode of the file in each tree
+	Mode1, Mode2 revision.FileMode
+	// For modified files, the number of parts in the second file that were already present in the first
+	SharedParts int
+	// For modified files, the number of parts in the second file that were not present in the first
+	NewParts int
+}
+
+// returns the tree, or an empty tree if hash is cas.Nil
+func (repo *Repository) diff_tree(hash cas.ContentID) (tree *revision.Tree, err error) {
+	if hash == cas.Nil {
+		tree = new(revision.Tree)
+		return
+	}
+	tree, err = repo.load_tree(hash)
+	return
+}
+
+// returns true if the file modes (including recorded POSIX permissions) are not the same
+func diff_mode_changed(entry1, entry2 *revision.TreeEntry) bool {
+	if entry1.Mode != entry2.Mode {
+		return true
+	}
+	perm1, ok1 := entry1.Metadata.Permissions(entry1.Mode)
+	perm2, ok2 := entry2.Metadata.Permissions(entry2.Mode)
+	return ok1 && ok2 && perm1 != perm2
+}
+
+// counts the parts of file2 that are shared with file1
+func (repo *Repository) diff_parts(difference *Difference) (err error) {
+	var (
+		file1, file2 []byte
+	)
+	if _, file1, err = repo.objects.Load(difference.Object1); err != nil {
+		return
+	}
+	if _, file2, err = repo.objects.Load(difference.Object2); err != nil {
+		return
+	}
+
+	parts1 := make(map[cas.ContentID]struct{}, len(file1)/cas.ContentIDSize)
+	var part_id cas.ContentID
+	for len(file1) > 0 {
+		copy(part_id[:], file1[:cas.ContentIDSize])
+		file1 = file1[cas.ContentIDSize:]
+		parts1[part_id] = struct{}{}
+	}
+
+	for len(file2) > 0 {
+		copy(part_id[:], file2[:cas.ContentIDSize])
+		file2 = file2[cas.ContentIDSize:]
+		if _, shared := parts1[part_id]; shared {
+			difference.SharedParts++
+		} else {
+			difference.NewParts++
+		}
+	}
+	return
+}
+
+// reports every file beneath a tree entry as added or removed
+func (repo *Repository) diff_one_side(entry *revision.TreeEntry, path string, status DiffStatus, differences *[]Difference) (err error) {
+	if entry.Prefix == cas.Tree {
+		var tree *revision.Tree
+		if tree, err = repo.load_tree(entry.Content); err != nil {
+			return
+		}
+		for i := range tree.Entries {
+			child := &tree.Entries[i]
+			if err = repo.diff_one_side(child, path+"/"+child.Name, status, differences); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	var difference Difference
+	difference.Status = status
+	difference.Path = path
+	if status == DiffAdded {
+		difference.Prefix2 = entry.Prefix
+		difference.Object2 = entry.Content
+		difference.Mode2 = entry.Mode
+	} else {
+		difference.Prefix1 = entry.Prefix
+		difference.Object1 = entry.Content
+		difference.Mode1 = entry.Mode
+	}
+	*differences = append(*differences, difference)
+	return
+}
+
+func (repo *Repository) diff_trees(tree_hash1, tree_hash2 cas.ContentID, path string, differences *[]Difference) (err error) {
+	// identical subtrees can be skipped without loading them
+	if tree_hash1 == tree_hash2 {
+		return
+	}
+
+	var tree1, tree2 *revision.Tree
+	if tree1, err = repo.diff_tree(tree_hash1); err != nil {
+		return
+	}
+	if tree2, err = repo.diff_tree(tree_hash2); err != nil {
+		return
+	}
+
+	if path != "" {
+		path += "/"
+	}
+
+	// both trees are sorted by name, so they can be merged in one pass
+	i, j := 0, 0
+	for i < len(tree1.Entries) || j < len(tree2.Entries) {
+		var (
+			entry1, entry2 *revision.TreeEntry
+		)
+		if i < len(tree1.Entries) {
+			entry1 = &tree1.Entries[i]
+		}
+		if j < len(tree2.Entries) {
+			entry2 = &tree2.Entries[j]
+		}
+
+		var order int
+		if entry1 == nil {
+			order = 1
+		} else if entry2 == nil {
+			order = -1
+		} else {
+			order = strings.Compare(entry1.Name, entry2.Name)
+		}
+
+		switch {
+		case order < 0:
+			err = repo.diff_one_side(entry1, path+entry1.Name, DiffRemoved, differences)
+			i++
+		case order > 0:
+			err = repo.diff_one_side(entry2, path+entry2.Name, DiffAdded, differences)
+			j++
+		default:
+			err = repo.diff_entries(entry1, entry2, path+entry1.Name, differences)
+			i++
+			j++
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// compares two entries with the same name
+func (repo *Repository) diff_entries(entry1, entry2 *revision.TreeEntry, path string, differences *[]Difference) (err error) {
+	if entry1.Prefix == cas.Tree && entry2.Prefix == cas.Tree {
+		err = repo.diff_trees(entry1.Content, entry2.Content, path, differences)
+		return
+	}
+
+	if entry1.Prefix != entry2.Prefix {
+		// a change in kind (e.g. a file became a directory) is a removal followed by an addition
+		if err = repo.diff_one_side(entry1, path, DiffRemoved, differences); err != nil {
+			return
+		}
+		err = repo.diff_one_side(entry2, path, DiffAdded, differences)
+		return
+	}
+
+	var difference Difference
+	if entry1.Content != entry2.Content {
+		difference.Status |= DiffModified
+	}
+	if diff_mode_changed(entry1, entry2) {
+		difference.Status |= DiffModeChanged
+	}
+	if difference.Status == 0 {
+		return
+	}
+
+	difference.Path = path
+	difference.Prefix1 = entry1.Prefix
+	difference.Prefix2 = entry2.Prefix
+	difference.Object1 = entry1.Content
+	difference.Object2 = entry2.Content
+	difference.Mode1 = entry1.Mode
+	difference.Mode2 = entry2.Mode
+
+	if difference.Status&DiffModified != 0 && entry1.Prefix == cas.File {
+		if err = repo.diff_parts(&difference); err != nil {
+			return
+		}
+	}
+
+	*differences = append(*differences, difference)
+	return
+}
+
+// DiffTrees compares two trees (or the trees of two commits), returning each path that differs between them in tree order.
+// Either hash may be cas.Nil, which stands for an empty tree.
+func (repo *Repository) DiffTrees(a, b cas.ContentID) (differences []Difference, err error) {
+	var tree_hash1, tree_hash2 cas.ContentID
+	if tree_hash1, err = repo.diff_root(a); err != nil {
+		return
+	}
+	if tree_hash2, err = repo.diff_root(b); err != nil {
+		return
+	}
+
+	err = repo.diff_trees(tree_hash1, tree_hash2, "", &differences)
+	return
+}
+
+// resolves a commit to its tree
+func (repo *Repository) diff_root(hash cas.ContentID) (tree_hash cas.ContentID, err error) {
+	if hash == cas.Nil {
+		return
+	}
+
+	var prefix cas.Prefix
+	if prefix, _, err = repo.objects.Load(hash); err != nil {
+		return
+	}
+	switch prefix {
+	case cas.Commit:
+		var commit_info *revision.CommitInfo
+		if _, commit_info, err = repo.check_commit(hash); err != nil {
+			return
+		}
+		tree_hash = commit_info.Tree
+	case cas.Tree:
+		tree_hash = hash
+	default:
+		err = ErrTreeInvalidPrefix
+	}
+	return
+}
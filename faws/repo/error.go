@@ -11,6 +11,8 @@ var (
 	ErrBadCommit                             = fmt.Errorf("faws/repo: bad commit")
 	ErrBadObject                             = fmt.Errorf("faws/repo: bad object")
 	ErrBadRef                                = fmt.Errorf("faws/repo: bad ref")
+	ErrRefNoParent                           = fmt.Errorf("faws/repo: that commit has no parent")
+	ErrRefNoCommitBeforeDate                 = fmt.Errorf("faws/repo: no commit in that history is dated at or before")
 	ErrCommitInvalidPrefix                   = fmt.Errorf("faws/repo: the commit object does not have the appropriate prefix")
 	ErrCommitAuthorNotTrusted                = fmt.Errorf("faws/repo: commit author isn't trusted")
 	ErrBadFilename                           = fmt.Errorf("faws/repo: filename isn't usable by repository hierarchy")
@@ -24,8 +26,14 @@ var (
 	ErrLocalTagNotInRemote                   = fmt.Errorf("faws/repo: refusing to overwrite a tag pointing to a local-only commit, use --force to overwrite anyway")
 	ErrRepoCannotInitializeNonEmptyDirectory = fmt.Errorf("faws/repo: refusing to initialize a Faws repository within a non-empty directory, use -f, --force if you know what you're doing")
 	ErrPullNoOrigin                          = fmt.Errorf("faws/repo: you cannot pull into this repository without a remote origin")
+	ErrPushNoOrigin                          = fmt.Errorf("faws/repo: you cannot push from this repository without a remote origin")
+	ErrPushOriginNotWritable                 = fmt.Errorf("faws/repo: that origin cannot be pushed to")
+	ErrPushRepositoryMismatch                = fmt.Errorf("faws/repo: the origin is a different repository")
+	ErrPushNotFastForward                    = fmt.Errorf("faws/repo: refusing to replace a tag in the origin which points to a commit that is not an ancestor of yours, use --force to replace it anyway")
 	ErrTopicRepositoryMismatch               = fmt.Errorf("faws/repo: the topic does not match the repository")
 	ErrNoPathspec                            = fmt.Errorf("faws/repo: no pathspec was given")
 	ErrNoPathspecMatch                       = fmt.Errorf("faws/repo: that pathspec did not match any file")
 	ErrIndexBadObjectPrefix                  = fmt.Errorf("faws/repo: you cannot add an object with that prefix to the index")
+	ErrBundleExists                          = fmt.Errorf("faws/repo: refusing to write a bundle into a directory which already has one")
+	ErrChunkingObjectsExist                  = fmt.Errorf("faws/repo: refusing to change the chunking parameters of a repository which already has objects, as new files would no longer share parts with old ones")
 )
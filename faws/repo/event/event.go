@@ -37,6 +37,18 @@ const (
 	NotifyPeerObjectDuplicateDownload
 	NotifyVisitObject
 	NotifyVisitQueueCount
+	// ( path string, chunker string, err error )
+	NotifyChunkerFallback
+	// ( path string, file cas.ContentID )
+	NotifyCacheUsedStat
+	// ( prefix cas.Prefix, object cas.ContentID, size int )
+	NotifyPushObject
+	// ( count int )
+	NotifyPushQueueCount
+	// ( tag string, old cas.ContentID, new cas.ContentID )
+	NotifyPushTag
+	// ( path string, err error )
+	NotifyCacheFileFailed
 )
 
 // A Stage represents a phase of operations within the repository, typically one that can take quite a long time.
@@ -48,7 +60,6 @@ const (
 	//
 	StageNone Stage = iota
 	StageCacheFiles
-	StageCacheFile
 	StageWriteTree
 	StagePullTags
 	StagePullObjects
@@ -56,6 +67,8 @@ const (
 	StageServeObjects
 	StageVisitObjects
 	StagePackObjects
+	StageCompareFiles
+	StagePushObjects
 )
 
 // NotifyParams are extra information parameters shared along with the Notification
@@ -80,6 +93,8 @@ type NotifyParams struct {
 	ID identity.ID
 	//
 	MessageID peernet.MessageID
+	// ChunkerFallback, CacheFileFailed
+	Err error
 }
 
 // A NotifyFunc can be supplied to repo.Repository.Open to get notifications about the repository's actions
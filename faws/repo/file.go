@@ -1,17 +1,16 @@
 package repo
 
 import (
-	"bytes"
 	"io"
 
 	"github.com/faws-vcs/faws/faws/repo/cas"
 )
 
 type file_reader struct {
-	repo        *Repository
-	contents    []byte
-	part        bool
-	part_reader bytes.Reader
+	repo     *Repository
+	contents []byte
+	// the part currently being read, if any
+	part io.ReadCloser
 }
 
 func (r *file_reader) Read(b []byte) (n int, err error) {
@@ -22,7 +21,7 @@ func (r *file_reader) Read(b []byte) (n int, err error) {
 	var rn int
 
 	for n < len(b) {
-		if !r.part {
+		if r.part == nil {
 			if len(r.contents) == 0 {
 				err = io.EOF
 				return
@@ -30,31 +29,33 @@ func (r *file_reader) Read(b []byte) (n int, err error) {
 			var part_hash cas.ContentID
 			copy(part_hash[:], r.contents[:cas.ContentIDSize])
 			r.contents = r.contents[cas.ContentIDSize:]
-			var (
-				p cas.Prefix
-				d []byte
-			)
-			p, d, err = r.repo.objects.Load(part_hash)
+			var p cas.Prefix
+			p, r.part, _, err = r.repo.objects.OpenObject(part_hash)
 			if err != nil {
 				return
 			}
 			if p != cas.Part {
+				r.part.Close()
+				r.part = nil
 				err = ErrBadObject
 				return
 			}
-			r.part_reader.Reset(d)
-			r.part = true
-			continue
-		}
-
-		rn, err = r.part_reader.Read(rb[:])
-		if err != nil {
-			r.part = false
 			continue
 		}
 
+		rn, err = r.part.Read(rb)
 		n += rn
 		rb = rb[rn:]
+		if err == io.EOF {
+			err = r.part.Close()
+			r.part = nil
+			if err != nil {
+				return
+			}
+			continue
+		} else if err != nil {
+			return
+		}
 	}
 
 	return
@@ -62,11 +63,15 @@ func (r *file_reader) Read(b []byte) (n int, err error) {
 
 func (r *file_reader) Close() (err error) {
 	r.contents = nil
-	r.part_reader.Reset(nil)
+	if r.part != nil {
+		err = r.part.Close()
+		r.part = nil
+	}
 	return
 }
 
-// OpenFile returns an [io.ReadCloser] for a file in the repository
+// OpenFile returns an [io.ReadCloser] for a file in the repository.
+// Parts are streamed from the cache as the file is read.
 func (repo *Repository) OpenFile(file_hash cas.ContentID) (file io.ReadCloser, err error) {
 	reader := new(file_reader)
 	reader.repo = repo
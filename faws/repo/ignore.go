@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"github.com/faws-vcs/faws/faws/repo/pathspec"
+)
+
+// IgnoreFile is the name of a file listing gitignore-style patterns of paths that [Repository.Add] should skip.
+//
+// It is read from the repository directory, and then from the root of the directory being added,
+// so the patterns in the latter take precedence.
+const IgnoreFile = ".fawsignore"
+
+// reads the ignore files for a source directory
+func (repo *Repository) read_ignore_list(source_root string) (list *pathspec.IgnoreList, err error) {
+	list = new(pathspec.IgnoreList)
+	if err = list.ReadFile(filepath.Join(repo.directory, IgnoreFile)); err != nil {
+		return
+	}
+	err = list.ReadFile(filepath.Join(source_root, IgnoreFile))
+	return
+}
+
+// returns true if the path should not be added. source is a path inside of o.ignore_root
+func (o *staging_options) ignored(source string, is_directory bool) bool {
+	if o.ignore.Empty() {
+		return false
+	}
+	relative, err := filepath.Rel(o.ignore_root, source)
+	if err != nil {
+		return false
+	}
+	return o.ignore.Match(filepath.ToSlash(relative), is_directory)
+}
+
+// IgnoredPaths walks the source directory, returning the paths (relative to source) that [Repository.Add] would ignore.
+// The contents of ignored directories are not listed.
+func (repo *Repository) IgnoredPaths(source string) (paths []string, err error) {
+	var o staging_options
+	o.ignore_root = source
+	o.ignore, err = repo.read_ignore_list(source)
+	if err != nil {
+		return
+	}
+
+	err = filepath.WalkDir(source, func(path string, directory_entry fs.DirEntry, walk_err error) (err error) {
+		if walk_err != nil {
+			err = walk_err
+			return
+		}
+		if path == source {
+			return
+		}
+		if o.ignored(path, directory_entry.IsDir()) {
+			relative, _ := filepath.Rel(source, path)
+			paths = append(paths, filepath.ToSlash(relative))
+			if directory_entry.IsDir() {
+				err = filepath.SkipDir
+			}
+		}
+		return
+	})
+	return
+}
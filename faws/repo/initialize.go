@@ -1,21 +1,89 @@
 package repo
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 
 	"github.com/faws-vcs/faws/faws/app/about"
 	"github.com/faws-vcs/faws/faws/fs"
+	"github.com/faws-vcs/faws/faws/multipart"
+	"github.com/faws-vcs/faws/faws/repo/cas"
 	"github.com/faws-vcs/faws/faws/repo/config"
 	"github.com/faws-vcs/faws/faws/repo/p2p/tracker"
 	"github.com/faws-vcs/faws/faws/repo/remote"
 	"github.com/google/uuid"
 )
 
+type initialize_options struct {
+	set_chunking bool
+	chunking     multipart.ChunkingParams
+	// used to open the origin
+	origin_options []remote.Option
+}
+
+// An InitializeOption sets up a new repository with something other than the defaults
+type InitializeOption func(*initialize_options)
+
+// WithChunkingParams is an [InitializeOption] that sets the parameters used to split files added to the repository.
+//
+// They cannot be changed by reinitializing once the repository has objects.
+func WithChunkingParams(params multipart.ChunkingParams) InitializeOption {
+	return func(o *initialize_options) {
+		o.set_chunking = true
+		o.chunking = params
+	}
+}
+
+// WithOriginOptions is an [InitializeOption] that passes options to [remote.Open] when the origin is opened to read its UUID,
+// such as the credentials needed to access it.
+func WithOriginOptions(options ...remote.Option) InitializeOption {
+	return func(o *initialize_options) {
+		o.origin_options = append(o.origin_options, options...)
+	}
+}
+
+var err_stop_list = errors.New("stop list")
+
+// returns true if there are any objects in the directory
+func objects_exist(objects_directory string) (exist bool, err error) {
+	if _, stat_err := os.Stat(objects_directory); stat_err != nil {
+		return
+	}
+
+	var objects cas.Set
+	if err = objects.Open(objects_directory); err != nil {
+		return
+	}
+	err = objects.List(func(packed bool, id cas.ContentID) (err error) {
+		exist = true
+		// one is enough
+		err = err_stop_list
+		return
+	})
+	if errors.Is(err, err_stop_list) {
+		err = nil
+	}
+	if close_err := objects.Close(); err == nil {
+		err = close_err
+	}
+	return
+}
+
 // Initialize a repository at the directory.
 // if reinitialize == true, you are allowed to refresh an existing repository with updated basics.
 // if origin_url != "", you start to pull repository information from the remote repository at origin_url
-func Initialize(directory string, origin_url string, reinitialize, force bool) (err error) {
+func Initialize(directory string, origin_url string, reinitialize, force bool, options ...InitializeOption) (err error) {
+	var o initialize_options
+	for _, option := range options {
+		option(&o)
+	}
+	if o.set_chunking {
+		if err = o.chunking.Check(); err != nil {
+			return
+		}
+	}
+
 	if Exists(directory) && !reinitialize {
 		err = ErrInitializeCannotExist
 		return
@@ -75,10 +143,11 @@ func Initialize(directory string, origin_url string, reinitialize, force bool) (
 		} else {
 			// treat as nominal origin
 			var origin remote.Origin
-			origin, err = remote.Open(origin_url)
+			origin, err = remote.Open(origin_url, o.origin_options...)
 			if err != nil {
 				return
 			}
+			defer close_origin(origin)
 
 			// read UUID from remote
 			config_.UUID, err = origin.UUID()
@@ -90,6 +159,27 @@ func Initialize(directory string, origin_url string, reinitialize, force bool) (
 		}
 	}
 
+	if o.set_chunking {
+		var current_chunking multipart.ChunkingParams
+		current_chunking, err = config_chunking_params(&config_)
+		if err != nil {
+			return
+		}
+		if current_chunking != o.chunking {
+			// changing where files are split would silently break deduplication with existing parts
+			var exist bool
+			exist, err = objects_exist(filepath.Join(directory, "objects"))
+			if err != nil {
+				return
+			}
+			if exist {
+				err = ErrChunkingObjectsExist
+				return
+			}
+		}
+		config_.Chunking = chunking_config(o.chunking)
+	}
+
 	err = config.WriteConfig(config_name, &config_)
 	if err != nil {
 		return
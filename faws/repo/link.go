@@ -0,0 +1,23 @@
+package repo
+
+import (
+	"github.com/faws-vcs/faws/faws/repo/cas"
+)
+
+// Link returns the target of a symbolic link object
+func (repo *Repository) Link(link_hash cas.ContentID) (target string, err error) {
+	var (
+		prefix cas.Prefix
+		object []byte
+	)
+	prefix, object, err = repo.objects.Load(link_hash)
+	if err != nil {
+		return
+	}
+	if prefix != cas.Link {
+		err = ErrBadObject
+		return
+	}
+	target = string(object)
+	return
+}
@@ -1,6 +1,8 @@
 package repo
 
 import (
+	"io"
+
 	"github.com/faws-vcs/faws/faws/repo/cas"
 )
 
@@ -10,6 +12,12 @@ func (repo *Repository) LoadObject(id cas.ContentID) (prefix cas.Prefix, object
 	return
 }
 
+// OpenObject returns a reader that streams an object from the cache. The reader must be closed.
+func (repo *Repository) OpenObject(id cas.ContentID) (prefix cas.Prefix, content io.ReadCloser, size int64, err error) {
+	prefix, content, size, err = repo.objects.OpenObject(id)
+	return
+}
+
 // LoadObject adds an object to the cache
 func (repo *Repository) StoreObject(prefix cas.Prefix, data []byte) (new bool, id cas.ContentID, err error) {
 	new, id, err = repo.objects.Store(prefix, data)
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"io"
+	"time"
+
+	"github.com/faws-vcs/faws/faws/repo/remote"
+)
+
+// WithCredentials is an [Option] that authenticates requests to the origin with the credentials returned by fn.
+// Credentials are looked up when the origin is opened, and are never written into the repository's config.
+func WithCredentials(fn remote.CredentialsFunc) Option {
+	return func(repo *Repository) {
+		repo.credentials = fn
+	}
+}
+
+// returns the options used to open the origin, as configured
+func (repo *Repository) origin_options() (options []remote.Option, err error) {
+	if repo.trust != nil {
+		// the publisher of a bundle is trusted the same way as the authors of commits
+		options = append(options, remote.WithTrust(repo.trust.Check))
+	}
+	if repo.credentials != nil {
+		var credentials remote.Credentials
+		if credentials, err = repo.credentials(repo.config.Origin); err != nil {
+			return
+		}
+		options = append(options, remote.WithCredentials(credentials))
+	}
+
+	http_config := repo.config.HTTP
+	if http_config == nil {
+		return
+	}
+	if http_config.Timeout > 0 {
+		options = append(options, remote.WithTimeout(time.Duration(http_config.Timeout)*time.Second))
+	}
+	if http_config.Retries != 0 {
+		options = append(options, remote.WithRetries(max(http_config.Retries, 0)))
+	}
+	if http_config.MaxRequests > 0 {
+		options = append(options, remote.WithMaxRequests(http_config.MaxRequests))
+	}
+	return
+}
+
+// opens the origin of the repository
+func (repo *Repository) open_origin() (origin remote.Origin, err error) {
+	var options []remote.Option
+	if options, err = repo.origin_options(); err != nil {
+		return
+	}
+	origin, err = remote.Open(repo.config.Origin, options...)
+	return
+}
+
+// releases any resources held by an origin, such as the pack of a bundle
+func close_origin(origin remote.Origin) {
+	if closer, ok := origin.(io.Closer); ok {
+		closer.Close()
+	}
+}
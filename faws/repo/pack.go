@@ -1,9 +1,13 @@
 package repo
 
 import (
+	"os"
+	"path/filepath"
+
 	"github.com/faws-vcs/faws/faws/repo/cas"
 	"github.com/faws-vcs/faws/faws/repo/event"
 	"github.com/faws-vcs/faws/faws/repo/queue"
+	"github.com/faws-vcs/faws/faws/validate"
 )
 
 type object_pack_job struct {
@@ -21,8 +25,28 @@ func (o object_pack_job) Less(than object_pack_job) bool {
 	return o.id.Less(than.id)
 }
 
-// Pack bundles together all objects of the repository into a named pack.
-func (repo *Repository) Pack(name string, max_archive_size int64) (err error) {
+// Pack bundles together objects of the repository into a named pack.
+//
+// If incremental == false, every reachable object is written to a pack at the path name, which is not loaded into the repository.
+// If incremental == true, only the reachable objects in the cache are written to a new pack, which is then loaded into the repository alongside
+// its existing packs. The cached copies of those objects are removed.
+// Options are passed along to the [cas.PackWriter], and can be used to enable delta compression with [cas.WithDeltas].
+func (repo *Repository) Pack(name string, max_archive_size int64, incremental bool, options ...cas.PackWriterOption) (err error) {
+	pack_name := name
+	var temporary_directory string
+	if incremental {
+		if err = validate.PackName(name); err != nil {
+			return
+		}
+		// write the pack somewhere it can't collide with repository files before moving it into the object set
+		temporary_directory, err = os.MkdirTemp(repo.directory, "pack-")
+		if err != nil {
+			return
+		}
+		defer os.RemoveAll(temporary_directory)
+		pack_name = filepath.Join(temporary_directory, name)
+	}
+
 	// gather a list of unreachable objects. These won't be included in the newly packed version of the repository
 	var vq visitor_queue
 	vq.init()
@@ -32,15 +56,48 @@ func (repo *Repository) Pack(name string, max_archive_size int64) (err error) {
 	}
 
 	var writer cas.PackWriter
-	if err = writer.Open(name, max_archive_size); err != nil {
+	if err = writer.Open(pack_name, max_archive_size, options...); err != nil {
+		return
+	}
+
+	var packed_objects []cas.ContentID
+	packed_objects, err = repo.pack_visited_objects(&writer, &vq, incremental)
+	vq.destroy()
+	if err != nil {
 		return
 	}
 
+	err = writer.Close()
+	if err != nil {
+		return
+	}
+
+	if incremental {
+		if err = repo.objects.AddPack(pack_name, false); err != nil {
+			return
+		}
+
+		// the objects are now safely in the pack, so the loose copies can go
+		for _, object_hash := range packed_objects {
+			if err = repo.objects.Remove(object_hash); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// stores each object in the visitor queue into the pack writer, largest first. if skip_packed == true, objects that are already packed are left out
+func (repo *Repository) pack_visited_objects(writer *cas.PackWriter, vq *visitor_queue, skip_packed bool) (packed_objects []cas.ContentID, err error) {
 	// load all object ids and sort descending by size
 	var object_list queue.OrderedSet[object_pack_job]
 	object_list.Init()
 
 	if err = repo.objects.List(func(packed bool, id cas.ContentID) (err error) {
+		if skip_packed && packed {
+			// already in a pack
+			return
+		}
 		var (
 			size int64
 		)
@@ -61,7 +118,19 @@ func (repo *Repository) Pack(name string, max_archive_size int64) (err error) {
 		return
 	}
 
-	vq.destroy()
+	// objects which are only found in an alternate, if they were tracked
+	for _, id := range vq.alternate_objects {
+		var size int64
+		size, err = repo.objects.Stat(id)
+		if err != nil {
+			return
+		}
+		var job object_pack_job
+		job.id = id
+		job.size = uint32(size)
+		job.include = true
+		object_list.Push(job)
+	}
 
 	var pack_objects event.NotifyParams
 	pack_objects.Stage = event.StagePackObjects
@@ -72,6 +141,9 @@ func (repo *Repository) Pack(name string, max_archive_size int64) (err error) {
 		if !popped {
 			break
 		}
+		if !object.include {
+			continue
+		}
 		var (
 			prefix  cas.Prefix
 			content []byte
@@ -80,23 +152,14 @@ func (repo *Repository) Pack(name string, max_archive_size int64) (err error) {
 		if err != nil {
 			break
 		}
-		if object.include {
-			_, _, err = writer.Store(prefix, content)
-			if err != nil {
-				break
-			}
+		_, _, err = writer.Store(prefix, content)
+		if err != nil {
+			break
 		}
+		packed_objects = append(packed_objects, object.id)
 	}
 
 	pack_objects.Success = err == nil
 	repo.notify(event.NotifyCompleteStage, &pack_objects)
-	if err != nil {
-		return
-	}
-
-	err = writer.Close()
-	if err != nil {
-		return
-	}
 	return
 }
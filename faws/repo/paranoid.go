@@ -0,0 +1,26 @@
+package repo
+
+import (
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/repo/event"
+)
+
+// WithParanoid is an [Option] that verifies every object in full before it is used, as if "paranoid" were set in the repository's config.
+// Corrupted objects generate [event.NotifyCorruptedObject] and cause the operation that encountered them to fail.
+func WithParanoid(paranoid bool) Option {
+	return func(repo *Repository) {
+		repo.paranoid = paranoid
+	}
+}
+
+// returns true if objects are verified in full whenever they are read
+func (repo *Repository) is_paranoid() bool {
+	return repo.paranoid || repo.config.Paranoid
+}
+
+func (repo *Repository) notify_corrupted_object(prefix cas.Prefix, id cas.ContentID) {
+	var notify_params event.NotifyParams
+	notify_params.Prefix = prefix
+	notify_params.Object1 = id
+	repo.notify(event.NotifyCorruptedObject, &notify_params)
+}
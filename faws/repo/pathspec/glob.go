@@ -0,0 +1,94 @@
+package pathspec
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// a gitignore-style glob, split on '/':
+//
+//	*.log     '*' matches anything except '/'
+//	file?     '?' matches any one character except '/'
+//	img[0-9]  a class matches one character in it. [!0-9] and [^0-9] match one character outside of it
+//	a/**/b    a whole '**' segment matches any number of directories
+//	\*        '\' matches the next character literally
+type glob struct {
+	segments []string
+}
+
+func compile_glob(pattern string) (g glob, err error) {
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment == "**" {
+			// consecutive ** are the same as one
+			if len(g.segments) > 0 && g.segments[len(g.segments)-1] == "**" {
+				continue
+			}
+			g.segments = append(g.segments, segment)
+			continue
+		}
+		segment = negate_classes(segment)
+		// path.Match only reports a malformed pattern when it gets that far, so check against an empty name
+		if _, err = path.Match(segment, ""); err != nil {
+			err = fmt.Errorf("%w: %s", err, pattern)
+			return
+		}
+		g.segments = append(g.segments, segment)
+	}
+	return
+}
+
+// git negates a character class with '!', path.Match only with '^'
+func negate_classes(segment string) string {
+	var builder strings.Builder
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		builder.WriteByte(c)
+		switch c {
+		case '\\':
+			if i+1 < len(segment) {
+				i++
+				builder.WriteByte(segment[i])
+			}
+		case '[':
+			if i+1 < len(segment) && segment[i+1] == '!' {
+				i++
+				builder.WriteByte('^')
+			}
+		}
+	}
+	return builder.String()
+}
+
+// returns true if the glob matches all of name, a path separated by '/'
+func (g glob) match(name string) bool {
+	return match_segments(g.segments, strings.Split(name, "/"))
+}
+
+func match_segments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			if len(rest) == 0 {
+				// a trailing ** matches everything inside, but not the directory itself
+				return len(name) > 0
+			}
+			for i := range len(name) + 1 {
+				if match_segments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
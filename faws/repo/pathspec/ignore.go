@@ -0,0 +1,155 @@
+package pathspec
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// an ignore pattern
+type ignore_rule struct {
+	glob glob
+	// the pattern began with '!', matching paths are no longer ignored
+	negate bool
+	// the pattern ended with '/', it only matches directories (and their contents)
+	directory_only bool
+	// the pattern had no '/', so it matches the last element of a path, at any depth
+	base_name bool
+}
+
+// An IgnoreList decides which paths are ignored, using gitignore-style patterns:
+//
+//	# comment
+//	*.log         ignore any file or directory named *.log, at any depth
+//	/build        ignore build, only at the root
+//	cache/        ignore directories named cache, at any depth
+//	!keep.log     don't ignore keep.log, even though it matched *.log
+//	img[0-9]?.png ignore img00.png through img9z.png, at any depth
+//	doc/*.pdf     ignore the PDFs directly inside doc, but not doc/a/b.pdf
+//	**/tmp        ignore tmp, at any depth
+//	doc/**/*.pdf  ignore the PDFs anywhere inside doc
+//
+// '*' and '?' never match '/'. Patterns are tested in order, and the last pattern that matches a path decides whether it is ignored.
+// Everything inside an ignored directory is ignored, and can't be un-ignored.
+// Paths are relative to the directory being walked, and separated by forward slashes.
+type IgnoreList struct {
+	rules []ignore_rule
+}
+
+// Add appends a single pattern to the list. Empty patterns and comments are skipped.
+func (list *IgnoreList) Add(pattern string) (err error) {
+	// trailing spaces are ignored unless escaped
+	if !strings.HasSuffix(pattern, "\\ ") {
+		pattern = strings.TrimRight(pattern, " \t\r")
+	}
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return
+	}
+
+	var rule ignore_rule
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, "\\!") || strings.HasPrefix(pattern, "\\#") {
+		pattern = pattern[1:]
+	}
+	pattern = strings.ReplaceAll(pattern, "\\ ", " ")
+
+	if strings.HasSuffix(pattern, "/") {
+		rule.directory_only = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if strings.Contains(pattern, "/") {
+		// anchored to the root of the walk
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		rule.base_name = true
+	}
+	if pattern == "" {
+		return
+	}
+
+	rule.glob, err = compile_glob(pattern)
+	if err != nil {
+		return
+	}
+	list.rules = append(list.rules, rule)
+	return
+}
+
+// Parse adds every line of an ignore file to the list
+func (list *IgnoreList) Parse(data []byte) (err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if err = list.Add(scanner.Text()); err != nil {
+			return
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// ReadFile adds the patterns from an ignore file to the list. It is not an error for the file to be missing.
+func (list *IgnoreList) ReadFile(name string) (err error) {
+	var data []byte
+	data, err = os.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	err = list.Parse(data)
+	return
+}
+
+// Empty returns true if the list has no patterns
+func (list *IgnoreList) Empty() bool {
+	return list == nil || len(list.rules) == 0
+}
+
+// Match returns true if the path is ignored. is_directory should be true if the path names a directory.
+//
+// Walking should not descend into an ignored directory, as nothing inside of it can be un-ignored.
+func (list *IgnoreList) Match(path string, is_directory bool) (ignored bool) {
+	if list == nil {
+		return
+	}
+
+	// a path inside of an ignored directory is ignored
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && list.match_rules(path[:i], true) {
+			ignored = true
+			return
+		}
+	}
+	ignored = list.match_rules(path, is_directory)
+	return
+}
+
+// returns true if the last rule that matches the path ignores it
+func (list *IgnoreList) match_rules(path string, is_directory bool) (ignored bool) {
+	base_name := path
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		base_name = path[i+1:]
+	}
+
+	for _, rule := range list.rules {
+		if rule.directory_only && !is_directory {
+			continue
+		}
+		var matched bool
+		if rule.base_name {
+			matched = rule.glob.match(base_name)
+		} else {
+			matched = rule.glob.match(path)
+		}
+		if matched {
+			ignored = !rule.negate
+		}
+	}
+	return
+}
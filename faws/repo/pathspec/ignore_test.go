@@ -0,0 +1,87 @@
+package pathspec
+
+import "testing"
+
+type ignore_test_case struct {
+	path         string
+	is_directory bool
+	ignored      bool
+}
+
+const test_ignore_file = `# comments and blank lines are skipped
+
+.DS_Store
+*.log
+!keep.log
+cache/
+/build
+shaders/*.bin
+\#notes
+file?.txt
+img[0-9].png
+[!a]x
+**/foo
+doc/**/*.pdf
+out/**
+`
+
+var ignore_test_cases = []ignore_test_case{
+	{".DS_Store", false, true},
+	{"a/b/.DS_Store", false, true},
+	{"crash.log", false, true},
+	{"logs/crash.log", false, true},
+	{"logs/keep.log", false, false},
+	{"cache", true, true},
+	{"a/cache", true, true},
+	{"cache", false, false},
+	{"build", true, true},
+	{"build", false, true},
+	{"a/build", true, false},
+	{"shaders/x.bin", false, true},
+	{"a/shaders/x.bin", false, false},
+	{"#notes", false, true},
+	{"notes", false, false},
+	{"data/file.txt", false, false},
+	// '*' doesn't cross directories
+	{"shaders/a/b.bin", false, false},
+	// the contents of an ignored directory are ignored
+	{"cache/x.txt", false, true},
+	{"build/a/b", false, true},
+	{"file1.txt", false, true},
+	{"a/fileZ.txt", false, true},
+	{"file10.txt", false, false},
+	{"img3.png", false, true},
+	{"imga.png", false, false},
+	{"bx", false, true},
+	{"ax", false, false},
+	// ** matches no directories at all, or many
+	{"foo", false, true},
+	{"a/b/foo", true, true},
+	{"doc/x.pdf", false, true},
+	{"doc/a/b/x.pdf", false, true},
+	{"x.pdf", false, false},
+	// a trailing ** matches what's inside, not the directory itself
+	{"out", true, false},
+	{"out/a/b", false, true},
+}
+
+func TestIgnoreListBadPattern(t *testing.T) {
+	var list IgnoreList
+	if err := list.Add("a[b"); err == nil {
+		t.Fatal("a malformed class should not be accepted")
+	}
+}
+
+func TestIgnoreList(t *testing.T) {
+	var list IgnoreList
+	if err := list.Parse([]byte(test_ignore_file)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test_case := range ignore_test_cases {
+		was := list.Match(test_case.path, test_case.is_directory)
+		if was != test_case.ignored {
+			t.Fatalf("test case %d (%s): ignored should have been %t, was instead %t", i, test_case.path, test_case.ignored, was)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 	"runtime"
+	"sync"
 
 	"github.com/faws-vcs/faws/faws/repo/cas"
 	"github.com/faws-vcs/faws/faws/repo/event"
@@ -13,6 +14,10 @@ import (
 
 type visitor_queue struct {
 	object_queue queue.TaskQueue[cas.ContentID]
+	// if true, reachable objects that are only found in an alternate are collected
+	track_alternates  bool
+	guard             sync.Mutex
+	alternate_objects []cas.ContentID
 }
 
 func (vq *visitor_queue) init() {
@@ -46,6 +51,12 @@ func (repo *Repository) visitor_worker(vq *visitor_queue) (err error) {
 			break
 		}
 
+		if vq.track_alternates && !repo.objects.Local(object_hash) {
+			vq.guard.Lock()
+			vq.alternate_objects = append(vq.alternate_objects, object_hash)
+			vq.guard.Unlock()
+		}
+
 		switch prefix {
 		case cas.Commit:
 			var (
@@ -124,6 +135,12 @@ func (repo *Repository) visit_all_objects(vq *visitor_queue) (err error) {
 		vq.object_queue.Push(tag.CommitHash)
 	}
 
+	err = repo.visit_objects(vq)
+	return
+}
+
+// visits every object in the queue, and every object reachable from them
+func (repo *Repository) visit_objects(vq *visitor_queue) (err error) {
 	// notify the CLI/GUI/remote client/whatever that we're starting to pull objects
 	var visiting_objects event.NotifyParams
 	visiting_objects.Stage = event.StageVisitObjects
@@ -154,7 +171,8 @@ func (repo *Repository) visit_all_objects(vq *visitor_queue) (err error) {
 	return
 }
 
-// Prune removes all unpacked objects in the repository that cannot be visited
+// Prune removes all unpacked objects in the repository that cannot be visited.
+// Objects in alternates are not local to the repository, so they are never removed.
 func (repo *Repository) PruneCache() (err error) {
 	var vq visitor_queue
 	vq.init()
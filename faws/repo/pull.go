@@ -31,38 +31,41 @@ import (
 func (repo *Repository) fetch_object(origin remote.Origin, object_hash cas.ContentID) (prefix cas.Prefix, data []byte, err error) {
 	prefix, data, err = repo.objects.Load(object_hash)
 	if err != nil && errors.Is(err, cas.ErrObjectNotFound) {
-		var (
-			received_object_hash cas.ContentID
-		)
-
 		prefix, data, err = origin.GetObject(object_hash)
 		if err != nil {
 			return
 		}
 
-		// attempt to store data
-		// also get the hash
-		_, received_object_hash, err = repo.objects.Store(prefix, data)
-		if err != nil {
-			return
-		}
+		err = repo.store_pulled_object(object_hash, prefix, data)
+	} else if err != nil {
+		return
+	}
 
-		if received_object_hash != object_hash {
-			// if the hash isn't correct just delete it
-			repo.objects.Remove(received_object_hash)
-			err = fmt.Errorf("%w: remote object %s does not match its hash", ErrBadObject, object_hash)
-			return
-		}
+	return
+}
 
-		var notify_params event.NotifyParams
-		notify_params.Prefix = prefix
-		notify_params.Object1 = object_hash
-		notify_params.Count = int64(len(data))
-		repo.notify(event.NotifyPullObject, &notify_params)
-	} else if err != nil {
+// stores an object downloaded from the remote, making sure it is the object that was asked for
+func (repo *Repository) store_pulled_object(object_hash cas.ContentID, prefix cas.Prefix, data []byte) (err error) {
+	// attempt to store data
+	// also get the hash
+	var received_object_hash cas.ContentID
+	_, received_object_hash, err = repo.objects.Store(prefix, data)
+	if err != nil {
+		return
+	}
+
+	if received_object_hash != object_hash {
+		// if the hash isn't correct just delete it
+		repo.objects.Remove(received_object_hash)
+		err = fmt.Errorf("%w: remote object %s does not match its hash", ErrBadObject, object_hash)
 		return
 	}
 
+	var notify_params event.NotifyParams
+	notify_params.Prefix = prefix
+	notify_params.Object1 = object_hash
+	notify_params.Count = int64(len(data))
+	repo.notify(event.NotifyPullObject, &notify_params)
 	return
 }
 
@@ -138,10 +141,11 @@ func (repo *Repository) Clone() (err error) {
 	}
 
 	var origin remote.Origin
-	origin, err = remote.Open(repo.config.Origin)
+	origin, err = repo.open_origin()
 	if err != nil {
 		return
 	}
+	defer close_origin(origin)
 
 	var pull_tags_stage event.NotifyParams
 	pull_tags_stage.Stage = event.StagePullTags
@@ -191,6 +195,8 @@ func (repo *Repository) Clone() (err error) {
 }
 
 // Pull only objects associated with a tag or an abbreviated object hash
+//
+// Objects already in the repository are not downloaded again, so a pull that was interrupted resumes where it left off when it is run again.
 func (repo *Repository) Pull(ref ...string) (err error) {
 	if repo.config.Origin == "" {
 		err = ErrPullNoOrigin
@@ -202,10 +208,11 @@ func (repo *Repository) Pull(ref ...string) (err error) {
 		return
 	}
 
-	origin, err := remote.Open(repo.config.Origin)
+	origin, err := repo.open_origin()
 	if err != nil {
 		return
 	}
+	defer close_origin(origin)
 
 	objects := make([]cas.ContentID, len(ref))
 	for i := range ref {
@@ -235,10 +242,11 @@ func (repo *Repository) PullTags() (err error) {
 	}
 
 	var origin remote.Origin
-	origin, err = remote.Open(repo.config.Origin)
+	origin, err = repo.open_origin()
 	if err != nil {
 		return
 	}
+	defer close_origin(origin)
 
 	// begin to pull tags
 	var notify_pull_tags event.NotifyParams
@@ -295,10 +303,11 @@ func (repo *Repository) PullTag(tags ...string) (err error) {
 	}
 
 	var origin remote.Origin
-	origin, err = remote.Open(repo.config.Origin)
+	origin, err = repo.open_origin()
 	if err != nil {
 		return
 	}
+	defer close_origin(origin)
 
 	// begin to pull tags
 	var notify_pull_tags event.NotifyParams
@@ -12,6 +12,9 @@ import (
 	"github.com/faws-vcs/faws/faws/repo/revision"
 )
 
+// the most parts of a file downloaded in a single request, from an origin that can send many objects at once
+const pull_batch_size = 64
+
 type pull_queue struct {
 	object_lock  sync.Mutex
 	object_queue queue.TaskQueue[cas.ContentID]
@@ -79,19 +82,36 @@ loop:
 				pq.object_queue.Push(entry.Content)
 			}
 		case cas.File:
-			var part_id cas.ContentID
+			var (
+				part_id       cas.ContentID
+				missing_parts []cas.ContentID
+			)
 			file_data := object
 			for len(file_data) > 0 {
 				copy(part_id[:], file_data[:cas.ContentIDSize])
 
 				// only download file parts we don't have
 				if _, err = repo.objects.Stat(part_id); err != nil {
-					pq.object_queue.Push(part_id)
+					missing_parts = append(missing_parts, part_id)
 					err = nil
 				}
 
 				file_data = file_data[cas.ContentIDSize:]
 			}
+
+			// parts refer to nothing, so if the origin can, they are downloaded here in batches instead of one at a time by the queue
+			if batch_origin, is_batch := origin.(remote.BatchOrigin); is_batch {
+				for len(missing_parts) > 0 {
+					batch := missing_parts[:min(len(missing_parts), pull_batch_size)]
+					missing_parts = missing_parts[len(batch):]
+					if err = batch_origin.GetObjects(batch, repo.store_pulled_object); err != nil {
+						break loop
+					}
+				}
+			}
+			for _, part_id := range missing_parts {
+				pq.object_queue.Push(part_id)
+			}
 		case cas.Part:
 			// raw data, nothing to do except complete task
 		}
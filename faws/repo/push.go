@@ -0,0 +1,315 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/repo/event"
+	"github.com/faws-vcs/faws/faws/repo/p2p/tracker"
+	"github.com/faws-vcs/faws/faws/repo/remote"
+	"github.com/faws-vcs/faws/faws/repo/revision"
+)
+
+// the most objects asked about in a single call to HasObjects
+const push_has_objects_batch_size = 4096
+
+// a tag to be pushed
+type push_tag struct {
+	name string
+	// the commit the remote tag points to before the push, or cas.Nil
+	remote_commit cas.ContentID
+	local_commit  cas.ContentID
+}
+
+// returns the objects referred to by an object
+func (repo *Repository) object_children(object_hash cas.ContentID) (children []cas.ContentID, err error) {
+	var (
+		prefix cas.Prefix
+		object []byte
+	)
+	if prefix, object, err = repo.objects.Load(object_hash); err != nil {
+		return
+	}
+
+	switch prefix {
+	case cas.Commit:
+		var (
+			commit      revision.Commit
+			commit_info revision.CommitInfo
+		)
+		if err = revision.UnmarshalCommit(object, &commit); err != nil {
+			return
+		}
+		if err = revision.UnmarshalCommitInfo(commit.Info, &commit_info); err != nil {
+			return
+		}
+		children = append(children, commit_info.Tree)
+		if commit_info.Parent != cas.Nil {
+			children = append(children, commit_info.Parent)
+		}
+	case cas.Tree:
+		var tree revision.Tree
+		if err = revision.UnmarshalTree(object, &tree); err != nil {
+			return
+		}
+		for _, entry := range tree.Entries {
+			children = append(children, entry.Content)
+		}
+	case cas.File:
+		children = make([]cas.ContentID, len(object)/cas.ContentIDSize)
+		for i := range children {
+			copy(children[i][:], object[i*cas.ContentIDSize:])
+		}
+	}
+	return
+}
+
+// asks the origin which objects it has, a batch at a time
+func has_objects(origin remote.WritableOrigin, object_hashes []cas.ContentID) (has []bool, err error) {
+	has = make([]bool, 0, len(object_hashes))
+	for len(object_hashes) > 0 {
+		batch := object_hashes[:min(len(object_hashes), push_has_objects_batch_size)]
+		object_hashes = object_hashes[len(batch):]
+
+		var batch_has []bool
+		if batch_has, err = origin.HasObjects(batch); err != nil {
+			return
+		}
+		has = append(has, batch_has...)
+	}
+	return
+}
+
+// finds the objects reachable from the commits which the origin is missing, in an order where every object
+// comes after the objects it refers to.
+//
+// Objects are always pushed in that order, so if the origin has an object, it is assumed to have every object beneath it.
+func (repo *Repository) missing_objects(origin remote.WritableOrigin, commits []cas.ContentID) (missing []cas.ContentID, err error) {
+	var (
+		seen     object_hash_set
+		frontier []cas.ContentID
+		// the missing objects beneath each missing object
+		children = make(map[cas.ContentID][]cas.ContentID)
+		// the missing objects, as they were found
+		found []cas.ContentID
+	)
+	seen.Init()
+	for _, commit_hash := range commits {
+		if seen.Push(commit_hash) {
+			frontier = append(frontier, commit_hash)
+		}
+	}
+
+	// walk the graph a level at a time, so that the origin can be asked about a whole level at once
+	for len(frontier) > 0 {
+		var has []bool
+		if has, err = has_objects(origin, frontier); err != nil {
+			return
+		}
+
+		var next []cas.ContentID
+		for i, object_hash := range frontier {
+			if has[i] {
+				continue
+			}
+			found = append(found, object_hash)
+
+			var object_children []cas.ContentID
+			if object_children, err = repo.object_children(object_hash); err != nil {
+				return
+			}
+			children[object_hash] = object_children
+			for _, child := range object_children {
+				if seen.Push(child) {
+					next = append(next, child)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	// children first
+	var (
+		ordered object_hash_set
+		visit   func(object_hash cas.ContentID)
+	)
+	ordered.Init()
+	visit = func(object_hash cas.ContentID) {
+		object_children, is_missing := children[object_hash]
+		if !is_missing || !ordered.Push(object_hash) {
+			return
+		}
+		for _, child := range object_children {
+			visit(child)
+		}
+		missing = append(missing, object_hash)
+	}
+	for _, object_hash := range found {
+		visit(object_hash)
+	}
+	return
+}
+
+// uploads the objects in order
+func (repo *Repository) push_objects(origin remote.WritableOrigin, objects []cas.ContentID) (err error) {
+	var notify_count event.NotifyParams
+	notify_count.Count = int64(len(objects))
+	repo.notify(event.NotifyPushQueueCount, &notify_count)
+
+	for _, object_hash := range objects {
+		var (
+			prefix cas.Prefix
+			object []byte
+		)
+		if prefix, object, err = repo.objects.Load(object_hash); err != nil {
+			return
+		}
+		if err = origin.PutObject(prefix, object); err != nil {
+			return
+		}
+
+		var notify_push_object event.NotifyParams
+		notify_push_object.Prefix = prefix
+		notify_push_object.Object1 = object_hash
+		notify_push_object.Count = int64(len(object))
+		repo.notify(event.NotifyPushObject, &notify_push_object)
+	}
+	return
+}
+
+// returns true if ancestor_hash is commit_hash, or one of its ancestors
+func (repo *Repository) is_ancestor(ancestor_hash, commit_hash cas.ContentID) (is_ancestor bool, err error) {
+	for commit_hash != cas.Nil {
+		if commit_hash == ancestor_hash {
+			is_ancestor = true
+			return
+		}
+		var info *revision.CommitInfo
+		if _, info, err = repo.check_commit(commit_hash); err != nil {
+			return
+		}
+		commit_hash = info.Parent
+	}
+	return
+}
+
+// Push uploads the commits named by tags, and every object beneath them which the origin is missing, then updates the tags in the origin.
+// If no tags are given, every tag is pushed.
+//
+// A tag in the origin is only replaced if it points to an ancestor of the local commit, unless force is true.
+// Tags are written last, and only if nobody else has changed them in the meantime, so readers of the origin never see a tag
+// pointing to a commit whose objects are missing.
+//
+// Only an origin in a local directory can be pushed to. Origins served over HTTP are read-only, and pushing to one fails with
+// ErrPushOriginNotWritable.
+func (repo *Repository) Push(force bool, tags ...string) (err error) {
+	if repo.config.Origin == "" {
+		err = ErrPushNoOrigin
+		return
+	}
+	if tracker.IsTopicURI(repo.config.Origin) {
+		err = fmt.Errorf("%w: %s", ErrPushOriginNotWritable, repo.config.Origin)
+		return
+	}
+
+	var origin remote.Origin
+	if origin, err = repo.open_origin(); err != nil {
+		return
+	}
+	writable_origin, is_writable := origin.(remote.WritableOrigin)
+	if !is_writable {
+		close_origin(origin)
+		err = fmt.Errorf("%w: %s", ErrPushOriginNotWritable, repo.config.Origin)
+		return
+	}
+	defer func() {
+		if close_err := writable_origin.Close(); err == nil {
+			err = close_err
+		}
+	}()
+
+	// make sure we aren't about to fill someone else's repository with our objects
+	origin_uuid, err := writable_origin.UUID()
+	if err != nil {
+		return
+	}
+	if origin_uuid != repo.config.UUID {
+		err = fmt.Errorf("%w: %s", ErrPushRepositoryMismatch, origin_uuid)
+		return
+	}
+
+	if len(tags) == 0 {
+		var local_tags []revision.Tag
+		if local_tags, err = repo.Tags(); err != nil {
+			return
+		}
+		for _, tag := range local_tags {
+			tags = append(tags, tag.Name)
+		}
+	}
+
+	var (
+		push_tags []push_tag
+		commits   []cas.ContentID
+	)
+	for _, tag := range tags {
+		var pt push_tag
+		pt.name = tag
+		if pt.local_commit, err = repo.read_tag(tag); err != nil {
+			err = fmt.Errorf("%w: %s", err, tag)
+			return
+		}
+		// a tag that doesn't exist in the origin is simply created
+		pt.remote_commit, err = writable_origin.ReadTag(tag)
+		if errors.Is(err, remote.ErrTagNotFound) {
+			err = nil
+		} else if err != nil {
+			return
+		}
+		if pt.remote_commit == pt.local_commit {
+			continue
+		}
+		if pt.remote_commit != cas.Nil && !force {
+			var fast_forward bool
+			if fast_forward, err = repo.is_ancestor(pt.remote_commit, pt.local_commit); err != nil {
+				return
+			}
+			if !fast_forward {
+				err = fmt.Errorf("%w: %s", ErrPushNotFastForward, tag)
+				return
+			}
+		}
+		push_tags = append(push_tags, pt)
+		commits = append(commits, pt.local_commit)
+	}
+
+	var push_stage event.NotifyParams
+	push_stage.Stage = event.StagePushObjects
+	repo.notify(event.NotifyBeginStage, &push_stage)
+
+	var missing []cas.ContentID
+	if missing, err = repo.missing_objects(writable_origin, commits); err == nil {
+		err = repo.push_objects(writable_origin, missing)
+	}
+
+	push_stage.Success = err == nil
+	repo.notify(event.NotifyCompleteStage, &push_stage)
+	if err != nil {
+		return
+	}
+
+	// every object is in place, so the tags can finally be moved
+	for _, pt := range push_tags {
+		if err = writable_origin.WriteTag(pt.name, pt.remote_commit, pt.local_commit); err != nil {
+			return
+		}
+
+		var notify_push_tag event.NotifyParams
+		notify_push_tag.Name1 = pt.name
+		notify_push_tag.Object1 = pt.remote_commit
+		notify_push_tag.Object2 = pt.local_commit
+		repo.notify(event.NotifyPushTag, &notify_push_tag)
+	}
+	return
+}
@@ -3,8 +3,13 @@ package repo
 import (
 	"encoding/hex"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/repo/revision"
+	"github.com/faws-vcs/faws/faws/timestamp"
 	"github.com/faws-vcs/faws/faws/validate"
 )
 
@@ -12,8 +17,98 @@ var (
 	ErrRefNotFound = fmt.Errorf("faws/repo: ref not found")
 )
 
-// ParseRef returns a hash from a string, which may be either an [abbreviated] hexadecimal object hash, or a commit tag
+// the characters which begin a suffix of a ref. none of them are allowed in a tag
+const ref_suffix_characters = "~^@:"
+
+// ParseRef returns a hash from a string, which may be either an [abbreviated] hexadecimal object hash, or a commit tag,
+// optionally followed by any number of these suffixes:
+//
+//	~N        the Nth ancestor of the commit (~ alone is the parent)
+//	^         the parent of the commit
+//	@{date}   the newest commit at or before the date (see [timestamp.Parse]), among the commit and its ancestors
+//	:path     the file, link or tree at the path within the commit (or tree). this must be the last suffix
+//
+// For example, "v1.2~3:data/maps" is the tree named data/maps, three commits before the tag v1.2.
 func (repo *Repository) ParseRef(ref string) (hash cas.ContentID, err error) {
+	var path string
+	has_path := false
+	if i := strings.IndexByte(ref, ':'); i >= 0 {
+		path = ref[i+1:]
+		has_path = true
+		ref = ref[:i]
+	}
+
+	name := ref
+	if i := strings.IndexAny(ref, ref_suffix_characters); i >= 0 {
+		name = ref[:i]
+		ref = ref[i:]
+	} else {
+		ref = ""
+	}
+
+	if name == "" {
+		err = ErrBadRef
+		return
+	}
+	if hash, err = repo.parse_ref_name(name); err != nil {
+		return
+	}
+
+	for ref != "" {
+		switch ref[0] {
+		case '~':
+			ref = ref[1:]
+			// the number of generations may be omitted
+			digits := len(ref) - len(strings.TrimLeft(ref, "0123456789"))
+			generations := 1
+			if digits > 0 {
+				if generations, err = strconv.Atoi(ref[:digits]); err != nil {
+					err = fmt.Errorf("%w: %w", ErrBadRef, err)
+					return
+				}
+				ref = ref[digits:]
+			}
+			if hash, err = repo.ancestor(hash, generations); err != nil {
+				return
+			}
+		case '^':
+			ref = ref[1:]
+			if hash, err = repo.ancestor(hash, 1); err != nil {
+				return
+			}
+		case '@':
+			if !strings.HasPrefix(ref, "@{") {
+				err = fmt.Errorf("%w: expected '{' after '@'", ErrBadRef)
+				return
+			}
+			end := strings.IndexByte(ref, '}')
+			if end < 0 {
+				err = fmt.Errorf("%w: expected '}' after date", ErrBadRef)
+				return
+			}
+			var date int64
+			if date, err = timestamp.Parse(ref[2:end]); err != nil {
+				err = fmt.Errorf("%w: %w", ErrBadRef, err)
+				return
+			}
+			ref = ref[end+1:]
+			if hash, err = repo.ancestor_before(hash, date); err != nil {
+				return
+			}
+		default:
+			err = fmt.Errorf("%w: unexpected '%c'", ErrBadRef, ref[0])
+			return
+		}
+	}
+
+	if has_path {
+		hash, err = repo.tree_path(hash, path)
+	}
+	return
+}
+
+// returns the hash of a tag or an [abbreviated] hexadecimal object hash
+func (repo *Repository) parse_ref_name(ref string) (hash cas.ContentID, err error) {
 	ref_is_valid_hex := validate.Hex(ref)
 
 	// abbreviated hashes
@@ -43,3 +138,66 @@ parse_hex:
 	hash, err = repo.objects.Deabbreviate(ref)
 	return
 }
+
+// follows the parents of a commit back the given number of generations
+func (repo *Repository) ancestor(commit_hash cas.ContentID, generations int) (hash cas.ContentID, err error) {
+	hash = commit_hash
+	for range generations {
+		var info *revision.CommitInfo
+		if _, info, err = repo.check_commit(hash); err != nil {
+			return
+		}
+		if info.Parent == cas.Nil {
+			err = fmt.Errorf("%w: %s", ErrRefNoParent, hash)
+			return
+		}
+		hash = info.Parent
+	}
+	return
+}
+
+// returns the newest commit among a commit and its ancestors whose tree date is at or before date
+func (repo *Repository) ancestor_before(commit_hash cas.ContentID, date int64) (hash cas.ContentID, err error) {
+	hash = commit_hash
+	for hash != cas.Nil {
+		var info *revision.CommitInfo
+		if _, info, err = repo.check_commit(hash); err != nil {
+			return
+		}
+		if info.TreeDate <= date {
+			return
+		}
+		hash = info.Parent
+	}
+	err = fmt.Errorf("%w: %s", ErrRefNoCommitBeforeDate, timestamp.Format(date))
+	return
+}
+
+// returns the object at a slash-separated path within a commit or tree. an empty path is the tree itself
+func (repo *Repository) tree_path(object_hash cas.ContentID, path string) (hash cas.ContentID, err error) {
+	if hash, err = repo.diff_root(object_hash); err != nil {
+		return
+	}
+
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return
+	}
+	names := strings.Split(path, "/")
+	for i, name := range names {
+		var tree *revision.Tree
+		if tree, err = repo.load_tree(hash); err != nil {
+			return
+		}
+		entry_index := slices.IndexFunc(tree.Entries, func(entry revision.TreeEntry) bool {
+			return entry.Name == name
+		})
+		// only trees can be descended into
+		if entry_index < 0 || (i < len(names)-1 && tree.Entries[entry_index].Prefix != cas.Tree) {
+			err = fmt.Errorf("%w: %s", ErrTreeFileNotFound, path)
+			return
+		}
+		hash = tree.Entries[entry_index].Content
+	}
+	return
+}
@@ -0,0 +1,101 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/faws-vcs/faws/faws/identity"
+	"github.com/faws-vcs/faws/faws/validate"
+	"github.com/google/uuid"
+)
+
+// A bundle is a snapshot of a repository's tags, which can be cloned and pulled from without the repository itself.
+// It is a directory which holds a pack of the objects reachable from the tags, and a manifest of the tags signed by the
+// one who created the bundle:
+//
+//	manifest        BundleManifestName
+//	objects         the pack index (BundlePackName)
+//	objects.000000  the archives of the pack
+const (
+	BundleManifestName = "manifest"
+	BundlePackName     = "objects"
+)
+
+// BundleTag is a tag in a bundle
+type BundleTag struct {
+	Name string `json:"name"`
+	// The commit hash, in hexadecimal
+	Commit string `json:"commit"`
+}
+
+// BundleInfo is the signed portion of a bundle's manifest
+type BundleInfo struct {
+	// The date the bundle was created in unix seconds
+	Date int64 `json:"date"`
+	// The UUID of the repository the bundle came from
+	UUID uuid.UUID `json:"uuid"`
+	// The name of the pack index in the bundle directory
+	Pack string `json:"pack"`
+	// The number of archives in the pack
+	Archives int `json:"archives"`
+	// The publisher's attributes
+	PublisherAttributes identity.Attributes `json:"publisher_attributes"`
+	// Sorted by name
+	Tags []BundleTag `json:"tags"`
+}
+
+// EncodeBundleManifest signs info, producing the manifest of a bundle. It is the publisher's ID and signature, followed by the info as JSON
+func EncodeBundleManifest(signing_identity *identity.Pair, info *BundleInfo) (manifest []byte, err error) {
+	var info_data []byte
+	if info_data, err = json.Marshal(info); err != nil {
+		return
+	}
+
+	var signature identity.Signature
+	identity.Sign(signing_identity, info_data, &signature)
+	publisher := signing_identity.ID()
+
+	manifest = make([]byte, 0, identity.IDSize+identity.SignatureSize+len(info_data))
+	manifest = append(manifest, publisher[:]...)
+	manifest = append(manifest, signature[:]...)
+	manifest = append(manifest, info_data...)
+	return
+}
+
+// DecodeBundleManifest verifies the signature of a bundle's manifest, and decodes its info
+func DecodeBundleManifest(manifest []byte, info *BundleInfo) (publisher identity.ID, err error) {
+	if len(manifest) < identity.IDSize+identity.SignatureSize {
+		err = ErrBundleManifestMalformed
+		return
+	}
+	var signature identity.Signature
+	copy(publisher[:], manifest[:identity.IDSize])
+	copy(signature[:], manifest[identity.IDSize:])
+	info_data := manifest[identity.IDSize+identity.SignatureSize:]
+
+	if !identity.Verify(publisher, &signature, info_data) {
+		err = fmt.Errorf("%w: from %s", ErrBundleManifestSignature, publisher)
+		return
+	}
+
+	if err = json.Unmarshal(info_data, info); err != nil {
+		err = fmt.Errorf("%w: %w", ErrBundleManifestMalformed, err)
+		return
+	}
+	// the pack name becomes a path, so it mustn't be able to escape the bundle
+	if validate.PackName(info.Pack) != nil || info.Archives < 0 {
+		err = ErrBundleManifestMalformed
+		return
+	}
+	for _, tag := range info.Tags {
+		if err = validate.CommitTag(tag.Name); err != nil {
+			err = fmt.Errorf("%w: %w", ErrBundleManifestMalformed, err)
+			return
+		}
+		if _, err = parse_content_id(tag.Commit); err != nil {
+			err = fmt.Errorf("%w: %w", ErrBundleManifestMalformed, err)
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,257 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/faws-vcs/faws/faws/fs"
+	"github.com/faws-vcs/faws/faws/identity"
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/google/uuid"
+)
+
+// the query parameter of a bundle URI which names the publisher the manifest must be signed by
+const bundle_publisher_parameter = "publisher"
+
+// bundle_origin is a bundle, opened directly from its pack without importing it into a repository.
+// A bundle at a URL is downloaded into a temporary directory first.
+//
+// The manifest must be signed by the publisher named in the URI, if there is one. The URI of an open bundle always names its publisher,
+// so a repository that was cloned from a bundle refuses to pull from one that was signed by anyone else.
+type bundle_origin struct {
+	// the URI without the publisher
+	uri url.URL
+	// the identity which signed the manifest. before the bundle is opened, the one named by the URI, or identity.Nobody
+	publisher identity.ID
+	trust     TrustFunc
+	// the directory holding the bundle
+	directory string
+	// if true, directory is temporary and removed when the origin is closed
+	temporary bool
+	info      BundleInfo
+	tags      map[string]cas.ContentID
+	pack      cas.Pack
+}
+
+func (bundle_origin *bundle_origin) URI() (uri string) {
+	u := bundle_origin.uri
+	query := u.Query()
+	query.Set(bundle_publisher_parameter, bundle_origin.publisher.String())
+	u.RawQuery = query.Encode()
+	uri = u.String()
+	return
+}
+
+func (bundle_origin *bundle_origin) UUID() (id uuid.UUID, err error) {
+	id = bundle_origin.info.UUID
+	return
+}
+
+func (bundle_origin *bundle_origin) Tags() (tags []string, err error) {
+	for name := range bundle_origin.tags {
+		tags = append(tags, name)
+	}
+	slices.Sort(tags)
+	return
+}
+
+func (bundle_origin *bundle_origin) ReadTag(name string) (commit_hash cas.ContentID, err error) {
+	commit_hash, found := bundle_origin.tags[name]
+	if !found {
+		err = fmt.Errorf("%w: %s", ErrTagNotFound, name)
+	}
+	return
+}
+
+func (bundle_origin *bundle_origin) GetObject(object_hash cas.ContentID) (prefix cas.Prefix, data []byte, err error) {
+	prefix, data, err = bundle_origin.pack.Load(object_hash)
+	return
+}
+
+func (bundle_origin *bundle_origin) Deabbreviate(abbreviation string) (object_hash cas.ContentID, err error) {
+	object_hash, err = bundle_origin.pack.Deabbreviate(abbreviation)
+	return
+}
+
+func (bundle_origin *bundle_origin) Close() (err error) {
+	err = bundle_origin.pack.Close()
+	if bundle_origin.temporary {
+		os.RemoveAll(bundle_origin.directory)
+	}
+	return
+}
+
+// reads the manifest, then opens the pack
+func (bundle_origin *bundle_origin) open() (err error) {
+	var manifest []byte
+	if manifest, err = os.ReadFile(filepath.Join(bundle_origin.directory, BundleManifestName)); err != nil {
+		return
+	}
+	var publisher identity.ID
+	if publisher, err = DecodeBundleManifest(manifest, &bundle_origin.info); err != nil {
+		return
+	}
+	if err = bundle_origin.expect_publisher(publisher); err != nil {
+		return
+	}
+	if bundle_origin.trust != nil && !bundle_origin.trust(publisher, &bundle_origin.info.PublisherAttributes) {
+		err = fmt.Errorf("%w: %s", ErrBundleNotTrusted, publisher)
+		return
+	}
+	bundle_origin.publisher = publisher
+
+	bundle_origin.tags = make(map[string]cas.ContentID, len(bundle_origin.info.Tags))
+	for _, tag := range bundle_origin.info.Tags {
+		// the manifest has already been checked
+		bundle_origin.tags[tag.Name], _ = parse_content_id(tag.Commit)
+	}
+
+	err = bundle_origin.pack.OpenReadOnly(filepath.Join(bundle_origin.directory, bundle_origin.info.Pack))
+	return
+}
+
+// refuses a manifest signed by anyone other than the publisher named by the URI
+func (bundle_origin *bundle_origin) expect_publisher(publisher identity.ID) (err error) {
+	if bundle_origin.publisher != identity.Nobody && publisher != bundle_origin.publisher {
+		err = fmt.Errorf("%w: signed by %s, expected %s", ErrBundlePublisher, publisher, bundle_origin.publisher)
+	}
+	return
+}
+
+// downloads a file of a bundle at a URL into the temporary directory
+func (bundle_origin *bundle_origin) download(client *http_client, base_url *url.URL, name string) (err error) {
+	u := *base_url
+	u.Path += name
+
+	var (
+		request  *http.Request
+		response *http.Response
+	)
+	if request, err = http.NewRequest("GET", u.String(), nil); err != nil {
+		return
+	}
+	if response, err = client.open(request); err != nil {
+		return
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		err = fmt.Errorf("faws/repo/remote: server returned %s for %s", response.Status, name)
+		return
+	}
+
+	var file *os.File
+	if file, err = os.OpenFile(filepath.Join(bundle_origin.directory, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, fs.DefaultPrivatePerm); err != nil {
+		return
+	}
+	_, err = io.Copy(file, response.Body)
+	if close_err := file.Close(); err == nil {
+		err = close_err
+	}
+	return
+}
+
+// downloads the manifest, followed by the pack it names
+func (bundle_origin *bundle_origin) download_bundle(base_url *url.URL, o *options) (err error) {
+	var client *http_client
+	if client, err = new_http_client(o); err != nil {
+		return
+	}
+
+	if err = bundle_origin.download(client, base_url, BundleManifestName); err != nil {
+		return
+	}
+	var manifest []byte
+	if manifest, err = os.ReadFile(filepath.Join(bundle_origin.directory, BundleManifestName)); err != nil {
+		return
+	}
+	var (
+		info      BundleInfo
+		publisher identity.ID
+	)
+	if publisher, err = DecodeBundleManifest(manifest, &info); err != nil {
+		return
+	}
+	// don't bother downloading the pack of the wrong bundle
+	if err = bundle_origin.expect_publisher(publisher); err != nil {
+		return
+	}
+
+	if err = bundle_origin.download(client, base_url, info.Pack); err != nil {
+		return
+	}
+	for archive_id := range info.Archives {
+		if err = bundle_origin.download(client, base_url, fmt.Sprintf("%s.%06d", info.Pack, archive_id)); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// opens a bundle in a local directory, or at a URL if the scheme is bundle+http or bundle+https
+func open_bundle(bundle_url *url.URL, o *options) (origin Origin, err error) {
+	bundle_origin_ := new(bundle_origin)
+	bundle_origin_.trust = o.trust
+
+	// the publisher is not part of the location of the bundle
+	query := bundle_url.Query()
+	if publisher := query.Get(bundle_publisher_parameter); publisher != "" {
+		if bundle_origin_.publisher, err = identity.Parse(publisher); err != nil {
+			err = fmt.Errorf("faws/repo/remote: bad bundle publisher '%s': %w", publisher, err)
+			return
+		}
+		query.Del(bundle_publisher_parameter)
+		bundle_url.RawQuery = query.Encode()
+	}
+
+	if scheme, is_http := strings.CutPrefix(bundle_url.Scheme, "bundle+"); is_http {
+		download_url := *bundle_url
+		download_url.Scheme = scheme
+		website_o := *o
+		strip_password(&download_url, &website_o.credentials)
+		if !strings.HasSuffix(download_url.Path, "/") {
+			download_url.Path += "/"
+		}
+		bundle_origin_.uri = download_url
+		bundle_origin_.uri.Scheme = bundle_url.Scheme
+
+		if bundle_origin_.directory, err = os.MkdirTemp("", "faws-bundle-"); err != nil {
+			return
+		}
+		bundle_origin_.temporary = true
+		if err = bundle_origin_.download_bundle(&download_url, &website_o); err != nil {
+			os.RemoveAll(bundle_origin_.directory)
+			return
+		}
+	} else {
+		directory := bundle_url.Path
+		if bundle_url.Opaque != "" {
+			// bundle:relative/path
+			directory = bundle_url.Opaque
+		}
+		if directory, err = filepath.Abs(directory); err != nil {
+			return
+		}
+		bundle_origin_.directory = directory
+		bundle_origin_.uri.Scheme = "bundle"
+		bundle_origin_.uri.Path = filepath.ToSlash(directory)
+	}
+
+	if err = bundle_origin_.open(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = fmt.Errorf("%w: %w", ErrBundleNotFound, err)
+		}
+		if bundle_origin_.temporary {
+			os.RemoveAll(bundle_origin_.directory)
+		}
+		return
+	}
+	origin = bundle_origin_
+	return
+}
@@ -0,0 +1,145 @@
+package remote
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/faws-vcs/faws/faws/identity"
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/google/uuid"
+)
+
+func TestBundleManifest(t *testing.T) {
+	pair, err := identity.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info BundleInfo
+	info.Date = 1700000000
+	info.UUID = uuid.New()
+	info.Pack = BundlePackName
+	info.Archives = 2
+	info.Tags = []BundleTag{{Name: "v1", Commit: "00112233445566778899aabbccddeeff00112233"}}
+
+	manifest, err := EncodeBundleManifest(&pair, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded BundleInfo
+	publisher, err := DecodeBundleManifest(manifest, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publisher != pair.ID() {
+		t.Fatalf("publisher is %s, expected %s", publisher, pair.ID())
+	}
+	if decoded.UUID != info.UUID || decoded.Archives != 2 || len(decoded.Tags) != 1 || decoded.Tags[0] != info.Tags[0] {
+		t.Fatal(decoded)
+	}
+
+	// changing the signed info must break the signature
+	manifest[len(manifest)-5] ^= 1
+	if _, err = DecodeBundleManifest(manifest, &decoded); !errors.Is(err, ErrBundleManifestSignature) {
+		t.Fatalf("expected ErrBundleManifestSignature, got %v", err)
+	}
+
+	// a pack name that escapes the bundle directory is refused, even when signed
+	info.Pack = "../objects"
+	if manifest, err = EncodeBundleManifest(&pair, &info); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DecodeBundleManifest(manifest, &decoded); !errors.Is(err, ErrBundleManifestMalformed) {
+		t.Fatalf("expected ErrBundleManifestMalformed, got %v", err)
+	}
+}
+
+// writes a bundle of one part, signed by signing_identity
+func write_test_bundle(t *testing.T, directory string, signing_identity *identity.Pair) {
+	var writer cas.PackWriter
+	if err := writer.Open(filepath.Join(directory, BundlePackName), -1); err != nil {
+		t.Fatal(err)
+	}
+	_, part_hash, err := writer.Store(cas.Part, []byte("the only part"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var info BundleInfo
+	info.UUID = uuid.New()
+	info.Pack = BundlePackName
+	info.Archives = 1
+	info.Tags = []BundleTag{{Name: "v1", Commit: part_hash.String()}}
+	manifest, err := EncodeBundleManifest(signing_identity, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(directory, BundleManifestName), manifest, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundlePublisher(t *testing.T) {
+	publisher, err := identity.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	impostor, err := identity.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := t.TempDir()
+	write_test_bundle(t, directory, &publisher)
+	var bundle_url url.URL
+	bundle_url.Scheme = "bundle"
+	bundle_url.Path = filepath.ToSlash(directory)
+
+	// the URI of an open bundle names its publisher
+	origin, err := Open(bundle_url.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned_uri := origin.URI()
+	origin.(*bundle_origin).Close()
+	pinned_url, err := url.Parse(pinned_uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pinned_url.Query().Get("publisher") != publisher.ID().String() {
+		t.Fatalf("the URI %s doesn't name the publisher", pinned_uri)
+	}
+
+	// the publisher that signed it is trusted
+	origin, err = Open(pinned_uri, WithTrust(func(id identity.ID, signed_attributes *identity.Attributes) bool {
+		return id == publisher.ID()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin.(*bundle_origin).Close()
+
+	// the same bundle re-signed by someone else is refused
+	os.RemoveAll(directory)
+	if err = os.Mkdir(directory, 0755); err != nil {
+		t.Fatal(err)
+	}
+	write_test_bundle(t, directory, &impostor)
+	if _, err = Open(pinned_uri); !errors.Is(err, ErrBundlePublisher) {
+		t.Fatalf("expected ErrBundlePublisher, got %v", err)
+	}
+
+	// without a publisher in the URI, the trust mechanism decides
+	if _, err = Open(bundle_url.String(), WithTrust(func(id identity.ID, signed_attributes *identity.Attributes) bool {
+		return id == publisher.ID()
+	})); !errors.Is(err, ErrBundleNotTrusted) {
+		t.Fatalf("expected ErrBundleNotTrusted, got %v", err)
+	}
+}
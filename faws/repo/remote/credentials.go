@@ -0,0 +1,99 @@
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// Credentials authenticate the requests made to an HTTP origin.
+//
+// They belong to the user, not to a repository, and are never written into a repository's config.
+type Credentials struct {
+	// Sent with every request using basic authentication, unless there is a Token
+	Username string
+	Password string
+	// Sent with every request as "Authorization: Bearer <Token>"
+	Token string
+	// A PEM file of certificate authorities to trust, in addition to those of the system
+	CACertificate string
+	// PEM files of a certificate and its private key, presented to servers which require clients to identify themselves
+	ClientCertificate string
+	ClientKey         string
+}
+
+// A CredentialsFunc returns the credentials to use for an origin, given its URI
+type CredentialsFunc func(uri string) (credentials Credentials, err error)
+
+// WithCredentials is an [Option] that authenticates every request made to an HTTP origin with credentials.
+func WithCredentials(credentials Credentials) Option {
+	return func(o *options) {
+		o.credentials = credentials
+	}
+}
+
+// sets the Authorization header of a request
+func (credentials *Credentials) authorize(request *http.Request) {
+	if credentials.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+credentials.Token)
+	} else if credentials.Username != "" || credentials.Password != "" {
+		request.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+}
+
+// returns the TLS configuration needed for the certificates in the credentials, or nil if there are none
+func (credentials *Credentials) tls_config() (config *tls.Config, err error) {
+	if credentials.CACertificate == "" && credentials.ClientCertificate == "" {
+		return
+	}
+	config = new(tls.Config)
+
+	if credentials.CACertificate != "" {
+		if config.RootCAs, err = x509.SystemCertPool(); err != nil {
+			config.RootCAs = x509.NewCertPool()
+		}
+		var pem []byte
+		if pem, err = os.ReadFile(credentials.CACertificate); err != nil {
+			return
+		}
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			err = fmt.Errorf("%w: no certificates in %s", ErrBadCredentials, credentials.CACertificate)
+			return
+		}
+	}
+
+	if credentials.ClientCertificate != "" {
+		key := credentials.ClientKey
+		if key == "" {
+			// the key may be in the same file as the certificate
+			key = credentials.ClientCertificate
+		}
+		var certificate tls.Certificate
+		if certificate, err = tls.LoadX509KeyPair(credentials.ClientCertificate, key); err != nil {
+			err = fmt.Errorf("%w: %w", ErrBadCredentials, err)
+			return
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return
+}
+
+// removes the password from a URL, so that it can be shown or stored.
+// a username and password in the URL are used as credentials, if there are no others
+func strip_password(u *url.URL, credentials *Credentials) {
+	if u.User == nil {
+		return
+	}
+	password, has_password := u.User.Password()
+	if has_password && credentials.Username == "" && credentials.Password == "" && credentials.Token == "" {
+		credentials.Username = u.User.Username()
+		credentials.Password = password
+	}
+	u.User = url.User(u.User.Username())
+	if u.User.Username() == "" {
+		u.User = nil
+	}
+}
@@ -0,0 +1,70 @@
+package remote
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCredentials(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if r.Header.Get("Authorization") != "Bearer t0ken" && !(ok && username == "alice" && password == "s3cret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/tags/":
+			w.Write([]byte(`<html><body><a href="v1">v1</a></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// trust the server's self-signed certificate
+	ca_certificate := filepath.Join(t.TempDir(), "ca.pem")
+	ca_pem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(ca_certificate, ca_pem, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var credentials Credentials
+	credentials.CACertificate = ca_certificate
+	if _, err := Open(server.URL); err == nil {
+		t.Fatal("opened a server with an untrusted certificate")
+	}
+
+	origin, err := Open(server.URL, WithCredentials(credentials))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = origin.Tags(); err == nil {
+		t.Fatal("read tags without authorization")
+	}
+
+	credentials.Token = "t0ken"
+	if origin, err = Open(server.URL, WithCredentials(credentials)); err != nil {
+		t.Fatal(err)
+	}
+	if tags, err := origin.Tags(); err != nil || len(tags) != 1 {
+		t.Fatal(tags, err)
+	}
+
+	// a password in the URL is used, but never shown
+	credentials.Token = ""
+	uri := strings.Replace(server.URL, "https://", "https://alice:s3cret@", 1)
+	if origin, err = Open(uri, WithCredentials(credentials)); err != nil {
+		t.Fatal(err)
+	}
+	if tags, err := origin.Tags(); err != nil || len(tags) != 1 {
+		t.Fatal(tags, err)
+	}
+	if strings.Contains(origin.URI(), "s3cret") {
+		t.Fatal(origin.URI())
+	}
+}
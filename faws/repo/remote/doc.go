@@ -1,2 +1,2 @@
-// Package remote implements remote filesystem interfaces, which are used for pulling objects from remote repositories
+// Package remote implements remote filesystem interfaces, which are used for pulling objects from (and pushing objects to) remote repositories
 package remote
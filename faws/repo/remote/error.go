@@ -0,0 +1,17 @@
+package remote
+
+import "fmt"
+
+var (
+	ErrUnsupportedScheme = fmt.Errorf("faws/repo/remote: unsupported URI scheme")
+	ErrTagChanged        = fmt.Errorf("faws/repo/remote: the tag was changed by someone else")
+	ErrTagLocked         = fmt.Errorf("faws/repo/remote: the tag is being written by someone else")
+	ErrBadCredentials    = fmt.Errorf("faws/repo/remote: the credentials could not be loaded")
+	ErrTagNotFound       = fmt.Errorf("faws/repo/remote: the tag does not exist in the origin")
+
+	ErrBundleNotFound          = fmt.Errorf("faws/repo/remote: there is no bundle at that location")
+	ErrBundleManifestMalformed = fmt.Errorf("faws/repo/remote: the bundle manifest is malformed")
+	ErrBundleManifestSignature = fmt.Errorf("faws/repo/remote: the bundle manifest has a bad signature")
+	ErrBundlePublisher         = fmt.Errorf("faws/repo/remote: the bundle was published by someone else")
+	ErrBundleNotTrusted        = fmt.Errorf("faws/repo/remote: the bundle publisher isn't trusted")
+)
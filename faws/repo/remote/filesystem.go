@@ -1,6 +1,7 @@
 package remote
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
@@ -36,7 +37,7 @@ func is_uri(name string) (is_uri bool) {
 	}
 
 	switch scheme {
-	case "http", "https", "topic":
+	case "file", "http", "https", "topic", "bundle", "bundle+http", "bundle+https":
 		is_uri = true
 		// case "git+https://"
 		// todo: implement git host free-riding
@@ -50,9 +51,14 @@ func is_uri(name string) (is_uri bool) {
 }
 
 // Open opens a remote Origin using a named local directory or URI
-func Open(name string) (origin Origin, err error) {
+func Open(name string, options ...Option) (origin Origin, err error) {
+	o := default_options()
+	for _, option := range options {
+		option(&o)
+	}
+
 	if is_uri(name) {
-		origin, err = open_uri(name)
+		origin, err = open_uri(name, &o)
 		return
 	}
 
@@ -60,7 +66,7 @@ func Open(name string) (origin Origin, err error) {
 	return
 }
 
-func open_uri(uri string) (origin Origin, err error) {
+func open_uri(uri string, o *options) (origin Origin, err error) {
 	scheme, _, was_cut := strings.Cut(uri, ":")
 	if !was_cut {
 		return
@@ -80,9 +86,32 @@ func open_uri(uri string) (origin Origin, err error) {
 		if err != nil {
 			return
 		}
-		origin, err = open_filesystem_website(website_url)
+		// the password must not end up in the URI of the origin, which is stored in the config
+		website_o := *o
+		strip_password(website_url, &website_o.credentials)
+		o = &website_o
+		// prefer the smart protocol, if the server is "faws serve". otherwise, it's an ordinary website
+		var smart_origin_ *smart_origin
+		smart_origin_, err = open_smart_origin(website_url, o)
+		if err == nil {
+			origin = smart_origin_
+			return
+		}
+		// there's no point asking again if the server couldn't be reached at all
+		var url_error *url.Error
+		if errors.As(err, &url_error) {
+			return
+		}
+		origin, err = open_filesystem_website(website_url, o)
+	case "bundle", "bundle+http", "bundle+https":
+		var bundle_url *url.URL
+		bundle_url, err = url.Parse(uri)
+		if err != nil {
+			return
+		}
+		origin, err = open_bundle(bundle_url, o)
 	default:
-		err = fmt.Errorf("%w: %s", scheme)
+		err = fmt.Errorf("%w: %s", ErrUnsupportedScheme, scheme)
 	}
 	return
 }
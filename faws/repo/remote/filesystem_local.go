@@ -42,9 +42,14 @@ func (filesystem_local *filesystem_local) ReadDir(name string) (entries []dir_en
 
 func (filesystem_local *filesystem_local) Stat(name string) (size int64, err error) {
 	var (
-		fi os.FileInfo
+		path string
+		fi   os.FileInfo
 	)
-	fi, err = os.Stat(name)
+	path, err = filesystem_local.path(name)
+	if err != nil {
+		return
+	}
+	fi, err = os.Stat(path)
 	if err != nil {
 		err = os.ErrNotExist
 		return
@@ -91,6 +96,9 @@ func open_filesystem_local(name string) (origin Origin, err error) {
 	filesystem_local_ := new(filesystem_local)
 	filesystem_local_.name = name
 
-	origin = filesystem_origin{filesystem_local_}
+	local_origin_ := new(local_origin)
+	local_origin_.filesystem_origin = filesystem_origin{filesystem_local_}
+	local_origin_.directory = name
+	origin = local_origin_
 	return
 }
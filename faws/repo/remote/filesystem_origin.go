@@ -3,8 +3,10 @@ package remote
 import (
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"strings"
 
 	"github.com/faws-vcs/faws/faws/repo/cas"
@@ -71,6 +73,9 @@ func (fs_origin filesystem_origin) ReadTag(name string) (commit_hash cas.Content
 	)
 	file, err = fs_origin.filesystem.Pull("tags/" + name)
 	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = fmt.Errorf("%w: %s", ErrTagNotFound, name)
+		}
 		return
 	}
 	if _, err = io.ReadFull(file, commit_hash[:]); err != nil {
@@ -112,6 +117,7 @@ func (fs_origin filesystem_origin) GetObject(object_hash cas.ContentID) (prefix
 	case cas.Tree:
 	case cas.File:
 	case cas.Part:
+	case cas.Link:
 	default:
 		err = cas.ErrObjectCorrupted
 		return
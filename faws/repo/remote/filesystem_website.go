@@ -1,19 +1,19 @@
 package remote
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 
-	"github.com/faws-vcs/faws/faws/app/about"
 	"golang.org/x/net/html"
 )
 
 type filesystem_website struct {
 	base_url url.URL
-	client   http.Client
+	client   *http_client
 }
 
 func (filesystem_website *filesystem_website) url(name string) (url string, err error) {
@@ -96,9 +96,7 @@ func (filesystem_website *filesystem_website) Stat(name string) (size int64, err
 		return
 	}
 
-	request.Header.Set("User-Agent", fmt.Sprintf("Faws/%s", about.GetVersionString()))
-
-	response, err = filesystem_website.client.Do(request)
+	response, _, err = filesystem_website.client.fetch(request)
 	if err != nil {
 		return
 	}
@@ -106,12 +104,12 @@ func (filesystem_website *filesystem_website) Stat(name string) (size int64, err
 		err = fmt.Errorf("faws/repo/remote: server returned %d %s", response.StatusCode, response.Status)
 		return
 	}
-	response.Body.Close()
 	size = response.ContentLength
 
 	return
 }
 
+// Pull reads the whole file before returning, so that a download which is cut short can be retried
 func (filesystem_website *filesystem_website) Pull(name string) (file io.ReadCloser, err error) {
 	var url string
 	url, err = filesystem_website.url(name)
@@ -122,15 +120,14 @@ func (filesystem_website *filesystem_website) Pull(name string) (file io.ReadClo
 	var (
 		request  *http.Request
 		response *http.Response
+		body     []byte
 	)
 	request, err = http.NewRequest("GET", url, nil)
 	if err != nil {
 		return
 	}
 
-	request.Header.Set("User-Agent", fmt.Sprintf("Faws/%s", about.GetVersionString()))
-
-	response, err = filesystem_website.client.Do(request)
+	response, body, err = filesystem_website.client.fetch(request)
 	if err != nil {
 		return
 	}
@@ -139,19 +136,15 @@ func (filesystem_website *filesystem_website) Pull(name string) (file io.ReadClo
 		return
 	}
 
-	file = response.Body
+	file = io.NopCloser(bytes.NewReader(body))
 	return
 }
 
-func open_filesystem_website(website_url *url.URL) (origin Origin, err error) {
+func open_filesystem_website(website_url *url.URL, o *options) (origin Origin, err error) {
 	filesystem_website_ := new(filesystem_website)
 	filesystem_website_.base_url = *website_url
-
-	if filesystem_website_.base_url.Scheme == "https" {
-		var http_transport http.Transport
-		http_transport.MaxConnsPerHost = 32
-		http_transport.MaxIdleConnsPerHost = 16
-		filesystem_website_.client.Transport = &http_transport
+	if filesystem_website_.client, err = new_http_client(o); err != nil {
+		return
 	}
 
 	if !strings.HasSuffix(filesystem_website_.base_url.Path, "/") {
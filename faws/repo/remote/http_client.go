@@ -0,0 +1,241 @@
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/faws-vcs/faws/faws/app/about"
+)
+
+const (
+	// the delay before the first retry, which doubles with each retry after it
+	http_backoff_base = 500 * time.Millisecond
+	http_backoff_max  = 30 * time.Second
+	// the longest a server may ask us to wait with Retry-After
+	http_max_retry_after = 5 * time.Minute
+)
+
+// http_client sends requests to an origin over HTTP. Requests which fail for a reason that may be temporary are sent again,
+// with an exponentially increasing delay between each attempt.
+type http_client struct {
+	client  http.Client
+	timeout time.Duration
+	retries int
+	// holds a value for every request in flight
+	requests    chan struct{}
+	credentials Credentials
+}
+
+func new_http_client(o *options) (http_client_ *http_client, err error) {
+	var tls_config *tls.Config
+	if tls_config, err = o.credentials.tls_config(); err != nil {
+		return
+	}
+
+	http_client_ = new(http_client)
+	http_client_.credentials = o.credentials
+	http_client_.timeout = o.timeout
+	http_client_.retries = max(o.retries, 0)
+	http_client_.requests = make(chan struct{}, max(o.max_requests, 1))
+
+	var dialer net.Dialer
+	dialer.Timeout = o.timeout
+	dialer.KeepAlive = 30 * time.Second
+
+	var http_transport http.Transport
+	http_transport.Proxy = http.ProxyFromEnvironment
+	http_transport.DialContext = dialer.DialContext
+	http_transport.ForceAttemptHTTP2 = true
+	http_transport.TLSClientConfig = tls_config
+	http_transport.TLSHandshakeTimeout = o.timeout
+	http_transport.ResponseHeaderTimeout = o.timeout
+	http_transport.MaxConnsPerHost = max(o.max_requests, 1)
+	http_transport.MaxIdleConnsPerHost = max(o.max_requests, 1)
+	http_client_.client.Transport = &http_transport
+	return
+}
+
+// http_body is the body of a response. It frees the request's place among those in flight once it is closed,
+// and abandons the request if no more of the body arrives before the timeout.
+type http_body struct {
+	io.ReadCloser
+	timer   *time.Timer
+	timeout time.Duration
+	cancel  context.CancelFunc
+	release func()
+	closed  bool
+}
+
+func (body *http_body) Read(b []byte) (n int, err error) {
+	n, err = body.ReadCloser.Read(b)
+	if n > 0 {
+		body.timer.Reset(body.timeout)
+	}
+	return
+}
+
+func (body *http_body) Close() (err error) {
+	if body.closed {
+		return
+	}
+	body.closed = true
+	err = body.ReadCloser.Close()
+	body.timer.Stop()
+	body.cancel()
+	body.release()
+	return
+}
+
+// returns true if a request may be safely sent more than once
+func is_idempotent(request *http.Request) bool {
+	return request.Method == http.MethodGet || request.Method == http.MethodHead
+}
+
+// returns true if a response with this status may succeed if the request is sent again
+func is_temporary_status(status_code int) bool {
+	switch status_code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parses the Retry-After header, which is either a number of seconds or a date
+func retry_after(header http.Header) (delay time.Duration, ok bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+		ok = true
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = max(time.Until(date), 0)
+		ok = true
+	}
+	delay = min(delay, http_max_retry_after)
+	return
+}
+
+// returns how long to wait before the retry that follows attempt, with some jitter so that many workers don't retry in lockstep
+func backoff(attempt int) (delay time.Duration) {
+	delay = http_backoff_max
+	if attempt < 16 {
+		delay = min(http_backoff_base<<attempt, http_backoff_max)
+	}
+	delay = delay/2 + rand.N(delay/2+1)
+	return
+}
+
+// sets the headers sent with every request
+func (http_client *http_client) prepare(request *http.Request) {
+	request.Header.Set("User-Agent", fmt.Sprintf("Faws/%s", about.GetVersionString()))
+	http_client.credentials.authorize(request)
+}
+
+// sends a request once. the response body must be closed
+func (http_client *http_client) do_once(request *http.Request) (response *http.Response, err error) {
+	http_client.requests <- struct{}{}
+	release := func() {
+		<-http_client.requests
+	}
+
+	ctx, cancel := context.WithCancel(request.Context())
+	timer := time.AfterFunc(http_client.timeout, cancel)
+
+	response, err = http_client.client.Do(request.WithContext(ctx))
+	if err != nil {
+		timer.Stop()
+		cancel()
+		release()
+		return
+	}
+	response.Body = &http_body{response.Body, timer, http_client.timeout, cancel, release, false}
+	return
+}
+
+// returns true if a request should be sent again after an attempt that ended with response or err
+func (http_client *http_client) should_retry(request *http.Request, attempt int, response *http.Response, err error) bool {
+	if request.Context().Err() != nil || !is_idempotent(request) || attempt >= http_client.retries {
+		return false
+	}
+	if err != nil {
+		return !is_permanent_error(err)
+	}
+	return is_temporary_status(response.StatusCode)
+}
+
+// returns true if an error will happen again no matter how many times the request is sent, such as an untrusted certificate
+func is_permanent_error(err error) bool {
+	var (
+		certificate_error   *tls.CertificateVerificationError
+		unknown_authority   x509.UnknownAuthorityError
+		hostname_error      x509.HostnameError
+		invalid_certificate x509.CertificateInvalidError
+	)
+	return errors.As(err, &certificate_error) || errors.As(err, &unknown_authority) ||
+		errors.As(err, &hostname_error) || errors.As(err, &invalid_certificate)
+}
+
+// waits before the retry that follows attempt, for as long as the server asked, if it did
+func wait_to_retry(attempt int, response *http.Response) {
+	delay, ok := time.Duration(0), false
+	if response != nil {
+		delay, ok = retry_after(response.Header)
+	}
+	if !ok {
+		delay = backoff(attempt)
+	}
+	time.Sleep(delay)
+}
+
+// sends a request, retrying it if it fails for a reason that may be temporary. The whole body is read before returning,
+// so that a response that is cut short can be retried too. The caller must check the status of the response.
+func (http_client *http_client) fetch(request *http.Request) (response *http.Response, body []byte, err error) {
+	http_client.prepare(request)
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && request.GetBody != nil {
+			if request.Body, err = request.GetBody(); err != nil {
+				return
+			}
+		}
+
+		response, err = http_client.do_once(request)
+		if err == nil {
+			body, err = io.ReadAll(response.Body)
+			response.Body.Close()
+		}
+		if !http_client.should_retry(request, attempt, response, err) {
+			return
+		}
+		wait_to_retry(attempt, response)
+	}
+}
+
+// sends a request, retrying it if it fails before the response begins. The caller must check the status of the response
+// and close its body.
+func (http_client *http_client) open(request *http.Request) (response *http.Response, err error) {
+	http_client.prepare(request)
+
+	for attempt := 0; ; attempt++ {
+		response, err = http_client.do_once(request)
+		if !http_client.should_retry(request, attempt, response, err) {
+			return
+		}
+		if err == nil {
+			response.Body.Close()
+		}
+		wait_to_retry(attempt, response)
+	}
+}
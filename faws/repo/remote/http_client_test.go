@@ -0,0 +1,55 @@
+package remote
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHTTPClientRetry(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("finally"))
+	}))
+	defer server.Close()
+
+	o := default_options()
+	client, err := new_http_client(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, body, err := client.fetch(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusOK || string(body) != "finally" {
+		t.Fatalf("got %s %q", response.Status, body)
+	}
+	if attempts.Load() != 3 {
+		t.Fatalf("server was asked %d times", attempts.Load())
+	}
+
+	// requests that aren't idempotent are never sent twice
+	attempts.Store(0)
+	request, err = http.NewRequest("POST", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response, _, err = client.fetch(request); err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusServiceUnavailable || attempts.Load() != 1 {
+		t.Fatalf("got %s after %d attempts", response.Status, attempts.Load())
+	}
+}
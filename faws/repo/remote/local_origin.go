@@ -0,0 +1,132 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	fawsfs "github.com/faws-vcs/faws/faws/fs"
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/validate"
+)
+
+// local_origin is a repository in a local directory. It is read like any other filesystem,
+// but it can also be pushed to, by writing to its objects and tags directly.
+type local_origin struct {
+	filesystem_origin
+	directory string
+	// the objects of the repository, opened the first time they are needed
+	objects      cas.Set
+	objects_open bool
+}
+
+func (local_origin *local_origin) open_objects() (err error) {
+	if local_origin.objects_open {
+		return
+	}
+	objects_directory := filepath.Join(local_origin.directory, "objects")
+	// don't create the objects of a repository that doesn't exist
+	if _, err = os.Stat(objects_directory); err != nil {
+		return
+	}
+	if err = local_origin.objects.Open(objects_directory); err != nil {
+		return
+	}
+	local_origin.objects_open = true
+	return
+}
+
+func (local_origin *local_origin) PutObject(prefix cas.Prefix, data []byte) (err error) {
+	if err = local_origin.open_objects(); err != nil {
+		return
+	}
+	_, _, err = local_origin.objects.Store(prefix, data)
+	return
+}
+
+func (local_origin *local_origin) HasObjects(object_hashes []cas.ContentID) (has []bool, err error) {
+	if err = local_origin.open_objects(); err != nil {
+		return
+	}
+	has = make([]bool, len(object_hashes))
+	for i, object_hash := range object_hashes {
+		_, stat_err := local_origin.objects.Stat(object_hash)
+		if stat_err == nil {
+			has[i] = true
+		} else if !errors.Is(stat_err, cas.ErrObjectNotFound) {
+			err = stat_err
+			return
+		}
+	}
+	return
+}
+
+func (local_origin *local_origin) WriteTag(name string, old_commit_hash, new_commit_hash cas.ContentID) (err error) {
+	if err = validate.CommitTag(name); err != nil {
+		return
+	}
+
+	// only one tag can be written at a time. the lock can't be kept in the tags directory, where it might be taken for a tag
+	lock_path := filepath.Join(local_origin.directory, "tags.lock")
+	var lock_file *os.File
+	lock_file, err = os.OpenFile(lock_path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, fawsfs.DefaultPublicPerm)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			err = fmt.Errorf("%w: remove %s if it is not", ErrTagLocked, lock_path)
+		}
+		return
+	}
+	lock_file.Close()
+	defer os.Remove(lock_path)
+
+	tags_directory := filepath.Join(local_origin.directory, "tags")
+	tag_path := filepath.Join(tags_directory, name)
+
+	var current_commit_hash cas.ContentID
+	current_tag, read_err := os.ReadFile(tag_path)
+	if read_err == nil {
+		copy(current_commit_hash[:], current_tag)
+	} else if !errors.Is(read_err, fs.ErrNotExist) {
+		err = read_err
+		return
+	}
+	if current_commit_hash != old_commit_hash {
+		err = fmt.Errorf("%w: %s now points to %s", ErrTagChanged, name, current_commit_hash)
+		return
+	}
+
+	if err = os.MkdirAll(tags_directory, fawsfs.DefaultPublicDirPerm); err != nil {
+		return
+	}
+	// readers see either the old commit or the new one, never a partially written tag
+	var temp_file *os.File
+	temp_file, err = os.CreateTemp(local_origin.directory, "tag.*.part")
+	if err != nil {
+		return
+	}
+	temp_path := temp_file.Name()
+	_, err = temp_file.Write(new_commit_hash[:])
+	if close_err := temp_file.Close(); err == nil {
+		err = close_err
+	}
+	if err == nil {
+		err = os.Chmod(temp_path, fawsfs.DefaultPublicPerm)
+	}
+	if err == nil {
+		err = os.Rename(temp_path, tag_path)
+	}
+	if err != nil {
+		os.Remove(temp_path)
+	}
+	return
+}
+
+func (local_origin *local_origin) Close() (err error) {
+	if local_origin.objects_open {
+		local_origin.objects_open = false
+		err = local_origin.objects.Close()
+	}
+	return
+}
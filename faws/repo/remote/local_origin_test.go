@@ -0,0 +1,50 @@
+package remote
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+)
+
+func TestLocalOriginWriteTag(t *testing.T) {
+	origin, err := open_filesystem_local(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writable_origin := origin.(WritableOrigin)
+
+	commit1 := cas.ContentID{1}
+	commit2 := cas.ContentID{2}
+
+	if _, err = writable_origin.ReadTag("v1"); !errors.Is(err, ErrTagNotFound) {
+		t.Fatalf("expected ErrTagNotFound, got %v", err)
+	}
+
+	if err = writable_origin.WriteTag("v1", cas.Nil, commit1); err != nil {
+		t.Fatal(err)
+	}
+	// someone else already created the tag
+	if err = writable_origin.WriteTag("v1", cas.Nil, commit2); !errors.Is(err, ErrTagChanged) {
+		t.Fatalf("expected ErrTagChanged, got %v", err)
+	}
+	if err = writable_origin.WriteTag("v1", commit1, commit2); err != nil {
+		t.Fatal(err)
+	}
+
+	commit_hash, err := writable_origin.ReadTag("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit_hash != commit2 {
+		t.Fatalf("tag points to %s, expected %s", commit_hash, commit2)
+	}
+
+	tags, err := writable_origin.Tags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 || tags[0] != "v1" {
+		t.Fatal(tags)
+	}
+}
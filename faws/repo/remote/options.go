@@ -0,0 +1,72 @@
+package remote
+
+import (
+	"time"
+
+	"github.com/faws-vcs/faws/faws/identity"
+)
+
+const (
+	default_timeout      = 30 * time.Second
+	default_retries      = 5
+	default_max_requests = 16
+)
+
+type options struct {
+	// how long to wait for a response to begin, or for more of it to arrive
+	timeout time.Duration
+	// how many times a request that failed for a temporary reason is sent again
+	retries int
+	// the most requests that may be in flight at once
+	max_requests int
+	// authenticate requests to HTTP origins
+	credentials Credentials
+	// decides whether the publisher of a bundle is trusted
+	trust TrustFunc
+}
+
+// An Option can be passed to [Open] to change how the origin is accessed
+type Option func(*options)
+
+// WithTimeout is an [Option] that abandons a request to an HTTP origin if no part of the response arrives within timeout.
+// It is not a limit on how long the whole response may take.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithRetries is an [Option] that sends a failed request to an HTTP origin again, up to retries times,
+// if it failed for a reason that may be temporary, such as a timeout or a 503 Service Unavailable.
+// Only requests that read from the origin are retried.
+func WithRetries(retries int) Option {
+	return func(o *options) {
+		o.retries = retries
+	}
+}
+
+// WithMaxRequests is an [Option] that limits the number of requests to an HTTP origin that may be in flight at once.
+// Requests beyond the limit wait for an earlier one to finish.
+func WithMaxRequests(max_requests int) Option {
+	return func(o *options) {
+		o.max_requests = max_requests
+	}
+}
+
+// A TrustFunc returns true if id is trusted, given the attributes it signed
+type TrustFunc func(id identity.ID, signed_attributes *identity.Attributes) (trusted bool)
+
+// WithTrust is an [Option] that refuses to open a bundle unless trust accepts its publisher.
+// Without it, any publisher is accepted, unless the bundle URI names one.
+func WithTrust(trust TrustFunc) Option {
+	return func(o *options) {
+		o.trust = trust
+	}
+}
+
+func default_options() (o options) {
+	o.timeout = default_timeout
+	o.retries = default_retries
+	o.max_requests = default_max_requests
+	return
+}
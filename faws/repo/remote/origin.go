@@ -10,9 +10,12 @@ import (
 // possibilities:
 //   - A local filesystem directory
 //   - A remote HTTP autoindex filesystem such as Apache or Nginx. Autoindex is required to obtain a list of tags.
+//   - A repository served over HTTP by "faws serve"
+//   - A bundle made by "faws bundle create"
 type Origin interface {
 	// - file:///
 	// - http://, https://
+	// - bundle:///, bundle+http://, bundle+https://, followed by ?publisher=<id> once the bundle has been opened
 	URI() (uri string)
 
 	UUID() (id uuid.UUID, err error)
@@ -29,3 +32,44 @@ type Origin interface {
 	// attempt to deabbreviate
 	Deabbreviate(ref string) (object_hash cas.ContentID, err error)
 }
+
+// A WritableOrigin is an Origin which objects and tags can be pushed to
+//
+// possibilities:
+//   - A local filesystem directory
+//
+// Neither a website nor a faws server can be written to over HTTP, so neither is a WritableOrigin.
+type WritableOrigin interface {
+	Origin
+
+	// Store an object in the remote. It is not an error if the remote already has it
+	PutObject(prefix cas.Prefix, data []byte) (err error)
+
+	// Test which objects the remote already has. has[i] is true if the remote has object_hashes[i]
+	HasObjects(object_hashes []cas.ContentID) (has []bool, err error)
+
+	// Point a tag at a new commit, only if it still points at old_commit_hash (or doesn't exist, if old_commit_hash is cas.Nil).
+	// Otherwise, err is ErrTagChanged
+	WriteTag(name string, old_commit_hash, new_commit_hash cas.ContentID) (err error)
+
+	// Release any resources held by the origin
+	Close() (err error)
+}
+
+// GetObjectsFunc receives each object requested from a [BatchOrigin]
+type GetObjectsFunc func(object_hash cas.ContentID, prefix cas.Prefix, data []byte) (err error)
+
+// A BatchOrigin is an Origin which can send many objects in response to a single request
+//
+// possibilities:
+//   - A repository served by "faws serve"
+type BatchOrigin interface {
+	Origin
+
+	// Get objects from the remote, calling fn with each of them in the order they were asked for.
+	// If the remote is missing any of them, err is cas.ErrObjectNotFound
+	GetObjects(object_hashes []cas.ContentID, fn GetObjectsFunc) (err error)
+
+	// Test which objects the remote has. has[i] is true if the remote has object_hashes[i]
+	HasObjects(object_hashes []cas.ContentID) (has []bool, err error)
+}
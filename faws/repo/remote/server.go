@@ -0,0 +1,335 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/repo/config"
+	"github.com/faws-vcs/faws/faws/validate"
+)
+
+// A Server serves a repository over HTTP, to be used as an origin by other repositories.
+//
+// It speaks the smart protocol, which lets clients check for many objects at once and receive many objects in one response.
+// For older clients, which only understand a website listing the files of a repository, it also serves the repository
+// as if all of its objects were loose, including the objects that are packed.
+type Server struct {
+	directory string
+	config    config.Config
+	objects   cas.Set
+	mux       http.ServeMux
+}
+
+// Open starts serving the repository at directory. [Server.Close] must be called when the Server is no longer in use.
+func (server *Server) Open(directory string) (err error) {
+	server.directory = directory
+	if err = config.ReadConfig(filepath.Join(directory, "config"), &server.config); err != nil {
+		return
+	}
+
+	options := []cas.SetOption{cas.WithReadOnly()}
+	for _, alternate := range server.config.Alternates {
+		if !filepath.IsAbs(alternate) {
+			alternate = filepath.Join(directory, alternate)
+		}
+		options = append(options, cas.WithAlternates(alternate))
+	}
+	if err = server.objects.Open(filepath.Join(directory, "objects"), options...); err != nil {
+		return
+	}
+
+	server.mux.HandleFunc("GET /"+smart_api+"info", server.serve_info)
+	server.mux.HandleFunc("GET /"+smart_api+"tags", server.serve_tags)
+	server.mux.HandleFunc("GET /"+smart_api+"tags/{name}", server.serve_tag)
+	server.mux.HandleFunc("GET /"+smart_api+"deabbreviate/{abbreviation}", server.serve_deabbreviate)
+	server.mux.HandleFunc("POST /"+smart_api+"objects/has", server.serve_has_objects)
+	server.mux.HandleFunc("GET /"+smart_api+"objects/{id}", server.serve_object)
+	server.mux.HandleFunc("GET /"+smart_api+"objects", server.serve_objects)
+
+	// the layout of a repository on disk
+	server.mux.HandleFunc("GET /config", server.serve_config)
+	server.mux.HandleFunc("GET /tags/{$}", server.serve_tags_index)
+	server.mux.HandleFunc("GET /tags/{name}", server.serve_tag_file)
+	server.mux.HandleFunc("GET /objects/{bucket1}/{bucket2}/{rest}", server.serve_object_file)
+	return
+}
+
+// Close releases the objects of the repository
+func (server *Server) Close() (err error) {
+	err = server.objects.Close()
+	return
+}
+
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mux.ServeHTTP(w, r)
+}
+
+func write_json(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+// responds with the status that best describes an error
+func write_error(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, cas.ErrObjectNotFound), errors.Is(err, os.ErrNotExist):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, cas.ErrAbbreviationAmbiguous):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, validate.ErrCommitTagCannotBeEmpty), errors.Is(err, validate.ErrCommitTagTooBig), errors.Is(err, validate.ErrCommitTagInvalidCharacters):
+		// a name that can't be a tag was asked for
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// returns the names of the tags, sorted
+func (server *Server) tags() (names []string, err error) {
+	var entries []os.DirEntry
+	if entries, err = os.ReadDir(filepath.Join(server.directory, "tags")); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && validate.CommitTag(entry.Name()) == nil && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	slices.Sort(names)
+	return
+}
+
+func (server *Server) read_tag(name string) (commit_hash cas.ContentID, err error) {
+	if err = validate.CommitTag(name); err != nil {
+		return
+	}
+	var data []byte
+	if data, err = os.ReadFile(filepath.Join(server.directory, "tags", name)); err != nil {
+		return
+	}
+	if len(data) != cas.ContentIDSize {
+		err = fmt.Errorf("faws/repo/remote: tag %s is malformed", name)
+		return
+	}
+	copy(commit_hash[:], data)
+	return
+}
+
+func (server *Server) serve_info(w http.ResponseWriter, r *http.Request) {
+	write_json(w, smart_info{Protocol: smart_protocol_version, UUID: server.config.UUID})
+}
+
+func (server *Server) serve_tags(w http.ResponseWriter, r *http.Request) {
+	names, err := server.tags()
+	if err != nil {
+		write_error(w, err)
+		return
+	}
+	tags := make([]smart_tag, 0, len(names))
+	for _, name := range names {
+		commit_hash, err := server.read_tag(name)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, smart_tag{name, format_content_id(commit_hash)})
+	}
+	write_json(w, tags)
+}
+
+func (server *Server) serve_tag(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	commit_hash, err := server.read_tag(name)
+	if err != nil {
+		write_error(w, err)
+		return
+	}
+	write_json(w, smart_tag{name, format_content_id(commit_hash)})
+}
+
+func (server *Server) serve_deabbreviate(w http.ResponseWriter, r *http.Request) {
+	object_hash, err := server.objects.Deabbreviate(r.PathValue("abbreviation"))
+	if err != nil {
+		write_error(w, err)
+		return
+	}
+	write_json(w, smart_object{format_content_id(object_hash)})
+}
+
+func (server *Server) serve_has_objects(w http.ResponseWriter, r *http.Request) {
+	var request smart_has_request
+	if err := json.NewDecoder(io.LimitReader(r.Body, smart_max_has_objects*(cas.ContentIDSize*2+4))).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(request.Objects) > smart_max_has_objects {
+		http.Error(w, "too many objects", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var response smart_has_response
+	response.Has = make([]bool, len(request.Objects))
+	for i, object := range request.Objects {
+		object_hash, err := parse_content_id(object)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, err = server.objects.Stat(object_hash)
+		if err == nil {
+			response.Has[i] = true
+		} else if !errors.Is(err, cas.ErrObjectNotFound) {
+			write_error(w, err)
+			return
+		}
+	}
+	write_json(w, response)
+}
+
+// serves the content of one object. the ETag is the ContentID, so the content never changes
+func (server *Server) write_object(w http.ResponseWriter, r *http.Request, object_hash cas.ContentID, with_prefix bool) {
+	etag := `"` + format_content_id(object_hash) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// ranges need to seek, which objects in packs can't do
+	if r.Header.Get("Range") != "" {
+		prefix, data, err := server.objects.Load(object_hash)
+		if err != nil {
+			write_error(w, err)
+			return
+		}
+		if with_prefix {
+			w.Header().Set(smart_prefix_header, string(prefix[:]))
+		} else {
+			data = append(prefix[:], data...)
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+		return
+	}
+
+	prefix, content, size, err := server.objects.OpenObject(object_hash)
+	if err != nil {
+		write_error(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Accept-Ranges", "bytes")
+	if with_prefix {
+		w.Header().Set(smart_prefix_header, string(prefix[:]))
+		w.Header().Set("Content-Length", fmt.Sprint(size))
+	} else {
+		w.Header().Set("Content-Length", fmt.Sprint(size+cas.PrefixSize))
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if !with_prefix {
+		w.Write(prefix[:])
+	}
+	io.Copy(w, content)
+}
+
+func (server *Server) serve_object(w http.ResponseWriter, r *http.Request) {
+	object_hash, err := parse_content_id(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	server.write_object(w, r, object_hash, true)
+}
+
+func (server *Server) serve_objects(w http.ResponseWriter, r *http.Request) {
+	ids := r.URL.Query()["id"]
+	if len(ids) > smart_max_objects {
+		http.Error(w, "too many objects", http.StatusRequestEntityTooLarge)
+		return
+	}
+	object_hashes := make([]cas.ContentID, len(ids))
+	for i, id := range ids {
+		var err error
+		if object_hashes[i], err = parse_content_id(id); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	// each object is streamed straight from its pack. once the response has begun, an error can only be reported by cutting it short
+	for _, object_hash := range object_hashes {
+		prefix, content, size, err := server.objects.OpenObject(object_hash)
+		if errors.Is(err, cas.ErrObjectNotFound) {
+			if write_frame_header(w, object_hash, cas.Prefix{}, 0) != nil {
+				return
+			}
+			continue
+		} else if err != nil {
+			return
+		}
+		if err = write_frame_header(w, object_hash, prefix, size); err == nil {
+			_, err = io.Copy(w, content)
+		}
+		content.Close()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (server *Server) serve_config(w http.ResponseWriter, r *http.Request) {
+	http.ServeFile(w, r, filepath.Join(server.directory, "config"))
+}
+
+// lists the tags like an autoindex page
+func (server *Server) serve_tags_index(w http.ResponseWriter, r *http.Request) {
+	names, err := server.tags()
+	if err != nil {
+		write_error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintln(w, "<html><body><pre>")
+	for _, name := range names {
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", html.EscapeString(name), html.EscapeString(name))
+	}
+	fmt.Fprintln(w, "</pre></body></html>")
+}
+
+func (server *Server) serve_tag_file(w http.ResponseWriter, r *http.Request) {
+	commit_hash, err := server.read_tag(r.PathValue("name"))
+	if err != nil {
+		write_error(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(commit_hash[:])
+}
+
+// serves an object at the path it would have if it were loose, prefix included
+func (server *Server) serve_object_file(w http.ResponseWriter, r *http.Request) {
+	object_hash, err := parse_content_id(r.PathValue("bucket1") + r.PathValue("bucket2") + r.PathValue("rest"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	server.write_object(w, r, object_hash, false)
+}
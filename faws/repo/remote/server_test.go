@@ -0,0 +1,125 @@
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/repo/config"
+	"github.com/google/uuid"
+)
+
+func TestServerSmartOrigin(t *testing.T) {
+	directory := t.TempDir()
+	var repo_config config.Config
+	repo_config.UUID = uuid.New()
+	if err := config.WriteConfig(filepath.Join(directory, "config"), &repo_config); err != nil {
+		t.Fatal(err)
+	}
+
+	var objects cas.Set
+	if err := objects.Open(filepath.Join(directory, "objects")); err != nil {
+		t.Fatal(err)
+	}
+	part1 := []byte("the first part")
+	part2 := []byte("the second part")
+	_, part1_hash, err := objects.Store(cas.Part, part1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, part2_hash, err := objects.Store(cas.Part, part2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects.Close()
+
+	if err = os.Mkdir(filepath.Join(directory, "tags"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(directory, "tags", "v1"), part1_hash[:], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var server Server
+	if err = server.Open(directory); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	http_server := httptest.NewServer(&server)
+	defer http_server.Close()
+
+	server_url, _ := url.Parse(http_server.URL)
+	o := default_options()
+	origin, err := open_smart_origin(server_url, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := origin.UUID(); id != repo_config.UUID {
+		t.Fatalf("UUID is %s, expected %s", id, repo_config.UUID)
+	}
+
+	tags, err := origin.Tags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 || tags[0] != "v1" {
+		t.Fatal(tags)
+	}
+	if commit_hash, err := origin.ReadTag("v1"); err != nil || commit_hash != part1_hash {
+		t.Fatal(commit_hash, err)
+	}
+
+	// a name that can't be a tag is the client's fault, and a tag that doesn't exist is simply not found
+	for name, status := range map[string]int{"v%20" + strings.Repeat("x", 200): http.StatusBadRequest, "v2": http.StatusNotFound} {
+		response, err := http.Get(http_server.URL + "/" + smart_api + "tags/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != status {
+			t.Fatalf("GET tags/%s returned %d, expected %d", name, response.StatusCode, status)
+		}
+	}
+
+	prefix, data, err := origin.GetObject(part2_hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefix != cas.Part || !bytes.Equal(data, part2) {
+		t.Fatalf("got %s %q", prefix, data)
+	}
+
+	has, err := origin.HasObjects([]cas.ContentID{part1_hash, cas.Nil, part2_hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !has[0] || has[1] || !has[2] {
+		t.Fatal(has)
+	}
+
+	var received [][]byte
+	err = origin.GetObjects([]cas.ContentID{part2_hash, part1_hash}, func(object_hash cas.ContentID, prefix cas.Prefix, data []byte) (err error) {
+		received = append(received, data)
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 || !bytes.Equal(received[0], part2) || !bytes.Equal(received[1], part1) {
+		t.Fatalf("%q", received)
+	}
+
+	err = origin.GetObjects([]cas.ContentID{cas.Nil}, func(object_hash cas.ContentID, prefix cas.Prefix, data []byte) (err error) {
+		return
+	})
+	if !errors.Is(err, cas.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package remote
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/google/uuid"
+)
+
+// The smart protocol is spoken by "faws serve". Every request is relative to the URL of the repository:
+//
+//	GET  api/info                   smart_info
+//	GET  api/tags                   []smart_tag
+//	GET  api/tags/{name}            smart_tag
+//	GET  api/deabbreviate/{abbr}    smart_object
+//	POST api/objects/has            smart_has_request -> smart_has_response
+//	GET  api/objects/{id}           the content of one object, with its prefix in the Faws-Prefix header
+//	GET  api/objects?id=...&id=...  the frames of up to smart_max_objects objects, in the order they were asked for
+//
+// Single objects have an ETag of their ContentID, and can be requested in ranges.
+//
+// A frame is the ContentID of the object, its prefix, and its size as a little-endian uint64, followed by its content.
+// If the server does not have the object, the prefix and size are zero.
+const (
+	smart_protocol_version = 1
+	smart_api              = "api/"
+	smart_prefix_header    = "Faws-Prefix"
+	// the most objects that can be requested at once
+	smart_max_objects = 256
+	// the most objects that can be tested for existence at once
+	smart_max_has_objects = 65536
+	smart_frame_header    = cas.ContentIDSize + cas.PrefixSize + 8
+)
+
+type smart_info struct {
+	Protocol int       `json:"protocol"`
+	UUID     uuid.UUID `json:"uuid"`
+}
+
+type smart_tag struct {
+	Name   string `json:"name"`
+	Commit string `json:"commit"`
+}
+
+type smart_object struct {
+	Object string `json:"object"`
+}
+
+type smart_has_request struct {
+	Objects []string `json:"objects"`
+}
+
+type smart_has_response struct {
+	Has []bool `json:"has"`
+}
+
+// parses a full hexadecimal ContentID
+func parse_content_id(s string) (id cas.ContentID, err error) {
+	if len(s) != cas.ContentIDSize*2 {
+		err = fmt.Errorf("faws/repo/remote: '%s' is not a full object hash", s)
+		return
+	}
+	_, err = hex.Decode(id[:], []byte(s))
+	return
+}
+
+// formats a full hexadecimal ContentID. unlike ContentID.String, cas.Nil is not written as "nil"
+func format_content_id(id cas.ContentID) string {
+	return hex.EncodeToString(id[:])
+}
+
+func write_frame_header(w io.Writer, id cas.ContentID, prefix cas.Prefix, size int64) (err error) {
+	var header [smart_frame_header]byte
+	copy(header[:cas.ContentIDSize], id[:])
+	copy(header[cas.ContentIDSize:], prefix[:])
+	binary.LittleEndian.PutUint64(header[cas.ContentIDSize+cas.PrefixSize:], uint64(size))
+	_, err = w.Write(header[:])
+	return
+}
+
+func read_frame_header(r io.Reader) (id cas.ContentID, prefix cas.Prefix, size int64, err error) {
+	var header [smart_frame_header]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return
+	}
+	copy(id[:], header[:cas.ContentIDSize])
+	copy(prefix[:], header[cas.ContentIDSize:])
+	size = int64(binary.LittleEndian.Uint64(header[cas.ContentIDSize+cas.PrefixSize:]))
+	return
+}
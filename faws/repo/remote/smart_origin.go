@@ -0,0 +1,288 @@
+package remote
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/validate"
+	"github.com/google/uuid"
+)
+
+// smart_origin is a repository served by "faws serve", which is spoken to with the smart protocol
+type smart_origin struct {
+	base_url url.URL
+	client   *http_client
+	info     smart_info
+}
+
+func (smart_origin *smart_origin) url(name string) (s string) {
+	u := smart_origin.base_url
+	name, u.RawQuery, _ = strings.Cut(name, "?")
+	u.Path += smart_api + name
+	s = u.String()
+	return
+}
+
+// returns an error describing an unsuccessful response from the API
+func smart_status_error(response *http.Response, name string) (err error) {
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		err = fmt.Errorf("%w: %s", cas.ErrObjectNotFound, name)
+	case http.StatusConflict:
+		err = cas.ErrAbbreviationAmbiguous
+	default:
+		err = fmt.Errorf("faws/repo/remote: server returned %s", response.Status)
+	}
+	return
+}
+
+func (smart_origin *smart_origin) new_request(method, name string, request_value any) (request *http.Request, err error) {
+	var body io.Reader
+	if request_value != nil {
+		var data []byte
+		if data, err = json.Marshal(request_value); err != nil {
+			return
+		}
+		body = bytes.NewReader(data)
+	}
+	if request, err = http.NewRequest(method, smart_origin.url(name), body); err != nil {
+		return
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	return
+}
+
+// sends a request to the API, returning the body of the response only if it was successful
+func (smart_origin *smart_origin) do(method, name string, request_value any) (response *http.Response, body []byte, err error) {
+	var request *http.Request
+	if request, err = smart_origin.new_request(method, name, request_value); err != nil {
+		return
+	}
+	if response, body, err = smart_origin.client.fetch(request); err != nil {
+		return
+	}
+	err = smart_status_error(response, name)
+	return
+}
+
+// sends a request to the API, decoding the JSON response into value
+func (smart_origin *smart_origin) do_json(method, name string, request_value, value any) (err error) {
+	var body []byte
+	if _, body, err = smart_origin.do(method, name, request_value); err != nil {
+		return
+	}
+	err = json.Unmarshal(body, value)
+	return
+}
+
+func (smart_origin *smart_origin) URI() (uri string) {
+	uri = smart_origin.base_url.String()
+	return
+}
+
+func (smart_origin *smart_origin) UUID() (id uuid.UUID, err error) {
+	id = smart_origin.info.UUID
+	return
+}
+
+func (smart_origin *smart_origin) Tags() (tags []string, err error) {
+	var smart_tags []smart_tag
+	if err = smart_origin.do_json("GET", "tags", nil, &smart_tags); err != nil {
+		return
+	}
+	for _, tag := range smart_tags {
+		if validate.CommitTag(tag.Name) == nil {
+			tags = append(tags, tag.Name)
+		}
+	}
+	return
+}
+
+func (smart_origin *smart_origin) ReadTag(name string) (commit_hash cas.ContentID, err error) {
+	if err = validate.CommitTag(name); err != nil {
+		return
+	}
+	var tag smart_tag
+	if err = smart_origin.do_json("GET", "tags/"+name, nil, &tag); err != nil {
+		return
+	}
+	commit_hash, err = parse_content_id(tag.Commit)
+	return
+}
+
+func (smart_origin *smart_origin) GetObject(object_hash cas.ContentID) (prefix cas.Prefix, data []byte, err error) {
+	var response *http.Response
+	if response, data, err = smart_origin.do("GET", "objects/"+format_content_id(object_hash), nil); err != nil {
+		return
+	}
+
+	prefix_header := response.Header.Get(smart_prefix_header)
+	if len(prefix_header) != cas.PrefixSize {
+		err = fmt.Errorf("%w: %s", cas.ErrObjectCorrupted, object_hash)
+		return
+	}
+	copy(prefix[:], prefix_header)
+	if len(data) > cas.MaxObjectSize {
+		err = fmt.Errorf("%w: %s", cas.ErrObjectCorrupted, object_hash)
+	}
+	return
+}
+
+func (smart_origin *smart_origin) Deabbreviate(abbreviation string) (object_hash cas.ContentID, err error) {
+	abbreviation = strings.ToLower(abbreviation)
+	if len(abbreviation) == 0 {
+		err = cas.ErrAbbreviationTooShort
+		return
+	}
+	if !validate.Hex(abbreviation) {
+		err = cas.ErrAbbreviationNotHex
+		return
+	}
+	var object smart_object
+	if err = smart_origin.do_json("GET", "deabbreviate/"+abbreviation, nil, &object); err != nil {
+		return
+	}
+	object_hash, err = parse_content_id(object.Object)
+	return
+}
+
+func (smart_origin *smart_origin) HasObjects(object_hashes []cas.ContentID) (has []bool, err error) {
+	has = make([]bool, 0, len(object_hashes))
+	for len(object_hashes) > 0 {
+		batch := object_hashes[:min(len(object_hashes), smart_max_has_objects)]
+		object_hashes = object_hashes[len(batch):]
+
+		var request smart_has_request
+		request.Objects = make([]string, len(batch))
+		for i, object_hash := range batch {
+			request.Objects[i] = format_content_id(object_hash)
+		}
+		var response smart_has_response
+		if err = smart_origin.do_json("POST", "objects/has", &request, &response); err != nil {
+			return
+		}
+		if len(response.Has) != len(batch) {
+			err = fmt.Errorf("faws/repo/remote: server answered for %d objects out of %d", len(response.Has), len(batch))
+			return
+		}
+		has = append(has, response.Has...)
+	}
+	return
+}
+
+func (smart_origin *smart_origin) GetObjects(object_hashes []cas.ContentID, fn GetObjectsFunc) (err error) {
+	for len(object_hashes) > 0 {
+		batch := object_hashes[:min(len(object_hashes), smart_max_objects)]
+		object_hashes = object_hashes[len(batch):]
+
+		// if the response is cut short, ask again for the objects that didn't arrive
+		for attempt := 0; len(batch) > 0; attempt++ {
+			var (
+				received    int
+				interrupted bool
+			)
+			received, interrupted, err = smart_origin.get_objects(batch, fn)
+			batch = batch[received:]
+			if err == nil {
+				break
+			}
+			if received > 0 {
+				attempt = 0
+			}
+			if !interrupted || attempt >= smart_origin.client.retries {
+				return
+			}
+			wait_to_retry(attempt, nil)
+		}
+	}
+	return
+}
+
+// requests objects in a single response. interrupted is true if the response was cut short
+func (smart_origin *smart_origin) get_objects(object_hashes []cas.ContentID, fn GetObjectsFunc) (received int, interrupted bool, err error) {
+	query := make(url.Values)
+	for _, object_hash := range object_hashes {
+		query.Add("id", format_content_id(object_hash))
+	}
+
+	name := "objects?" + query.Encode()
+	var (
+		request  *http.Request
+		response *http.Response
+	)
+	if request, err = smart_origin.new_request("GET", name, nil); err != nil {
+		return
+	}
+	if response, err = smart_origin.client.open(request); err != nil {
+		return
+	}
+	defer response.Body.Close()
+	if err = smart_status_error(response, name); err != nil {
+		return
+	}
+
+	reader := bufio.NewReader(response.Body)
+	for _, object_hash := range object_hashes {
+		var (
+			id     cas.ContentID
+			prefix cas.Prefix
+			size   int64
+		)
+		if id, prefix, size, err = read_frame_header(reader); err != nil {
+			interrupted = true
+			return
+		}
+		if id != object_hash || size < 0 || size > cas.MaxObjectSize {
+			err = fmt.Errorf("%w: malformed frame for %s", cas.ErrObjectCorrupted, object_hash)
+			return
+		}
+		if prefix == (cas.Prefix{}) {
+			err = fmt.Errorf("%w: %s", cas.ErrObjectNotFound, object_hash)
+			return
+		}
+		data := make([]byte, size)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			interrupted = true
+			return
+		}
+		if err = fn(object_hash, prefix, data); err != nil {
+			return
+		}
+		received++
+	}
+	return
+}
+
+// asks the server whether it speaks the smart protocol.
+func open_smart_origin(base_url *url.URL, o *options) (origin *smart_origin, err error) {
+	origin = new(smart_origin)
+	origin.base_url = *base_url
+	if !strings.HasSuffix(origin.base_url.Path, "/") {
+		origin.base_url.Path += "/"
+	}
+
+	if origin.client, err = new_http_client(o); err != nil {
+		origin = nil
+		return
+	}
+
+	if err = origin.do_json("GET", "info", nil, &origin.info); err != nil {
+		origin = nil
+		return
+	}
+	if origin.info.Protocol != smart_protocol_version {
+		err = fmt.Errorf("faws/repo/remote: server speaks version %d of the smart protocol", origin.info.Protocol)
+		origin = nil
+	}
+	return
+}
@@ -9,10 +9,15 @@ import (
 	"github.com/google/uuid"
 )
 
-func (repo *Repository) Repack(max_archive_size int64) (err error) {
+// Repack replaces every pack in the repository with a single pack containing only the reachable objects.
+// If include_alternates == true, reachable objects that are only present in alternates are copied into the pack,
+// so that the repository no longer depends on them.
+// Options are passed along to the [cas.PackWriter].
+func (repo *Repository) Repack(max_archive_size int64, include_alternates bool, options ...cas.PackWriterOption) (err error) {
 	// gather a list of unreachable objects. These won't be included in the newly packed version of the repository
 	var vq visitor_queue
 	vq.init()
+	vq.track_alternates = include_alternates
 	err = repo.visit_all_objects(&vq)
 	if err != nil {
 		return
@@ -20,7 +25,7 @@ func (repo *Repository) Repack(max_archive_size int64) (err error) {
 
 	temporary_pack := filepath.Join(repo.directory, uuid.New().String())
 	var writer cas.PackWriter
-	if err = writer.Open(temporary_pack, max_archive_size); err != nil {
+	if err = writer.Open(temporary_pack, max_archive_size, options...); err != nil {
 		return
 	}
 
@@ -49,6 +54,19 @@ func (repo *Repository) Repack(max_archive_size int64) (err error) {
 		return
 	}
 
+	for _, id := range vq.alternate_objects {
+		var size int64
+		size, err = repo.objects.Stat(id)
+		if err != nil {
+			return
+		}
+		var job object_pack_job
+		job.id = id
+		job.size = uint32(size)
+		job.include = true
+		object_list.Push(job)
+	}
+
 	vq.destroy()
 
 	var pack_objects event.NotifyParams
@@ -7,6 +7,7 @@ import (
 	"github.com/faws-vcs/faws/faws/repo/config"
 	"github.com/faws-vcs/faws/faws/repo/event"
 	"github.com/faws-vcs/faws/faws/repo/p2p/tracker"
+	"github.com/faws-vcs/faws/faws/repo/remote"
 	"github.com/google/uuid"
 )
 
@@ -25,6 +26,10 @@ type Repository struct {
 	index staging_index
 	// the URL of the tracker server
 	tracker_url string
+	// verify every object in full, regardless of the config
+	paranoid bool
+	// looks up the credentials for the origin
+	credentials remote.CredentialsFunc
 }
 
 type Option func(*Repository)
@@ -58,7 +63,7 @@ func (repo *Repository) Open(directory string, options ...Option) (err error) {
 	}
 
 	// open main cas
-	if err = repo.objects.Open(filepath.Join(repo.directory, "objects")); err != nil {
+	if err = repo.objects.Open(filepath.Join(repo.directory, "objects"), repo.object_set_options()...); err != nil {
 		return
 	}
 
@@ -70,6 +75,15 @@ func (repo *Repository) Open(directory string, options ...Option) (err error) {
 	return
 }
 
+// returns the options used to open the repository's objects
+func (repo *Repository) object_set_options() (options []cas.SetOption) {
+	options = repo.alternate_set_options()
+	if repo.is_paranoid() {
+		options = append(options, cas.WithParanoid(repo.notify_corrupted_object))
+	}
+	return
+}
+
 func (repo *Repository) UUID() uuid.UUID {
 	return repo.config.UUID
 }
@@ -80,6 +94,10 @@ func (repo *Repository) Close() (err error) {
 		return
 	}
 
+	if err = repo.objects.Close(); err != nil {
+		return
+	}
+
 	err = repo.unlock()
 	return
 }
@@ -27,7 +27,7 @@ type CommitInfo struct {
 	Tree cas.ContentID
 	// Unix seconds for when the filesystem is dated to.
 	// (the date of the tree at this repository)
-	// Faws does not manage individual timestamps for files.
+	// Individual timestamps for files are optional, and stored in the tree (see FileMetadata).
 	TreeDate int64
 	// Unix seconds for when the commit was made.
 	CommitDate int64
@@ -0,0 +1,176 @@
+package revision
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// MetadataVersion is the current version of the metadata extension, which follows the entries of trees
+// (and the staging index) when at least one entry carries [FileMetadata].
+const MetadataVersion = 1
+
+var (
+	ErrMetadataVersion   = fmt.Errorf("faws/repo/revision: unknown metadata extension version")
+	ErrMetadataTruncated = fmt.Errorf("faws/repo/revision: metadata extension is truncated")
+)
+
+// MetadataField indicates which fields of [FileMetadata] were recorded
+type MetadataField uint8
+
+const (
+	// the modification time was recorded
+	MetadataModTime MetadataField = 1 << iota
+	// the full POSIX mode was recorded
+	MetadataMode
+)
+
+// FileMetadata is optional information about a file, beyond its content and [FileMode].
+type FileMetadata struct {
+	// Which of the following fields are present
+	Fields MetadataField
+	// Modification time, in nanoseconds since the Unix epoch
+	ModTime int64
+	// POSIX permission bits, including setuid, setgid and sticky (e.g. 04755)
+	Mode uint32
+}
+
+// NewFileMetadata records the modification time and mode of a file from its [fs.FileInfo]
+func NewFileMetadata(info fs.FileInfo) (metadata FileMetadata) {
+	metadata.Fields = MetadataModTime | MetadataMode
+	metadata.ModTime = info.ModTime().UnixNano()
+
+	mode := info.Mode()
+	metadata.Mode = uint32(mode.Perm())
+	if mode&fs.ModeSetuid != 0 {
+		metadata.Mode |= 04000
+	}
+	if mode&fs.ModeSetgid != 0 {
+		metadata.Mode |= 02000
+	}
+	if mode&fs.ModeSticky != 0 {
+		metadata.Mode |= 01000
+	}
+	return
+}
+
+// IsZero returns true if no metadata was recorded
+func (metadata FileMetadata) IsZero() bool {
+	return metadata.Fields == 0
+}
+
+// Time returns the modification time. ok is false if it was not recorded.
+func (metadata FileMetadata) Time() (t time.Time, ok bool) {
+	if metadata.Fields&MetadataModTime == 0 {
+		return
+	}
+	t = time.Unix(0, metadata.ModTime)
+	ok = true
+	return
+}
+
+// Permissions returns the POSIX mode as an [fs.FileMode], made consistent with the executable bit of mode.
+// ok is false if the POSIX mode was not recorded.
+func (metadata FileMetadata) Permissions(mode FileMode) (perm fs.FileMode, ok bool) {
+	if metadata.Fields&MetadataMode == 0 {
+		return
+	}
+	perm = fs.FileMode(metadata.Mode & 0777)
+	if metadata.Mode&04000 != 0 {
+		perm |= fs.ModeSetuid
+	}
+	if metadata.Mode&02000 != 0 {
+		perm |= fs.ModeSetgid
+	}
+	if metadata.Mode&01000 != 0 {
+		perm |= fs.ModeSticky
+	}
+
+	// the FileMode may have been changed since the metadata was recorded (e.g. faws chmod)
+	if mode&FileModeExecutable != 0 {
+		if perm&0111 == 0 {
+			// grant execute to whoever can read
+			perm |= (perm & 0444) >> 2
+		}
+	} else {
+		perm &^= 0111
+	}
+	ok = true
+	return
+}
+
+// String returns the recorded metadata in a human-readable form, or "" if none was recorded
+func (metadata FileMetadata) String() (s string) {
+	if metadata.Fields&MetadataMode != 0 {
+		s = fmt.Sprintf("%04o", metadata.Mode)
+	}
+	if t, ok := metadata.Time(); ok {
+		if s != "" {
+			s += " "
+		}
+		s += t.UTC().Format(time.RFC3339Nano)
+	}
+	return
+}
+
+// AppendMetadataExtension appends the metadata extension for a list of entries to data.
+// The extension is a version byte, followed by the metadata of each entry, in the same order as the entries.
+func AppendMetadataExtension(data []byte, metadata []FileMetadata) []byte {
+	data = append(data, MetadataVersion)
+	for _, m := range metadata {
+		data = append(data, byte(m.Fields))
+		if m.Fields&MetadataModTime != 0 {
+			data = binary.LittleEndian.AppendUint64(data, uint64(m.ModTime))
+		}
+		if m.Fields&MetadataMode != 0 {
+			data = binary.LittleEndian.AppendUint32(data, m.Mode)
+		}
+	}
+	return data
+}
+
+// ReadMetadataExtension reads the metadata extension for count entries from the beginning of data,
+// returning the remaining bytes
+func ReadMetadataExtension(data []byte, count int) (metadata []FileMetadata, remaining []byte, err error) {
+	if len(data) < 1 {
+		err = ErrMetadataTruncated
+		return
+	}
+	if data[0] != MetadataVersion {
+		err = fmt.Errorf("%w: %d", ErrMetadataVersion, data[0])
+		return
+	}
+	field := data[1:]
+
+	metadata = make([]FileMetadata, count)
+	for i := range metadata {
+		m := &metadata[i]
+		if len(field) < 1 {
+			err = ErrMetadataTruncated
+			return
+		}
+		m.Fields = MetadataField(field[0])
+		field = field[1:]
+
+		if m.Fields&MetadataModTime != 0 {
+			if len(field) < 8 {
+				err = ErrMetadataTruncated
+				return
+			}
+			m.ModTime = int64(binary.LittleEndian.Uint64(field[:8]))
+			field = field[8:]
+		}
+		if m.Fields&MetadataMode != 0 {
+			if len(field) < 4 {
+				err = ErrMetadataTruncated
+				return
+			}
+			m.Mode = binary.LittleEndian.Uint32(field[:4])
+			field = field[4:]
+		}
+	}
+
+	remaining = field
+	return
+}
@@ -3,6 +3,7 @@ package revision
 import (
 	"encoding/binary"
 	"fmt"
+	"slices"
 
 	"github.com/faws-vcs/faws/faws/repo/cas"
 )
@@ -30,6 +31,8 @@ type TreeEntry struct {
 	//  if FileModeDirectory,
 	//    this should be one ID pointing to the tree object
 	Content cas.ContentID
+	// Optional modification time and POSIX mode
+	Metadata FileMetadata
 }
 
 // A Tree is a root directory or a subdirectory
@@ -40,6 +43,9 @@ type Tree struct {
 }
 
 // MarshalTree serializes the Tree into a binary representation
+//
+// If any entry carries [FileMetadata], the metadata extension is appended after the entries.
+// Trees without metadata are encoded exactly as they were before the extension existed.
 func MarshalTree(tree *Tree) (data []byte, err error) {
 	// preallocate minimum tree capacity
 	data = make([]byte, 0, 4+4+(len(tree.Entries)*((cas.ContentIDSize*2)+1+4)))
@@ -67,6 +73,17 @@ func MarshalTree(tree *Tree) (data []byte, err error) {
 		data = append(data, file.Content[:]...)
 	}
 
+	// store metadata extension
+	if slices.ContainsFunc(tree.Entries, func(entry TreeEntry) bool {
+		return !entry.Metadata.IsZero()
+	}) {
+		metadata := make([]FileMetadata, len(tree.Entries))
+		for i := range tree.Entries {
+			metadata[i] = tree.Entries[i].Metadata
+		}
+		data = AppendMetadataExtension(data, metadata)
+	}
+
 	return
 }
 
@@ -105,5 +122,17 @@ func UnmarshalTree(data []byte, tree *Tree) (err error) {
 		field = field[cas.ContentIDSize:]
 	}
 
+	// read metadata extension
+	if len(field) > 0 {
+		var metadata []FileMetadata
+		metadata, _, err = ReadMetadataExtension(field, num_entries)
+		if err != nil {
+			return
+		}
+		for i := range tree.Entries {
+			tree.Entries[i].Metadata = metadata[i]
+		}
+	}
+
 	return
 }
@@ -0,0 +1,61 @@
+package revision
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+)
+
+func TestMarshalTreeMetadata(t *testing.T) {
+	var tree Tree
+	tree.Entries = []TreeEntry{
+		{Prefix: cas.File, Name: "a.exe", Mode: FileModeExecutable, Content: cas.ContentID{1}},
+		{Prefix: cas.Tree, Name: "data", Content: cas.ContentID{2}},
+	}
+
+	// without metadata, the tree must be encoded exactly as it was before the extension
+	var legacy []byte
+	legacy = binary.LittleEndian.AppendUint32(legacy, 2)
+	for _, entry := range tree.Entries {
+		legacy = append(legacy, entry.Prefix[:]...)
+		legacy = binary.LittleEndian.AppendUint32(legacy, uint32(len(entry.Name)))
+		legacy = append(legacy, entry.Name...)
+		legacy = append(legacy, byte(entry.Mode))
+		legacy = append(legacy, entry.Content[:]...)
+	}
+
+	data, err := MarshalTree(&tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, legacy) {
+		t.Fatalf("tree without metadata changed encoding")
+	}
+
+	tree.Entries[0].Metadata = FileMetadata{Fields: MetadataModTime | MetadataMode, ModTime: 1700000000123456789, Mode: 04755}
+	data, err = MarshalTree(&tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, legacy) {
+		t.Fatalf("metadata extension must follow the entries")
+	}
+
+	var decoded Tree
+	if err = UnmarshalTree(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Entries[0].Metadata != tree.Entries[0].Metadata {
+		t.Fatalf("metadata mismatch: %+v", decoded.Entries[0].Metadata)
+	}
+	if !decoded.Entries[1].Metadata.IsZero() {
+		t.Fatalf("unexpected metadata on tree entry")
+	}
+
+	perm, ok := decoded.Entries[0].Metadata.Permissions(0)
+	if !ok || perm.Perm() != 0644 {
+		t.Fatalf("non-executable FileMode should clear execute bits, got %s", perm)
+	}
+}
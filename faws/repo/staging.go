@@ -23,10 +23,16 @@ import (
 
 // An IndexEntry associates a path string with an object hash and a filemode
 type staging_index_entry struct {
-	// The hash of the cached file
+	// cas.File, or cas.Link for symbolic links
+	prefix cas.Prefix
+	// The hash of the cached file (or link)
 	file cas.ContentID
 	// The mode of the file
 	mode revision.FileMode
+	// The optional modification time and POSIX mode of the file
+	metadata revision.FileMetadata
+	// The file on disk when it was cached, if it was added from a directory
+	stat staging.StatRecord
 }
 
 // a working version of staging.Index
@@ -35,6 +41,10 @@ type staging_index struct {
 	entries map[string]staging_index_entry
 	// maps lazy signatures to files
 	lazy_signatures map[multipart.LazySignature]cas.ContentID
+	// the modification time of the index file, in nanoseconds since the Unix epoch.
+	// a file modified at or after this time may have changed again without its modification time changing,
+	// because the filesystem only records it so precisely, so its stat record can't be trusted
+	write_time int64
 }
 
 // Index returns the index of the staging area
@@ -44,9 +54,12 @@ func (repo *Repository) Index() (index *staging.Index) {
 	index.Entries = make([]staging.IndexEntry, 0, len(repo.index.entries))
 	for path, index_entry := range repo.index.entries {
 		index.Entries = append(index.Entries, staging.IndexEntry{
-			Path: path,
-			File: index_entry.file,
-			Mode: index_entry.mode,
+			Path:     path,
+			Prefix:   index_entry.prefix,
+			File:     index_entry.file,
+			Mode:     index_entry.mode,
+			Metadata: index_entry.metadata,
+			Stat:     index_entry.stat,
 		})
 	}
 	// sort entries by path
@@ -85,8 +98,8 @@ func (repo *Repository) reset_tree(destination string, tree_hash cas.ContentID)
 	for _, tree_entry := range tree.Entries {
 		child_destination := destination + tree_entry.Name
 		switch tree_entry.Prefix {
-		case cas.File:
-			repo.index.entries[child_destination] = staging_index_entry{tree_entry.Content, tree_entry.Mode}
+		case cas.File, cas.Link:
+			repo.index.entries[child_destination] = staging_index_entry{prefix: tree_entry.Prefix, file: tree_entry.Content, mode: tree_entry.Mode, metadata: tree_entry.Metadata}
 		case cas.Tree:
 			if err = repo.reset_tree(child_destination, tree_entry.Content); err != nil {
 				return
@@ -117,6 +130,22 @@ type staging_options struct {
 	set_mode bool
 	mode     revision.FileMode
 	lazy     bool
+	// if true, the modification time and POSIX mode are not recorded
+	no_metadata bool
+	// if not empty, every file is split by this chunker
+	chunker string
+	// from the repository config
+	chunker_rules []chunker_rule
+	chunking      multipart.ChunkingParams
+	// the number of files cached at once
+	workers int
+	// if true, the ignore files are not read
+	no_ignore bool
+	// if true, files are read again even if their stat record is unchanged
+	rehash bool
+	// the patterns from the ignore files, relative to ignore_root
+	ignore      *pathspec.IgnoreList
+	ignore_root string
 }
 
 // A StagingOption can be used to add specific options to a staging operation
@@ -137,9 +166,55 @@ func WithLazy(lazy bool) StagingOption {
 	}
 }
 
-// ingest a file or directory into the staging area
-func (repo *Repository) stage_file(o *staging_options, destination, source string) (err error) {
-	source_info, stat_err := os.Stat(source)
+// WithMetadata is a [StagingOption] that controls whether the modification time and POSIX mode of
+// added files are recorded. They are recorded by default.
+func WithMetadata(metadata bool) StagingOption {
+	return func(c *staging_options) {
+		c.no_metadata = !metadata
+	}
+}
+
+// WithChunker is a [StagingOption] that splits every added file with the chunker registered as name,
+// overriding the chunker rules in the repository config and the detection of file formats.
+func WithChunker(name string) StagingOption {
+	return func(c *staging_options) {
+		c.chunker = name
+	}
+}
+
+// WithWorkers is a [StagingOption] that sets the number of files that are chunked and stored at once.
+// By default, this is the number of CPUs. Notifications about different files may arrive concurrently.
+func WithWorkers(workers int) StagingOption {
+	return func(c *staging_options) {
+		c.workers = workers
+	}
+}
+
+// WithIgnore is a [StagingOption] that controls whether the patterns in [IgnoreFile] are used to skip files
+// when adding a directory. They are used by default.
+func WithIgnore(ignore bool) StagingOption {
+	return func(c *staging_options) {
+		c.no_ignore = !ignore
+	}
+}
+
+// WithRehash is a [StagingOption] that controls whether files are read and chunked again,
+// even if their size, modification time and inode are the same as when they were last added.
+func WithRehash(rehash bool) StagingOption {
+	return func(c *staging_options) {
+		c.rehash = rehash
+	}
+}
+
+// walks a file or directory, queueing regular files to be cached by the staging workers
+func (repo *Repository) stage_file(o *staging_options, queue *staging_queue, destination, source string) (err error) {
+	if queue.failed() {
+		// a worker has failed, there's no point in walking any further
+		return
+	}
+
+	// symbolic links are recorded, not followed
+	source_info, stat_err := os.Lstat(source)
 	if stat_err != nil {
 		err = stat_err
 		return
@@ -169,7 +244,11 @@ func (repo *Repository) stage_file(o *staging_options, destination, source strin
 				child_destination += "/"
 			}
 			child_destination += directory_entry.Name()
-			if err = repo.stage_file(o, child_destination, filepath.Join(source, directory_entry.Name())); err != nil {
+			child_source := filepath.Join(source, directory_entry.Name())
+			if o.ignored(child_source, directory_entry.IsDir()) {
+				continue
+			}
+			if err = repo.stage_file(o, queue, child_destination, child_source); err != nil {
 				return
 			}
 		}
@@ -182,14 +261,78 @@ func (repo *Repository) stage_file(o *staging_options, destination, source strin
 		return
 	}
 
-	var entry staging_index_entry
+	if source_info.Mode()&os.ModeSymlink != 0 {
+		err = repo.stage_link(destination, source)
+		return
+	}
+
+	job := &staging_job{
+		destination: destination,
+		source:      source,
+		source_info: source_info,
+	}
+	// the workers can't look at the index while links are being staged
+	job.previous, job.has_previous = repo.index.entries[destination]
+	queue.push(job)
+	return
+}
 
+// returns the mode and metadata that a file would be added with
+func (o *staging_options) file_mode(source_info os.FileInfo) (mode revision.FileMode, metadata revision.FileMetadata) {
 	if o.set_mode {
-		entry.mode = o.mode
+		mode = o.mode
 	} else {
 		if source_info.Mode()&0111 != 0 {
 			// if any executable bit is set, the file is an executable.
-			entry.mode = revision.FileModeExecutable
+			mode = revision.FileModeExecutable
+		}
+	}
+
+	if !o.no_metadata {
+		metadata = revision.NewFileMetadata(source_info)
+	}
+	return
+}
+
+// returns the chunker for a file that will be added at destination
+func (repo *Repository) new_chunker(o *staging_options, destination string, file io.ReadSeeker) (chunker multipart.Chunker, err error) {
+	chunker, err = multipart.NewChunker(file,
+		multipart.WithChunker(o.chunker_for(destination)),
+		multipart.WithChunkingParams(o.chunking),
+		multipart.WithFallback(func(name string, chunker_err error) {
+			// the file is still added, just with less deduplication
+			var notify_params event.NotifyParams
+			notify_params.Name1 = destination
+			notify_params.Name2 = name
+			notify_params.Err = chunker_err
+			repo.notify(event.NotifyChunkerFallback, &notify_params)
+		}))
+	return
+}
+
+// chunks and stores a regular file. this is called by the staging workers, so it must not modify the index
+func (repo *Repository) cache_file(o *staging_options, job *staging_job) (err error) {
+	var (
+		destination = job.destination
+		source      = job.source
+		source_info = job.source_info
+		entry       = &job.entry
+	)
+
+	entry.prefix = cas.File
+	entry.mode, entry.metadata = o.file_mode(source_info)
+	entry.stat = staging.NewStatRecord(source_info)
+
+	// if the file looks exactly as it did when it was last cached, don't bother reading it
+	if !o.rehash && o.chunker == "" && job.has_previous && job.previous.prefix == cas.File && job.previous.stat == entry.stat && entry.stat.ModTime < repo.index.write_time {
+		if _, stat_err := repo.objects.Stat(job.previous.file); stat_err == nil {
+			var notify_params event.NotifyParams
+			notify_params.Name1 = destination
+			notify_params.Object1 = job.previous.file
+			repo.notify(event.NotifyCacheUsedStat, &notify_params)
+
+			entry.file = job.previous.file
+			return
 		}
 	}
 
@@ -209,39 +352,27 @@ func (repo *Repository) stage_file(o *staging_options, destination, source strin
 	notify_params.Name2 = source
 	notify_params.Count = source_info.Size()
 	repo.notify(event.NotifyCacheFile, &notify_params)
+	defer repo.notify_cache_file_failed(destination, &err)
 
-	chunker, err = multipart.NewChunker(source_file)
+	chunker, err = repo.new_chunker(o, destination, source_file)
 	if err != nil {
 		return
 	}
 
-	var chunking_file_stage event.NotifyParams
-	chunking_file_stage.Stage = event.StageCacheFile
-	chunking_file_stage.Child = true
-	repo.notify(event.NotifyBeginStage, &chunking_file_stage)
-
-	// hold on to this
-	var (
-		lazy_signature     multipart.LazySignature
-		got_lazy_signature bool
-	)
-
 	// sometimes you can get away with being a little bit lazy
 	// this trick allows us to wholly ingest previously-scanned MPQ files
 	// in a fraction of the time
 	if o.lazy {
 		lazy_chunker, can_be_lazy := chunker.(multipart.LazyChunker)
 		if can_be_lazy {
-			var (
-				lazy_file_hash cas.ContentID
-			)
-			lazy_signature, err = lazy_chunker.LazySignature()
+			job.lazy_signature, err = lazy_chunker.LazySignature()
 			if err != nil {
 				return
 			}
-			got_lazy_signature = true
+			job.got_lazy_signature = true
 
-			lazy_file_hash, ok := repo.index.lazy_signatures[lazy_signature]
+			// the lazy signatures are only written once every worker has finished
+			lazy_file_hash, ok := repo.index.lazy_signatures[job.lazy_signature]
 			if ok {
 				// notify UI that we're lazy
 				var notify_params event.NotifyParams
@@ -249,37 +380,21 @@ func (repo *Repository) stage_file(o *staging_options, destination, source strin
 				notify_params.Object1 = lazy_file_hash
 				repo.notify(event.NotifyCacheUsedLazySignature, &notify_params)
 
-				// we
-				var lazy_file []byte
-				_, lazy_file, err = repo.objects.Load(lazy_file_hash)
-				if err != nil {
+				// make sure the file we're being lazy about still exists
+				if _, _, err = repo.objects.Load(lazy_file_hash); err != nil {
 					return
 				}
-				var lazy_file_part_hash cas.ContentID
-				for len(lazy_file) > 0 {
-					copy(lazy_file_part_hash[:], lazy_file[:cas.ContentIDSize])
-					lazy_file = lazy_file[cas.ContentIDSize:]
-				}
 
 				entry.file = lazy_file_hash
-				repo.index.entries[destination] = entry
-
-				chunking_file_stage.Success = true
-				repo.notify(event.NotifyCompleteStage, &chunking_file_stage)
-
 				return
 			}
 		}
 	}
 
-	// Remove existing entries with this path (deferred in case of detection of lazy signature)
-	delete(repo.index.entries, destination)
-
 	var (
 		chunk    []byte
 		chunk_id cas.ContentID
 		file     []byte
-		file_id  cas.ContentID
 	)
 	for {
 		_, chunk, err = chunker.Next()
@@ -297,54 +412,124 @@ func (repo *Repository) stage_file(o *staging_options, destination, source strin
 		}
 
 		var notify_cache_file_part event.NotifyParams
+		notify_cache_file_part.Name1 = destination
 		notify_cache_file_part.Count = int64(len(chunk))
 		repo.notify(event.NotifyCacheFilePart, &notify_cache_file_part)
 
 		file = append(file, chunk_id[:]...)
 	}
 
-	_, file_id, err = repo.objects.Store(cas.File, file)
-	if err != nil {
+	_, entry.file, err = repo.objects.Store(cas.File, file)
+	return
+}
+
+// tells the user interface that a file which was being cached failed partway through, if *err != nil
+func (repo *Repository) notify_cache_file_failed(destination string, err *error) {
+	if *err == nil {
 		return
 	}
+	var notify_params event.NotifyParams
+	notify_params.Name1 = destination
+	notify_params.Err = *err
+	repo.notify(event.NotifyCacheFileFailed, &notify_params)
+}
 
-	entry.file = file_id
-	repo.index.entries[destination] = entry
-
-	chunking_file_stage.Success = err == nil
-	repo.notify(event.NotifyCompleteStage, &chunking_file_stage)
+// records a symbolic link in the staging area, without following it
+func (repo *Repository) stage_link(destination, source string) (err error) {
+	if err = repo.check_index_destination_for_dir_conflict(destination); err != nil {
+		return
+	}
 
+	var target string
+	target, err = os.Readlink(source)
 	if err != nil {
 		return
 	}
 
-	if got_lazy_signature {
-		repo.index.lazy_signatures[lazy_signature] = file_id
+	var notify_params event.NotifyParams
+	notify_params.Name1 = destination
+	notify_params.Name2 = source
+	notify_params.Count = int64(len(target))
+	repo.notify(event.NotifyCacheFile, &notify_params)
+	defer repo.notify_cache_file_failed(destination, &err)
+
+	var entry staging_index_entry
+	entry.prefix = cas.Link
+	// link targets are stored with forward slashes, like every other path in the repository
+	_, entry.file, err = repo.objects.Store(cas.Link, []byte(filepath.ToSlash(target)))
+	if err != nil {
+		return
 	}
 
+	var notify_cache_file_part event.NotifyParams
+	notify_cache_file_part.Name1 = destination
+	notify_cache_file_part.Count = int64(len(target))
+	repo.notify(event.NotifyCacheFilePart, &notify_cache_file_part)
+
+	repo.index.entries[destination] = entry
 	return
 }
 
-// Add imports a file into the repository, and then adds it to the index (or staging area)
-func (repo *Repository) Add(destination, source string, options ...StagingOption) (err error) {
-	var o staging_options
+// applies the options, and prepares the chunker rules, chunking parameters and ignore files for adding source
+func (repo *Repository) init_staging_options(o *staging_options, source string, options []StagingOption) (absolute_source string, err error) {
 	for _, option := range options {
-		option(&o)
+		option(o)
+	}
+
+	if o.chunker != "" {
+		if err = check_chunker(o.chunker); err != nil {
+			return
+		}
+	}
+	o.chunker_rules, err = repo.chunker_rules()
+	if err != nil {
+		return
+	}
+	o.chunking, err = repo.ChunkingParams()
+	if err != nil {
+		return
 	}
 
 	// convert source to absolute path
+	absolute_source = source
 	abs_source, abs_err := filepath.Abs(source)
 	if abs_err == nil {
-		source = abs_source
+		absolute_source = abs_source
 	}
 	// convert back to forward slash
-	source = strings.ReplaceAll(source, "\\", "/")
+	absolute_source = strings.ReplaceAll(absolute_source, "\\", "/")
+
+	// ignore files are only consulted when adding the contents of a directory
+	if !o.no_ignore {
+		if source_info, stat_err := os.Stat(absolute_source); stat_err == nil && source_info.IsDir() {
+			o.ignore_root = absolute_source
+			o.ignore, err = repo.read_ignore_list(absolute_source)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Add imports a file into the repository, and then adds it to the index (or staging area)
+func (repo *Repository) Add(destination, source string, options ...StagingOption) (err error) {
+	var o staging_options
+	if source, err = repo.init_staging_options(&o, source, options); err != nil {
+		return
+	}
 
 	var notify_params event.NotifyParams
 	notify_params.Stage = event.StageCacheFiles
 	repo.notify(event.NotifyBeginStage, &notify_params)
 
-	err = repo.stage_file(&o, destination, source)
+	var queue staging_queue
+	queue.start(repo, &o)
+	walk_err := repo.stage_file(&o, &queue, destination, source)
+	err = queue.finish()
+	if walk_err != nil {
+		err = walk_err
+	}
 
 	notify_params.Success = err == nil
 	repo.notify(event.NotifyCompleteStage, &notify_params)
@@ -429,14 +614,16 @@ func (repo *Repository) Chmod(pattern string, mode revision.FileMode) (err error
 
 func (repo *Repository) read_index() (err error) {
 	var staging_index staging.Index
-	if index_data, index_err := os.ReadFile(filepath.Join(repo.directory, "index")); index_err == nil {
+	index_name := filepath.Join(repo.directory, "index")
+	if index_data, index_err := os.ReadFile(index_name); index_err == nil {
 		if err = staging.UnmarshalIndex(index_data, &staging_index); err != nil {
 			return
 		}
 	}
+	repo.index.write_time = index_write_time(index_name)
 	repo.index.entries = make(map[string]staging_index_entry, len(staging_index.Entries))
 	for _, entry := range staging_index.Entries {
-		repo.index.entries[entry.Path] = staging_index_entry{file: entry.File, mode: entry.Mode}
+		repo.index.entries[entry.Path] = staging_index_entry{prefix: entry.Prefix, file: entry.File, mode: entry.Mode, metadata: entry.Metadata, stat: entry.Stat}
 	}
 
 	repo.index.lazy_signatures = make(map[multipart.LazySignature]cas.ContentID, len(staging_index.Entries))
@@ -453,7 +640,44 @@ func (repo *Repository) write_index() (err error) {
 		return
 	}
 
-	err = os.WriteFile(filepath.Join(repo.directory, "index"), index_data, fs.DefaultPrivatePerm)
+	index_name := filepath.Join(repo.directory, "index")
+	if err = os.WriteFile(index_name, index_data, fs.DefaultPrivatePerm); err != nil {
+		return
+	}
+	repo.index.write_time = index_write_time(index_name)
+
+	// like git, the index is written again without the stat records that became untrustworthy as it was written
+	if repo.index.smudge_racily_clean_entries() {
+		index_data, err = staging.MarshalIndex(repo.Index())
+		if err != nil {
+			return
+		}
+		if err = os.WriteFile(index_name, index_data, fs.DefaultPrivatePerm); err != nil {
+			return
+		}
+		repo.index.write_time = index_write_time(index_name)
+	}
+	return
+}
+
+// clears the stat records of files modified at or after the index was written. Otherwise, once the index is written again,
+// a file changed in the same instant it was added would seem unchanged. Returns true if any were cleared
+func (index *staging_index) smudge_racily_clean_entries() (smudged bool) {
+	for path, entry := range index.entries {
+		if entry.stat != (staging.StatRecord{}) && entry.stat.ModTime >= index.write_time {
+			entry.stat = staging.StatRecord{}
+			index.entries[path] = entry
+			smudged = true
+		}
+	}
+	return
+}
+
+// returns the modification time of the index file, or zero if it doesn't exist
+func index_write_time(name string) (write_time int64) {
+	if info, err := os.Stat(name); err == nil {
+		write_time = info.ModTime().UnixNano()
+	}
 	return
 }
 
@@ -478,7 +702,8 @@ func (repo *Repository) check_index_destination_for_dir_conflict(destination str
 	return
 }
 
-func (repo *Repository) add_object(options *staging_options, destination string, source cas.ContentID) (err error) {
+// adds source at destination. tree_entry is the entry of source in its parent tree, if it has one, so that files keep their mode and metadata
+func (repo *Repository) add_object(options *staging_options, destination string, source cas.ContentID, tree_entry *revision.TreeEntry) (err error) {
 	// remove trailing slash from destination
 	destination = strings.TrimSuffix(destination, "/")
 
@@ -492,16 +717,22 @@ func (repo *Repository) add_object(options *staging_options, destination string,
 	}
 
 	switch source_prefix {
-	case cas.File:
+	case cas.File, cas.Link:
 		if err = repo.check_index_destination_for_dir_conflict(destination); err != nil {
 			return
 		}
 		// very good. since an index is just map of paths to
-		var mode revision.FileMode
-		if options.set_mode {
-			mode = options.mode
+		index_entry := staging_index_entry{prefix: source_prefix, file: source}
+		if tree_entry != nil {
+			index_entry.mode = tree_entry.Mode
+			if !options.no_metadata {
+				index_entry.metadata = tree_entry.Metadata
+			}
+		}
+		if options.set_mode && source_prefix == cas.File {
+			index_entry.mode = options.mode
 		}
-		repo.index.entries[destination] = staging_index_entry{source, mode}
+		repo.index.entries[destination] = index_entry
 	case cas.Tree:
 		// not so easy. we load the tree, and recursively add all of its children to the index.
 		var (
@@ -511,13 +742,14 @@ func (repo *Repository) add_object(options *staging_options, destination string,
 		if err != nil {
 			return
 		}
-		for _, entry := range tree.Entries {
+		for i := range tree.Entries {
+			entry := &tree.Entries[i]
 			child_destination := destination
 			if child_destination != "" {
 				child_destination += "/"
 			}
 			child_destination += entry.Name
-			if err = repo.add_object(options, child_destination, source); err != nil {
+			if err = repo.add_object(options, child_destination, entry.Content, entry); err != nil {
 				return
 			}
 		}
@@ -534,7 +766,7 @@ func (repo *Repository) add_object(options *staging_options, destination string,
 		if err = revision.UnmarshalCommitInfo(commit.Info, &commit_info); err != nil {
 			return
 		}
-		err = repo.add_object(options, destination, commit_info.Tree)
+		err = repo.add_object(options, destination, commit_info.Tree, nil)
 		return
 	default:
 		err = fmt.Errorf("%w: %s", ErrIndexBadObjectPrefix, source_prefix)
@@ -550,6 +782,6 @@ func (repo *Repository) AddObject(destination string, source cas.ContentID, opti
 	for _, option := range options {
 		option(&options_)
 	}
-	err = repo.add_object(&options_, destination, source)
+	err = repo.add_object(&options_, destination, source, nil)
 	return
 }
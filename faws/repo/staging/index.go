@@ -3,6 +3,7 @@ package staging
 import (
 	"encoding/binary"
 	"fmt"
+	"slices"
 
 	"github.com/faws-vcs/faws/faws/multipart"
 	"github.com/faws-vcs/faws/faws/repo/cas"
@@ -11,16 +12,48 @@ import (
 
 var (
 	ErrCacheEntryCannotBeEmpty = fmt.Errorf("faws/repo/staging: index entry cannot be empty")
+	ErrIndexTruncated          = fmt.Errorf("faws/repo/staging: index is truncated")
+	ErrIndexUnknownExtension   = fmt.Errorf("faws/repo/staging: index contains an unknown extension")
+	ErrIndexVersion            = fmt.Errorf("faws/repo/staging: index was written by a newer version of Faws")
 )
 
+// IndexVersion is the version of the index format written by [MarshalIndex].
+//
+// Version 0 indexes have no header, and begin directly with the number of entries.
+// Later versions begin with index_magic, followed by the version number.
+const IndexVersion = 1
+
+// the beginning of a versioned index. read as the number of entries in a version 0 index,
+// it would be far more than could fit in an index file of any reasonable size
+var index_magic = [4]byte{'F', 'I', 'D', 'X'}
+
+// extensions which may follow the lazy signatures, each introduced by a tag byte
+const (
+	// the metadata of each entry, see [revision.AppendMetadataExtension]
+	index_extension_metadata byte = 1 + iota
+	// the prefix of each entry, present only if an entry is not a [cas.File]
+	index_extension_prefixes
+	// the [StatRecord] of each entry, present only if any entry has one
+	index_extension_stat
+)
+
+// the size of a StatRecord in the stat extension, after the byte indicating whether it is present
+const stat_record_size = 32
+
 // An IndexEntry associates a path string with an object hash and a filemode
 type IndexEntry struct {
 	// The path inside the repository
 	Path string
-	// The hash of the cached file
+	// The kind of object: [cas.File] or [cas.Link]
+	Prefix cas.Prefix
+	// The hash of the cached file (or link)
 	File cas.ContentID
 	// The mode of the file
 	Mode revision.FileMode
+	// Optional modification time and POSIX mode
+	Metadata revision.FileMetadata
+	// Optional record of the file on disk when it was cached, used to skip reading it again
+	Stat StatRecord
 }
 
 // A LazySignature memoizes the intensive process of scanning a file by exploiting format specific features.
@@ -43,7 +76,13 @@ type Index struct {
 }
 
 // MarshalIndex serializes the Index to a slice of bytes
+//
+// The index always begins with a header carrying the [IndexVersion].
+// Extensions for metadata, entries which are not files and stat records are appended after the lazy signatures, only if needed.
 func MarshalIndex(index *Index) (data []byte, err error) {
+	data = append(data, index_magic[:]...)
+	data = binary.LittleEndian.AppendUint32(data, IndexVersion)
+
 	var entries_count [4]byte
 	binary.LittleEndian.PutUint32(entries_count[:], uint32(len(index.Entries)))
 	data = append(data, entries_count[:]...)
@@ -71,13 +110,69 @@ func MarshalIndex(index *Index) (data []byte, err error) {
 		data = append(data, lazy_signature.File[:]...)
 	}
 
+	if slices.ContainsFunc(index.Entries, func(entry IndexEntry) bool {
+		return !entry.Metadata.IsZero()
+	}) {
+		metadata := make([]revision.FileMetadata, len(index.Entries))
+		for i := range index.Entries {
+			metadata[i] = index.Entries[i].Metadata
+		}
+		data = append(data, index_extension_metadata)
+		data = revision.AppendMetadataExtension(data, metadata)
+	}
+
+	if slices.ContainsFunc(index.Entries, func(entry IndexEntry) bool {
+		return entry.Prefix != cas.File && entry.Prefix != (cas.Prefix{})
+	}) {
+		data = append(data, index_extension_prefixes)
+		for _, entry := range index.Entries {
+			prefix := entry.Prefix
+			if prefix == (cas.Prefix{}) {
+				prefix = cas.File
+			}
+			data = append(data, prefix[:]...)
+		}
+	}
+
+	if slices.ContainsFunc(index.Entries, func(entry IndexEntry) bool {
+		return !entry.Stat.IsZero()
+	}) {
+		data = append(data, index_extension_stat)
+		for _, entry := range index.Entries {
+			if entry.Stat.IsZero() {
+				data = append(data, 0)
+				continue
+			}
+			data = append(data, 1)
+			data = binary.LittleEndian.AppendUint64(data, uint64(entry.Stat.Size))
+			data = binary.LittleEndian.AppendUint64(data, uint64(entry.Stat.ModTime))
+			data = binary.LittleEndian.AppendUint64(data, entry.Stat.Inode)
+			data = binary.LittleEndian.AppendUint64(data, entry.Stat.Device)
+		}
+	}
+
 	return
 }
 
 // UnmarshalIndex deserializes the slice of bytes into the Index
+//
+// Indexes written by older versions are still understood, but an index with a newer [IndexVersion] is refused.
 func UnmarshalIndex(data []byte, index *Index) (err error) {
 	field := data
 
+	if len(field) >= 8 && [4]byte(field[:4]) == index_magic {
+		version := binary.LittleEndian.Uint32(field[4:8])
+		if version > IndexVersion {
+			err = fmt.Errorf("%w: %d", ErrIndexVersion, version)
+			return
+		}
+		field = field[8:]
+	}
+	if len(field) < 4 {
+		err = ErrIndexTruncated
+		return
+	}
+
 	entries_count := binary.LittleEndian.Uint32(field[:4])
 	field = field[4:]
 
@@ -95,6 +190,8 @@ func UnmarshalIndex(data []byte, index *Index) (err error) {
 
 		entry.Mode = revision.FileMode(field[0])
 		field = field[1:]
+
+		entry.Prefix = cas.File
 	}
 
 	lazy_signatures_count := binary.LittleEndian.Uint32(field[:4])
@@ -108,5 +205,55 @@ func UnmarshalIndex(data []byte, index *Index) (err error) {
 		field = field[cas.ContentIDSize:]
 	}
 
+	for len(field) > 0 {
+		extension := field[0]
+		field = field[1:]
+		switch extension {
+		case index_extension_metadata:
+			var metadata []revision.FileMetadata
+			metadata, field, err = revision.ReadMetadataExtension(field, len(index.Entries))
+			if err != nil {
+				return
+			}
+			for i := range index.Entries {
+				index.Entries[i].Metadata = metadata[i]
+			}
+		case index_extension_prefixes:
+			if len(field) < len(index.Entries)*cas.PrefixSize {
+				err = ErrIndexTruncated
+				return
+			}
+			for i := range index.Entries {
+				copy(index.Entries[i].Prefix[:], field[:cas.PrefixSize])
+				field = field[cas.PrefixSize:]
+			}
+		case index_extension_stat:
+			for i := range index.Entries {
+				if len(field) < 1 {
+					err = ErrIndexTruncated
+					return
+				}
+				present := field[0]
+				field = field[1:]
+				if present == 0 {
+					continue
+				}
+				if len(field) < stat_record_size {
+					err = ErrIndexTruncated
+					return
+				}
+				stat := &index.Entries[i].Stat
+				stat.Size = int64(binary.LittleEndian.Uint64(field[0:8]))
+				stat.ModTime = int64(binary.LittleEndian.Uint64(field[8:16]))
+				stat.Inode = binary.LittleEndian.Uint64(field[16:24])
+				stat.Device = binary.LittleEndian.Uint64(field[24:32])
+				field = field[stat_record_size:]
+			}
+		default:
+			err = fmt.Errorf("%w: %d", ErrIndexUnknownExtension, extension)
+			return
+		}
+	}
+
 	return
 }
@@ -0,0 +1,71 @@
+package staging
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/faws-vcs/faws/faws/repo/cas"
+	"github.com/faws-vcs/faws/faws/repo/revision"
+)
+
+func test_index() *Index {
+	return &Index{
+		Entries: []IndexEntry{
+			{Path: "a", Prefix: cas.File, File: cas.ContentID{1}, Mode: revision.FileModeExecutable},
+			{Path: "b/c", Prefix: cas.File, File: cas.ContentID{2}, Stat: StatRecord{Size: 100, ModTime: 1700000000000000000, Inode: 42, Device: 7}},
+			{Path: "d", Prefix: cas.Link, File: cas.ContentID{3}},
+		},
+	}
+}
+
+func TestIndexRoundTrip(t *testing.T) {
+	index := test_index()
+	data, err := MarshalIndex(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Index
+	if err = UnmarshalIndex(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(index.Entries, decoded.Entries) {
+		t.Fatalf("entries changed:\n%v\n%v", index.Entries, decoded.Entries)
+	}
+}
+
+func TestIndexVersion0(t *testing.T) {
+	index := test_index()
+	for i := range index.Entries {
+		index.Entries[i].Prefix = cas.File
+		index.Entries[i].Stat = StatRecord{}
+	}
+	data, err := MarshalIndex(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without stat records, a version 0 index is the same, but without the header
+	var decoded Index
+	if err = UnmarshalIndex(data[8:], &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(index.Entries, decoded.Entries) {
+		t.Fatalf("entries changed:\n%v\n%v", index.Entries, decoded.Entries)
+	}
+}
+
+func TestIndexNewerVersion(t *testing.T) {
+	data, err := MarshalIndex(test_index())
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint32(data[4:8], IndexVersion+1)
+
+	var decoded Index
+	if err = UnmarshalIndex(data, &decoded); !errors.Is(err, ErrIndexVersion) {
+		t.Fatalf("expected ErrIndexVersion, got %v", err)
+	}
+}
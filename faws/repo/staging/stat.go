@@ -0,0 +1,30 @@
+package staging
+
+import (
+	"io/fs"
+)
+
+// A StatRecord remembers what a file looked like on disk when it was cached.
+// If a file still has the same StatRecord, it is assumed to have the same content, and need not be read again.
+type StatRecord struct {
+	// The size of the file in bytes
+	Size int64
+	// The modification time, in nanoseconds since the Unix epoch
+	ModTime int64
+	// The inode number and device of the file, or zero where the platform has no such thing
+	Inode  uint64
+	Device uint64
+}
+
+// NewStatRecord records the size, modification time, inode and device of a file from its [fs.FileInfo]
+func NewStatRecord(info fs.FileInfo) (record StatRecord) {
+	record.Size = info.Size()
+	record.ModTime = info.ModTime().UnixNano()
+	record.Inode, record.Device = file_identity(info)
+	return
+}
+
+// IsZero returns true if nothing was recorded
+func (record StatRecord) IsZero() bool {
+	return record == StatRecord{}
+}
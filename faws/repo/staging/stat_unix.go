@@ -0,0 +1,17 @@
+//go:build unix
+
+package staging
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// returns the inode number and device of a file
+func file_identity(info fs.FileInfo) (inode, device uint64) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		inode = uint64(stat.Ino)
+		device = uint64(stat.Dev)
+	}
+	return
+}
@@ -21,11 +21,12 @@ var (
 
 // File is a temporary structure used when converting the index into trees
 type File struct {
-	Prefix cas.Prefix
-	Name   string
-	Mode   revision.FileMode
-	Tree   *Tree
-	File   cas.ContentID
+	Prefix   cas.Prefix
+	Name     string
+	Mode     revision.FileMode
+	Metadata revision.FileMetadata
+	Tree     *Tree
+	File     cas.ContentID
 }
 
 // Tree is a temporary structure used when converting the index into trees
@@ -46,6 +47,7 @@ func (cache_tree *Tree) Store(objects *cas.Set) (root cas.ContentID, err error)
 		revision_file.Prefix = cache_file.Prefix
 		revision_file.Name = cache_file.Name
 		revision_file.Mode = cache_file.Mode
+		revision_file.Metadata = cache_file.Metadata
 
 		if cache_file.Prefix == cas.Tree {
 			// store subdirectory as a tree
@@ -59,7 +61,7 @@ func (cache_tree *Tree) Store(objects *cas.Set) (root cas.ContentID, err error)
 			}
 			revision_file.Content = revision_file_tree
 			revision_tree.Entries = append(revision_tree.Entries, revision_file)
-		} else if cache_file.Prefix == cas.File {
+		} else if cache_file.Prefix == cas.File || cache_file.Prefix == cas.Link {
 			// TODO: write content
 			revision_file.Content = cache_file.File
 			revision_tree.Entries = append(revision_tree.Entries, revision_file)
@@ -127,17 +129,19 @@ func (tree *Tree) build_index_entry(linked_file *IndexEntry) (err error) {
 	if entry_index < len(current_tree.Files) && current_tree.Files[entry_index].Name == filename {
 		// found
 		file := &current_tree.Files[entry_index]
-		file.Prefix = cas.File
+		file.Prefix = linked_file.Prefix
 		file.Name = filename
 		file.Mode = linked_file.Mode
+		file.Metadata = linked_file.Metadata
 		file.File = linked_file.File
 
 		// err = ErrTreeFileAlreadyLinked
 	} else {
 		var file File
-		file.Prefix = cas.File
+		file.Prefix = linked_file.Prefix
 		file.Name = filename
 		file.Mode = linked_file.Mode
+		file.Metadata = linked_file.Metadata
 		file.File = linked_file.File
 		current_tree.Files = slices.Insert(current_tree.Files, entry_index, file)
 	}
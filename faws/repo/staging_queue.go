@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/faws-vcs/faws/faws/multipart"
+)
+
+// a regular file waiting to be cached by a staging worker
+type staging_job struct {
+	destination string
+	source      string
+	source_info os.FileInfo
+	// the entry already in the index at the destination, if there is one
+	previous     staging_index_entry
+	has_previous bool
+	// the results of caching the file
+	entry              staging_index_entry
+	lazy_signature     multipart.LazySignature
+	got_lazy_signature bool
+	// false if the job was skipped because another worker failed
+	done bool
+	err  error
+}
+
+// staging_queue distributes the files found while walking the source to a pool of workers,
+// which chunk and store them concurrently.
+//
+// The index is only updated once every worker has finished, in the order the files were found,
+// so the result is the same no matter which worker finishes first.
+type staging_queue struct {
+	repo    *Repository
+	options *staging_options
+	jobs    chan *staging_job
+	// every job, in the order it was pushed
+	queued      []*staging_job
+	workers     sync.WaitGroup
+	stop_signal atomic.Bool
+}
+
+func (queue *staging_queue) start(repo *Repository, options *staging_options) {
+	queue.repo = repo
+	queue.options = options
+
+	num_workers := options.workers
+	if num_workers <= 0 {
+		num_workers = runtime.NumCPU()
+	}
+	queue.jobs = make(chan *staging_job, num_workers)
+
+	for range num_workers {
+		queue.workers.Add(1)
+		go queue.work()
+	}
+}
+
+func (queue *staging_queue) work() {
+	defer queue.workers.Done()
+
+	for job := range queue.jobs {
+		if queue.failed() {
+			// drain the queue without doing any more work
+			continue
+		}
+		if job.err = queue.repo.cache_file(queue.options, job); job.err != nil {
+			queue.stop_signal.Store(true)
+			continue
+		}
+		job.done = true
+	}
+}
+
+// returns true if any worker has failed
+func (queue *staging_queue) failed() bool {
+	return queue.stop_signal.Load()
+}
+
+// adds a file to the queue, blocking while every worker is busy
+func (queue *staging_queue) push(job *staging_job) {
+	queue.queued = append(queue.queued, job)
+	queue.jobs <- job
+}
+
+// waits for the workers to finish, then adds the cached files to the index
+//
+// if any file could not be cached, the files queued before it are still added, and its error is returned
+func (queue *staging_queue) finish() (err error) {
+	close(queue.jobs)
+	queue.workers.Wait()
+
+	for _, job := range queue.queued {
+		if job.err != nil {
+			err = job.err
+			break
+		}
+	}
+
+	index := &queue.repo.index
+	for _, job := range queue.queued {
+		if !job.done {
+			break
+		}
+		index.entries[job.destination] = job.entry
+		if job.got_lazy_signature {
+			index.lazy_signatures[job.lazy_signature] = job.entry.file
+		}
+	}
+	return
+}
@@ -0,0 +1,38 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrPackNameEmpty             = fmt.Errorf("faws/validate: pack name cannot be empty")
+	ErrPackNameTooLong           = fmt.Errorf("faws/validate: pack name is too long")
+	ErrPackNameInvalidCharacters = fmt.Errorf("faws/validate: pack name contains illegal characters")
+)
+
+func is_invalid_pack_name_character(r rune) bool {
+	return !(is_letter(r) || is_digit(r) || r == '_' || r == '-')
+}
+
+// PackName returns an error if name cannot be used to name a pack.
+//
+// Pack names may not contain '.', as it separates the name of the pack from the number of each archive.
+func PackName(name string) (err error) {
+	if name == "" {
+		err = ErrPackNameEmpty
+		return
+	}
+
+	if len(name) > 120 {
+		err = ErrPackNameTooLong
+		return
+	}
+
+	if strings.ContainsFunc(name, is_invalid_pack_name_character) {
+		err = ErrPackNameInvalidCharacters
+		return
+	}
+
+	return
+}
@@ -8,6 +8,7 @@ require (
 	github.com/dustin/go-humanize v1.0.1
 	github.com/faws-vcs/console v0.0.0-20260131025334-e632c3e5b6a0
 	github.com/google/btree v1.1.3
+	github.com/klauspost/compress v1.18.0
 	github.com/restic/chunker v0.4.1-0.20231001122857-ac4c622f4b08
 	github.com/spf13/cobra v1.9.1
 	github.com/spf13/pflag v1.0.6